@@ -17,6 +17,12 @@ func RegisterRoutes(r *gin.Engine, services *service.Services) {
 		// 状态检查
 		public.GET("/health", HealthCheck)
 
+		// 系统信息路由
+		system := public.Group("/system")
+		{
+			system.GET("/file-formats", HandleGetFileFormats)
+		}
+
 		// 认证相关路由
 		auth := public.Group("/auth")
 		{
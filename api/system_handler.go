@@ -0,0 +1,18 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ljx520ljx/chartSystem/pkg/fileproc"
+)
+
+// HandleGetFileFormats 获取支持的文件格式
+func HandleGetFileFormats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"supported_formats": fileproc.Formats(),
+		},
+	})
+}
@@ -1,15 +1,17 @@
 package app
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"strconv"
 
 	"fyne.io/fyne/v2"
-	"github.com/liujiaxin/chartSystem/internal/config"
-	"github.com/liujiaxin/chartSystem/internal/data"
-	"github.com/liujiaxin/chartSystem/internal/ui"
-	"github.com/liujiaxin/chartSystem/pkg/fileio"
+	"github.com/ljx520ljx/chartSystem/internal/config"
+	"github.com/ljx520ljx/chartSystem/internal/data"
+	"github.com/ljx520ljx/chartSystem/internal/ui"
+	"github.com/ljx520ljx/chartSystem/pkg/fileio"
+	"github.com/ljx520ljx/chartSystem/pkg/fileproc"
 )
 
 // App 表示图表应用程序
@@ -30,6 +32,13 @@ func NewApp(theFyneApp fyne.App) (*App, error) {
 		cfg = getDefaultConfig()
 	}
 
+	// 注册config/formats目录中的自定义二进制格式
+	if n, err := fileproc.RegisterDescriptorDir("config/formats"); err != nil {
+		log.Printf("自定义格式加载失败: %v", err)
+	} else if n > 0 {
+		log.Printf("已注册%d个自定义格式", n)
+	}
+
 	// 创建数据模型
 	dataModel := data.NewDataModel()
 
@@ -69,37 +78,47 @@ func (a *App) Run() error {
 
 // LoadEDFFile 加载EDF文件
 func (a *App) LoadEDFFile(path string) error {
+	return a.LoadFile(path)
+}
+
+// LoadFile 自动检测文件格式并加载信号文件
+func (a *App) LoadFile(path string) error {
 	// 检查文件是否存在
 	_, err := os.Stat(path)
 	if os.IsNotExist(err) {
 		return err
 	}
 
-	// 打开EDF文件
-	edfReader, err := fileio.OpenEDF(path)
+	// 根据文件内容检测格式并打开
+	reader, err := fileproc.Open(path)
 	if err != nil {
 		return err
 	}
-	defer edfReader.Close()
+	defer reader.Close()
 
-	// 获取信号数量
-	numSignals := edfReader.GetNumSignals()
+	meta := reader.Metadata()
+	for _, warning := range meta.Warnings {
+		log.Printf("%s文件警告: %s", meta.Format, warning)
+	}
 
 	// 清空数据模型
 	a.DataModel = data.NewDataModel()
 
-	// 加载每个信号到通道
-	for i := 0; i < numSignals && i < 4; i++ {
-		// 获取信号信息
-		label, _, physMin, physMax := edfReader.GetChannelInfo(i) // 忽略未使用的physDim变量
+	// 为前4个信号创建通道
+	var indices []int
+	var channels []*data.Channel
+	for _, signal := range meta.Signals {
+		if len(channels) >= 4 {
+			break
+		}
 
 		// 创建通道
-		channel := data.NewChannel(strconv.Itoa(i), label)
-		channel.YAxisMin = physMin
-		channel.YAxisMax = physMax
+		channel := data.NewChannel(strconv.Itoa(signal.Index), signal.Name)
+		channel.YAxisMin = signal.PhysicalMin
+		channel.YAxisMax = signal.PhysicalMax
 
 		// 设置颜色
-		switch i {
+		switch len(channels) {
 		case 0:
 			channel.Color = "#FF0000" // 红色
 		case 1:
@@ -110,13 +129,17 @@ func (a *App) LoadEDFFile(path string) error {
 			channel.Color = "#FFFF00" // 黄色
 		}
 
-		// 加载信号数据
-		if err := edfReader.LoadSignalToChannel(i, channel); err != nil {
-			log.Printf("加载信号%d失败: %v", i, err)
-			continue
-		}
+		indices = append(indices, signal.Index)
+		channels = append(channels, channel)
+	}
+
+	// 一次读取所有通道的信号数据
+	if err := fileproc.LoadChannels(reader, indices, channels); err != nil {
+		return fmt.Errorf("加载信号数据失败: %w", err)
+	}
 
-		// 添加通道到数据模型
+	// 添加通道到数据模型
+	for _, channel := range channels {
 		a.DataModel.AddChannel(channel)
 	}
 
@@ -2,6 +2,7 @@ package data
 
 import (
 	"strconv"
+	"time"
 )
 
 // DataPoint 表示一个数据点
@@ -10,12 +11,21 @@ type DataPoint struct {
 	Y float64
 }
 
+// Gap 表示数据中的间断区间[Start, End)，区间内没有采样数据
+type Gap struct {
+	Start float64
+	End   float64
+}
+
 // Channel 表示一个数据通道
 type Channel struct {
 	ID            string
 	Name          string
 	Data          []DataPoint
 	ProcessedData []DataPoint
+	Gaps          []Gap     // 数据间断（如EDF+D文件中记录之间的空白）
+	StartTime     time.Time // X=0对应的绝对时间，零值表示未知
+	SampleRate    float64   // 采样率（Hz），0表示未知
 	Visible       bool
 	Color         string
 	Scale         float64
@@ -47,6 +57,22 @@ func (c *Channel) AddDataPoint(x, y float64) {
 func (c *Channel) ClearData() {
 	c.Data = make([]DataPoint, 0)
 	c.ProcessedData = make([]DataPoint, 0)
+	c.Gaps = nil
+}
+
+// AbsoluteTime 将通道内的相对时间（秒）转换为绝对时间
+func (c *Channel) AbsoluteTime(x float64) time.Time {
+	return c.StartTime.Add(time.Duration(x * float64(time.Second)))
+}
+
+// SpansGap 判断两个相邻数据点之间是否跨越数据间断
+func (c *Channel) SpansGap(x1, x2 float64) bool {
+	for _, g := range c.Gaps {
+		if x1 <= g.Start && x2 >= g.End {
+			return true
+		}
+	}
+	return false
 }
 
 // DataModel 表示应用程序的数据模型
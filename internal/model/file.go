@@ -12,6 +12,10 @@ type File struct {
 	FilePath     string         `json:"file_path" gorm:"size:500;not null"`
 	FileSize     int64          `json:"file_size" gorm:"not null"`
 	ContentType  string         `json:"content_type" gorm:"size:100;not null"`
+	Format       string         `json:"format" gorm:"size:50"` // 自动检测到的文件格式ID，如"edf"
+	ArchiveName  string         `json:"archive_name,omitempty" gorm:"size:255;index"` // 来自压缩包时为上传的压缩包名，同一压缩包中的文件FilePath相同
+	ArchiveEntry string         `json:"archive_entry,omitempty" gorm:"size:500"` // 记录主文件在压缩包中的路径
+	Deidentified bool           `json:"deidentified" gorm:"default:false"` // 入库前是否已去标识化
 	UserID       uint           `json:"user_id" gorm:"not null"`
 	DataChannels []DataChannel  `json:"data_channels,omitempty" gorm:"foreignKey:FileID"`
 	Processing   *FileProcessing `json:"processing,omitempty" gorm:"foreignKey:FileID"`
@@ -80,3 +84,16 @@ type Analysis struct {
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
+
+// FormatDescriptor 自定义二进制格式描述模型
+// UserID为0时为系统级描述，对所有用户可用；否则只对该用户可用。
+type FormatDescriptor struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id" gorm:"not null;default:0;uniqueIndex:idx_format_descriptor_user_format"`
+	FormatID    string    `json:"format_id" gorm:"size:50;not null;uniqueIndex:idx_format_descriptor_user_format"`
+	Name        string    `json:"name" gorm:"size:100;not null"`
+	Description string    `json:"description" gorm:"size:500"`
+	Content     string    `json:"content" gorm:"type:text;not null"` // 描述文件内容（XML或JSON）
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,98 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sort"
+
+	"github.com/ljx520ljx/chartSystem/pkg/signal"
+	"github.com/ljx520ljx/chartSystem/pkg/util"
+)
+
+// 对数标度时频图显示的动态范围（dB），低于最大值该范围的部分显示为最低颜色
+const spectrogramDynamicRange = 80.0
+
+// 热力图色标，从低到高依次为深蓝、蓝、青、黄、红
+var heatColors = []color.RGBA{
+	{0, 0, 128, 255},
+	{0, 0, 255, 255},
+	{0, 255, 255, 255},
+	{255, 255, 0, 255},
+	{255, 0, 0, 255},
+}
+
+// RenderSpectrogram 将时频图渲染为热力图
+// 横轴为时间，与波形使用相同的视口（OffsetX、ScaleX）；纵轴为频率，底部为最低频率。
+// 颜色范围取时频图的最小值到最大值，对数标度时最多显示80dB的动态范围。
+func (r *Renderer) RenderSpectrogram(spec *signal.Spectrogram, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, r.Width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{r.BackColor}, image.Point{}, draw.Src)
+	if spec == nil || len(spec.Values) == 0 || len(spec.Frequencies) == 0 || height <= 0 {
+		return img
+	}
+
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, row := range spec.Values {
+		for _, v := range row {
+			lo = math.Min(lo, v)
+			hi = math.Max(hi, v)
+		}
+	}
+	if spec.Scale == signal.ScaleLog {
+		lo = math.Max(lo, hi-spectrogramDynamicRange)
+	}
+	if hi <= lo {
+		hi = lo + 1
+	}
+
+	fMin := spec.Frequencies[0]
+	fMax := spec.Frequencies[len(spec.Frequencies)-1]
+	halfStep := spec.FrameStep / 2
+	for x := 0; x < r.Width; x++ {
+		// 像素列对应的时间，取最近的一帧
+		t := float64(x)/r.ScaleX + r.OffsetX
+		if t < spec.Times[0]-halfStep || t > spec.Times[len(spec.Times)-1]+halfStep {
+			continue
+		}
+		i := nearestIndex(spec.Times, t)
+
+		for y := 0; y < height; y++ {
+			f := fMax - (float64(y)+0.5)/float64(height)*(fMax-fMin)
+			j := 0
+			if spec.Resolution > 0 {
+				j = int(math.Round((f - fMin) / spec.Resolution))
+			}
+			if j < 0 || j >= len(spec.Frequencies) {
+				continue
+			}
+			img.SetRGBA(x, y, heatColor(util.Clamp((spec.Values[i][j]-lo)/(hi-lo), 0, 1)))
+		}
+	}
+	return img
+}
+
+// 返回有序切片中与v最接近的元素下标
+func nearestIndex(sorted []float64, v float64) int {
+	i := sort.SearchFloat64s(sorted, v)
+	if i == len(sorted) || (i > 0 && v-sorted[i-1] < sorted[i]-v) {
+		i--
+	}
+	return i
+}
+
+// 将0到1之间的值映射为热力图颜色，在色标之间线性插值
+func heatColor(v float64) color.RGBA {
+	pos := v * float64(len(heatColors)-1)
+	k := int(pos)
+	if k >= len(heatColors)-1 {
+		return heatColors[len(heatColors)-1]
+	}
+	frac := pos - float64(k)
+	c0, c1 := heatColors[k], heatColors[k+1]
+	mix := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a) + frac*(float64(b)-float64(a))))
+	}
+	return color.RGBA{mix(c0.R, c1.R), mix(c0.G, c1.G), mix(c0.B, c1.B), 255}
+}
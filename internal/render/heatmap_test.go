@@ -0,0 +1,107 @@
+package render
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/ljx520ljx/chartSystem/pkg/signal"
+)
+
+// 两帧、四个频点的线性标度时频图，帧中心为0秒和1秒
+func testSpectrogram() *signal.Spectrogram {
+	return &signal.Spectrogram{
+		Times:       []float64{0, 1},
+		Frequencies: []float64{0, 1, 2, 3},
+		Values:      [][]float64{{0, 1, 2, 3}, {3, 3, 3, 3}},
+		Scale:       signal.ScaleLinear,
+		Resolution:  1,
+		FrameStep:   1,
+	}
+}
+
+func TestRenderSpectrogram(t *testing.T) {
+	r := NewRenderer(4, 100)
+	r.ScaleX = 2 // 每个像素0.5秒
+
+	img := r.RenderSpectrogram(testSpectrogram(), 4)
+	if b := img.Bounds(); b.Dx() != 4 || b.Dy() != 4 {
+		t.Fatalf("Bounds = %v", b)
+	}
+
+	// 第一帧从上到下为最高频率到最低频率
+	red, darkBlue := heatColors[len(heatColors)-1], heatColors[0]
+	column := []color.RGBA{red, {170, 255, 85, 255}, {0, 85, 255, 255}, darkBlue}
+	for y, want := range column {
+		if got := img.RGBAAt(0, y); got != want {
+			t.Errorf("(0, %d) = %v, want %v", y, got, want)
+		}
+	}
+	// 第二帧的值都为最大值
+	for y := 0; y < 4; y++ {
+		if got := img.RGBAAt(3, y); got != red {
+			t.Errorf("(3, %d) = %v, want %v", y, got, red)
+		}
+	}
+
+	// 时频图时间范围之外的像素列保持背景色
+	r.OffsetX = -2
+	img = r.RenderSpectrogram(testSpectrogram(), 4)
+	if got := img.RGBAAt(0, 0); got != r.BackColor {
+		t.Errorf("范围外的像素 = %v, want %v", got, r.BackColor)
+	}
+	if got := img.RGBAAt(3, 0); got != red {
+		t.Errorf("(3, 0) = %v, want %v", got, red)
+	}
+}
+
+func TestRenderSpectrogramLogRange(t *testing.T) {
+	// 对数标度时低于最大值80dB的部分都显示为最低颜色
+	spec := testSpectrogram()
+	spec.Scale = signal.ScaleLog
+	spec.Values = [][]float64{{-300, -200, -40, 0}, {0, 0, 0, 0}}
+
+	r := NewRenderer(1, 100)
+	img := r.RenderSpectrogram(spec, 4)
+	want := []color.RGBA{heatColors[4], heatColors[2], heatColors[0], heatColors[0]}
+	for y := range want {
+		if got := img.RGBAAt(0, y); got != want[y] {
+			t.Errorf("(0, %d) = %v, want %v", y, got, want[y])
+		}
+	}
+}
+
+func TestRenderSpectrogramEmpty(t *testing.T) {
+	r := NewRenderer(3, 100)
+	for _, spec := range []*signal.Spectrogram{nil, {}} {
+		img := r.RenderSpectrogram(spec, 2)
+		if b := img.Bounds(); b.Dx() != 3 || b.Dy() != 2 || img.RGBAAt(1, 1) != r.BackColor {
+			t.Errorf("空时频图应只绘制背景: %v", b)
+		}
+	}
+}
+
+func TestHeatColor(t *testing.T) {
+	tests := []struct {
+		v    float64
+		want color.RGBA
+	}{
+		{0, heatColors[0]},
+		{0.125, color.RGBA{0, 0, 192, 255}},
+		{0.5, heatColors[2]},
+		{1, heatColors[4]},
+	}
+	for _, tt := range tests {
+		if got := heatColor(tt.v); got != tt.want {
+			t.Errorf("heatColor(%g) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestNearestIndex(t *testing.T) {
+	sorted := []float64{0, 1, 3}
+	for v, want := range map[float64]int{-1: 0, 0.4: 0, 0.6: 1, 2.1: 2, 10: 2} {
+		if got := nearestIndex(sorted, v); got != want {
+			t.Errorf("nearestIndex(%g) = %d, want %d", v, got, want)
+		}
+	}
+}
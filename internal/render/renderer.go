@@ -7,9 +7,9 @@ import (
 	"math"
 	"sort"
 
-	"github.com/liujiaxin/chartSystem/internal/config"
-	"github.com/liujiaxin/chartSystem/internal/data"
-	"github.com/liujiaxin/chartSystem/pkg/util"
+	"github.com/ljx520ljx/chartSystem/internal/config"
+	"github.com/ljx520ljx/chartSystem/internal/data"
+	"github.com/ljx520ljx/chartSystem/pkg/util"
 )
 
 // Renderer 负责将数据渲染为图像
@@ -186,6 +186,11 @@ func (r *Renderer) drawWaveform(img *image.RGBA, channel *data.Channel) {
 			x2 := int((channelData[i+1].X - r.OffsetX) * r.ScaleX)
 			y2 := height - int((channelData[i+1].Y-channel.YAxisMin)*yScale)
 
+			// 不在数据间断两侧之间连线
+			if channel.SpansGap(channelData[i].X, channelData[i+1].X) {
+				continue
+			}
+
 			// 确保坐标在有效范围内
 			if x1 >= 0 && x1 < r.Width && y1 >= 0 && y1 < height &&
 				x2 >= 0 && x2 < r.Width && y2 >= 0 && y2 < height {
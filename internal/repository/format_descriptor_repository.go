@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/ljx520ljx/chartSystem/internal/model"
+	"gorm.io/gorm"
+)
+
+// FormatDescriptorRepoImpl 自定义格式描述存储库实现
+type FormatDescriptorRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewFormatDescriptorRepository 创建自定义格式描述存储库
+func NewFormatDescriptorRepository(db *gorm.DB) FormatDescriptorRepository {
+	return &FormatDescriptorRepoImpl{db: db}
+}
+
+// Create 创建格式描述
+func (r *FormatDescriptorRepoImpl) Create(desc *model.FormatDescriptor) error {
+	return r.db.Create(desc).Error
+}
+
+// GetByID 通过ID获取格式描述
+func (r *FormatDescriptorRepoImpl) GetByID(id uint) (*model.FormatDescriptor, error) {
+	var desc model.FormatDescriptor
+	result := r.db.First(&desc, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("格式描述不存在")
+		}
+		return nil, result.Error
+	}
+	return &desc, nil
+}
+
+// GetByFormatID 通过格式ID获取用户可用的格式描述，用户自己的描述优先于系统级描述
+func (r *FormatDescriptorRepoImpl) GetByFormatID(userID uint, formatID string) (*model.FormatDescriptor, error) {
+	var desc model.FormatDescriptor
+	result := r.db.Where("format_id = ? AND user_id IN ?", formatID, []uint{userID, 0}).
+		Order("user_id DESC").First(&desc)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("格式描述不存在")
+		}
+		return nil, result.Error
+	}
+	return &desc, nil
+}
+
+// Update 更新格式描述
+func (r *FormatDescriptorRepoImpl) Update(desc *model.FormatDescriptor) error {
+	return r.db.Save(desc).Error
+}
+
+// Delete 删除格式描述
+func (r *FormatDescriptorRepoImpl) Delete(id uint) error {
+	return r.db.Delete(&model.FormatDescriptor{}, id).Error
+}
+
+// ListByUser 获取用户可用的格式描述，包括用户自己的和系统级的
+// 用户自己的描述排在前面，格式ID相同时应使用先出现的描述。
+func (r *FormatDescriptorRepoImpl) ListByUser(userID uint) ([]*model.FormatDescriptor, error) {
+	var descs []*model.FormatDescriptor
+	if err := r.db.Where("user_id IN ?", []uint{userID, 0}).Order("user_id DESC, format_id").Find(&descs).Error; err != nil {
+		return nil, err
+	}
+	return descs, nil
+}
+
+// ListSystem 获取系统级格式描述
+func (r *FormatDescriptorRepoImpl) ListSystem() ([]*model.FormatDescriptor, error) {
+	var descs []*model.FormatDescriptor
+	if err := r.db.Where("user_id = ?", 0).Order("format_id").Find(&descs).Error; err != nil {
+		return nil, err
+	}
+	return descs, nil
+}
@@ -13,6 +13,7 @@ type Repositories struct {
 	DataChannel DataChannelRepository
 	Role        RoleRepository
 	Analysis    AnalysisRepository
+	Format      FormatDescriptorRepository
 	db          *gorm.DB
 	rdb         *redis.Client
 }
@@ -25,6 +26,7 @@ func NewRepositories(db *gorm.DB, rdb *redis.Client) *Repositories {
 		DataChannel: NewDataChannelRepository(db),
 		Role:        NewRoleRepository(db),
 		Analysis:    NewAnalysisRepository(db),
+		Format:      NewFormatDescriptorRepository(db),
 		db:          db,
 		rdb:         rdb,
 	}
@@ -78,3 +80,14 @@ type AnalysisRepository interface {
 	Update(analysis *model.Analysis) error
 	Delete(id uint) error
 }
+
+// FormatDescriptorRepository 自定义格式描述存储库接口
+type FormatDescriptorRepository interface {
+	Create(desc *model.FormatDescriptor) error
+	GetByID(id uint) (*model.FormatDescriptor, error)
+	GetByFormatID(userID uint, formatID string) (*model.FormatDescriptor, error)
+	Update(desc *model.FormatDescriptor) error
+	Delete(id uint) error
+	ListByUser(userID uint) ([]*model.FormatDescriptor, error)
+	ListSystem() ([]*model.FormatDescriptor, error)
+}
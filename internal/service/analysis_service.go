@@ -0,0 +1,180 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+	"github.com/ljx520ljx/chartSystem/internal/model"
+	"github.com/ljx520ljx/chartSystem/internal/repository"
+	"github.com/ljx520ljx/chartSystem/pkg/fileproc"
+	"github.com/ljx520ljx/chartSystem/pkg/signal"
+)
+
+// 分析类型，对应Analysis.Type
+const (
+	AnalysisTypeHRV = "hrv"
+)
+
+// HRVAnalysisParams 心率变异性分析的参数，对应Analysis.Parameters
+type HRVAnalysisParams struct {
+	ChannelID uint `json:"channel_id"` // 用于检测心搏的ECG通道
+}
+
+// AnalysisServiceImpl 分析服务实现
+type AnalysisServiceImpl struct {
+	repos *repository.Repositories
+}
+
+// NewAnalysisService 创建分析服务
+func NewAnalysisService(repos *repository.Repositories) AnalysisService {
+	return &AnalysisServiceImpl{repos: repos}
+}
+
+// CreateAnalysis 创建分析
+func (s *AnalysisServiceImpl) CreateAnalysis(analysis *model.Analysis) error {
+	if !isSupportedAnalysis(analysis.Type) {
+		return fmt.Errorf("不支持的分析类型: %s", analysis.Type)
+	}
+	if analysis.EndTime > 0 && analysis.EndTime <= analysis.StartTime {
+		return errors.New("分析的结束时间必须大于开始时间")
+	}
+	return s.repos.Analysis.Create(analysis)
+}
+
+// GetByID 通过ID获取分析
+func (s *AnalysisServiceImpl) GetByID(id uint) (*model.Analysis, error) {
+	return s.repos.Analysis.GetByID(id)
+}
+
+// GetByFileID 获取文件的所有分析
+func (s *AnalysisServiceImpl) GetByFileID(fileID uint) ([]*model.Analysis, error) {
+	return s.repos.Analysis.GetByFileID(fileID)
+}
+
+// UpdateAnalysis 更新分析
+func (s *AnalysisServiceImpl) UpdateAnalysis(analysis *model.Analysis) error {
+	return s.repos.Analysis.Update(analysis)
+}
+
+// DeleteAnalysis 删除分析
+func (s *AnalysisServiceImpl) DeleteAnalysis(id uint) error {
+	return s.repos.Analysis.Delete(id)
+}
+
+// RunAnalysis 在分析的时间范围[StartTime, EndTime)内执行分析，结果以JSON保存到Results
+// EndTime不大于StartTime时分析到记录结束。
+func (s *AnalysisServiceImpl) RunAnalysis(analysisID uint) error {
+	analysis, err := s.repos.Analysis.GetByID(analysisID)
+	if err != nil {
+		return err
+	}
+
+	var result interface{}
+	switch analysis.Type {
+	case AnalysisTypeHRV:
+		result, err = s.runHRV(analysis)
+	default:
+		return fmt.Errorf("不支持的分析类型: %s", analysis.Type)
+	}
+	if err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("序列化分析结果失败: %w", err)
+	}
+	analysis.Results = string(content)
+	return s.repos.Analysis.Update(analysis)
+}
+
+// 判断是否支持该分析类型
+func isSupportedAnalysis(analysisType string) bool {
+	switch analysisType {
+	case AnalysisTypeHRV:
+		return true
+	}
+	return false
+}
+
+// 执行心率变异性分析
+func (s *AnalysisServiceImpl) runHRV(analysis *model.Analysis) (*signal.HRVResult, error) {
+	var params HRVAnalysisParams
+	if analysis.Parameters != "" {
+		if err := json.Unmarshal([]byte(analysis.Parameters), &params); err != nil {
+			return nil, fmt.Errorf("解析分析参数失败: %w", err)
+		}
+	}
+	if params.ChannelID == 0 {
+		return nil, errors.New("未指定分析的通道")
+	}
+
+	channel, sampleRate, err := s.loadChannel(analysis.FileID, params.ChannelID, analysis.StartTime, analysis.EndTime)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := signal.NewProcessor(sampleRate).AnalyzeHRV(channel)
+	if err != nil {
+		return nil, fmt.Errorf("心率变异性分析失败: %w", err)
+	}
+	return result, nil
+}
+
+// 读取文件中某个通道在时间范围[startTime, endTime)内的数据及其采样率，endTime不大于startTime时读到记录结束
+func (s *AnalysisServiceImpl) loadChannel(fileID, channelID uint, startTime, endTime float64) (*data.Channel, float64, error) {
+	channel, err := s.repos.DataChannel.GetByID(channelID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if channel.FileID != fileID {
+		return nil, 0, fmt.Errorf("通道%d不属于文件%d", channelID, fileID)
+	}
+	file, err := s.repos.File.GetByID(fileID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 用户自定义的格式优先于已注册的格式
+	descs, err := s.repos.Format.ListByUser(file.UserID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("加载自定义格式失败: %w", err)
+	}
+	reader, err := fileproc.OpenUserFile(file, descs)
+	if err != nil {
+		return nil, 0, fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer reader.Close()
+
+	ch := data.NewChannel(fmt.Sprint(channel.ID), channel.Name)
+	if startTime < 0 {
+		startTime = 0
+	}
+
+	// 读取整个记录时使用LoadChannel，格式支持时一次遍历文件
+	if startTime == 0 && endTime <= 0 {
+		if err := fileproc.LoadChannel(reader, int(channel.DataOffset), ch); err != nil {
+			return nil, 0, fmt.Errorf("读取通道数据失败: %w", err)
+		}
+		ch.SampleRate = channel.SampleRate
+		return ch, channel.SampleRate, nil
+	}
+
+	meta := reader.Metadata()
+	if endTime <= startTime {
+		endTime = meta.Duration
+	}
+
+	points, err := reader.ReadWindow(int(channel.DataOffset), startTime, endTime)
+	if err != nil {
+		return nil, 0, fmt.Errorf("读取通道数据失败: %w", err)
+	}
+
+	ch.Data = points
+	ch.Gaps = meta.Gaps
+	ch.StartTime = meta.StartTime
+	ch.SampleRate = channel.SampleRate
+	return ch, channel.SampleRate, nil
+}
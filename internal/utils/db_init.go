@@ -40,6 +40,7 @@ func migrateSchema(db *gorm.DB) error {
 		&model.FileProcessing{},
 		&model.Marker{},
 		&model.Analysis{},
+		&model.FormatDescriptor{},
 	)
 }
 
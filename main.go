@@ -13,6 +13,8 @@ import (
 	"github.com/ljx520ljx/chartSystem/internal/repository"
 	"github.com/ljx520ljx/chartSystem/internal/service"
 	"github.com/ljx520ljx/chartSystem/internal/utils"
+	"github.com/ljx520ljx/chartSystem/pkg/dataproc"
+	"github.com/ljx520ljx/chartSystem/pkg/fileproc"
 )
 
 func main() {
@@ -41,6 +43,9 @@ func main() {
 	// 初始化存储库
 	repos := repository.NewRepositories(db, rdb)
 
+	// 注册系统级自定义二进制格式
+	registerSystemFormats(repos)
+
 	// 初始化服务
 	services := service.NewServices(repos)
 
@@ -83,3 +88,22 @@ func main() {
 		log.Fatalf("服务器启动失败: %v", err)
 	}
 }
+
+// registerSystemFormats 注册数据库中保存的系统级自定义二进制格式
+func registerSystemFormats(repos *repository.Repositories) {
+	descs, err := repos.Format.ListSystem()
+	if err != nil {
+		log.Printf("加载系统自定义格式失败: %v", err)
+		return
+	}
+
+	for _, d := range descs {
+		desc, err := dataproc.ParseDescriptor([]byte(d.Content))
+		if err == nil {
+			err = fileproc.RegisterDescriptor(desc)
+		}
+		if err != nil {
+			log.Printf("注册自定义格式%s失败: %v", d.FormatID, err)
+		}
+	}
+}
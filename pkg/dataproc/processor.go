@@ -0,0 +1,524 @@
+// Package dataproc 提供由格式描述文件驱动的原始二进制数据读取
+//
+// 格式描述文件使用与config.xml相同风格的XML（也可使用JSON），例如：
+//
+//	<BinaryFormat id="acme-dump">
+//	    <Name>ACME监护仪导出</Name>
+//	    <Extension>.acm</Extension>
+//	    <Magic offset="0">41434D45</Magic>
+//	    <HeaderSize>256</HeaderSize>
+//	    <SampleType>int16</SampleType>
+//	    <ByteOrder>little</ByteOrder>
+//	    <Layout>interleaved</Layout>
+//	    <SampleRate>250</SampleRate>
+//	    <Channels>
+//	        <Channel>
+//	            <Name>ECG II</Name>
+//	            <Unit>mV</Unit>
+//	            <Gain>0.005</Gain>
+//	            <SampleRate>500</SampleRate>
+//	        </Channel>
+//	        <Channel>
+//	            <Name>Resp</Name>
+//	            <Unit>Ohm</Unit>
+//	            <Gain>0.1</Gain>
+//	            <Offset>-100</Offset>
+//	        </Channel>
+//	    </Channels>
+//	</BinaryFormat>
+package dataproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+// 数据布局
+const (
+	LayoutInterleaved = "interleaved" // 按帧交错存放，每帧依次包含各通道的样本
+	LayoutSequential  = "sequential"  // 各通道的样本连续存放，一个通道接一个通道
+)
+
+// 字节序
+const (
+	ByteOrderLittle = "little"
+	ByteOrderBig    = "big"
+)
+
+// 每次从文件读取的最大字节数
+const maxReadBytes = 1 << 20
+
+// 采样类型对应的样本字节数
+var sampleTypeSizes = map[string]int{
+	"int8": 1, "uint8": 1,
+	"int16": 2, "uint16": 2,
+	"int24": 3, "uint24": 3,
+	"int32": 4, "uint32": 4,
+	"float32": 4, "float64": 8,
+}
+
+// FormatDescriptor 描述一种原始二进制数据文件的布局
+type FormatDescriptor struct {
+	XMLName     xml.Name            `xml:"BinaryFormat" json:"-"`
+	ID          string              `xml:"id,attr" json:"format_id"`
+	Name        string              `xml:"Name" json:"name"`
+	Description string              `xml:"Description,omitempty" json:"description,omitempty"`
+	Extension   string              `xml:"Extension,omitempty" json:"extension,omitempty"` // 如".acm"，用于自动检测
+	Magic       *MagicDescriptor    `xml:"Magic,omitempty" json:"magic,omitempty"`         // 文件头标识，用于自动检测
+	HeaderSize  int64               `xml:"HeaderSize" json:"header_size"`                  // 数据前跳过的字节数
+	FooterSize  int64               `xml:"FooterSize,omitempty" json:"footer_size,omitempty"`
+	SampleType  string              `xml:"SampleType" json:"sample_type"`                   // int8/16/24/32、uint8/16/24/32、float32/64
+	ByteOrder   string              `xml:"ByteOrder,omitempty" json:"byte_order,omitempty"` // little或big，默认little
+	Layout      string              `xml:"Layout,omitempty" json:"layout,omitempty"`        // interleaved或sequential，默认interleaved
+	SampleRate  float64             `xml:"SampleRate" json:"sample_rate"`                   // 帧频率（Hz）
+	Channels    []ChannelDescriptor `xml:"Channels>Channel" json:"channels"`
+}
+
+// MagicDescriptor 表示文件头中用于识别格式的固定字节
+type MagicDescriptor struct {
+	Offset int64  `xml:"offset,attr" json:"offset"`
+	Value  string `xml:",chardata" json:"value"` // 十六进制表示的字节
+}
+
+// ChannelDescriptor 描述文件中的一个通道
+// 物理值 = 原始值 * Gain + Offset。
+type ChannelDescriptor struct {
+	Name       string  `xml:"Name" json:"name"`
+	Unit       string  `xml:"Unit,omitempty" json:"unit,omitempty"`
+	Gain       float64 `xml:"Gain,omitempty" json:"gain,omitempty"` // 0表示1
+	Offset     float64 `xml:"Offset,omitempty" json:"offset,omitempty"`
+	SampleRate float64 `xml:"SampleRate,omitempty" json:"sample_rate,omitempty"` // 0表示使用帧频率，否则须为帧频率的整数倍
+}
+
+// ParseDescriptor 解析XML或JSON格式的描述文件并校验
+func ParseDescriptor(content []byte) (*FormatDescriptor, error) {
+	var desc FormatDescriptor
+
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &desc); err != nil {
+			return nil, fmt.Errorf("解析格式描述失败: %w", err)
+		}
+	} else if err := xml.Unmarshal(trimmed, &desc); err != nil {
+		return nil, fmt.Errorf("解析格式描述失败: %w", err)
+	}
+
+	if err := desc.Validate(); err != nil {
+		return nil, err
+	}
+	return &desc, nil
+}
+
+// LoadDescriptor 从指定路径加载描述文件
+func LoadDescriptor(path string) (*FormatDescriptor, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDescriptor(content)
+}
+
+// MarshalDescriptor 将描述序列化为XML
+func MarshalDescriptor(desc *FormatDescriptor) ([]byte, error) {
+	return xml.MarshalIndent(desc, "", "    ")
+}
+
+// Validate 检查描述是否完整有效
+func (d *FormatDescriptor) Validate() error {
+	if d.ID == "" || strings.ContainsAny(d.ID, " \t\r\n") {
+		return fmt.Errorf("格式ID不能为空且不能包含空白字符: %q", d.ID)
+	}
+	if _, ok := sampleTypeSizes[d.sampleType()]; !ok {
+		return fmt.Errorf("不支持的采样类型: %s", d.SampleType)
+	}
+	if order := d.byteOrder(); order != ByteOrderLittle && order != ByteOrderBig {
+		return fmt.Errorf("不支持的字节序: %s", d.ByteOrder)
+	}
+	if layout := d.layout(); layout != LayoutInterleaved && layout != LayoutSequential {
+		return fmt.Errorf("不支持的数据布局: %s", d.Layout)
+	}
+	if d.HeaderSize < 0 || d.FooterSize < 0 {
+		return fmt.Errorf("文件头和文件尾大小不能为负数")
+	}
+	if !(d.SampleRate > 0) || math.IsInf(d.SampleRate, 0) {
+		return fmt.Errorf("无效的采样率: %g", d.SampleRate)
+	}
+	if len(d.Channels) == 0 {
+		return fmt.Errorf("格式%s没有定义通道", d.ID)
+	}
+
+	for i, c := range d.Channels {
+		if c.SampleRate < 0 || math.IsInf(c.SampleRate, 0) || math.IsNaN(c.SampleRate) {
+			return fmt.Errorf("通道%d的采样率无效: %g", i, c.SampleRate)
+		}
+		if _, err := d.samplesPerFrame(i); err != nil {
+			return err
+		}
+		if math.IsNaN(c.Gain) || math.IsInf(c.Gain, 0) || math.IsNaN(c.Offset) || math.IsInf(c.Offset, 0) {
+			return fmt.Errorf("通道%d的增益或偏移无效", i)
+		}
+	}
+
+	if d.Magic != nil {
+		if _, err := d.MagicBytes(); err != nil {
+			return err
+		}
+		if d.Magic.Offset < 0 {
+			return fmt.Errorf("文件头标识的偏移不能为负数")
+		}
+	}
+	return nil
+}
+
+// MagicBytes 返回文件头标识的字节，未定义时返回nil
+func (d *FormatDescriptor) MagicBytes() ([]byte, error) {
+	if d.Magic == nil {
+		return nil, nil
+	}
+	magic, err := hex.DecodeString(strings.Join(strings.Fields(d.Magic.Value), ""))
+	if err != nil {
+		return nil, fmt.Errorf("文件头标识不是有效的十六进制: %w", err)
+	}
+	if len(magic) == 0 {
+		return nil, fmt.Errorf("文件头标识不能为空")
+	}
+	return magic, nil
+}
+
+// SampleSize 返回单个样本的字节数
+func (d *FormatDescriptor) SampleSize() int {
+	return sampleTypeSizes[d.sampleType()]
+}
+
+// ChannelSampleRate 返回通道的采样率
+func (d *FormatDescriptor) ChannelSampleRate(channelIndex int) float64 {
+	if rate := d.Channels[channelIndex].SampleRate; rate > 0 {
+		return rate
+	}
+	return d.SampleRate
+}
+
+func (d *FormatDescriptor) sampleType() string {
+	return strings.ToLower(strings.TrimSpace(d.SampleType))
+}
+
+func (d *FormatDescriptor) byteOrder() string {
+	if order := strings.ToLower(strings.TrimSpace(d.ByteOrder)); order != "" {
+		return order
+	}
+	return ByteOrderLittle
+}
+
+func (d *FormatDescriptor) layout() string {
+	if layout := strings.ToLower(strings.TrimSpace(d.Layout)); layout != "" {
+		return layout
+	}
+	return LayoutInterleaved
+}
+
+// 计算通道每帧的样本数，通道采样率须为帧频率的整数倍
+func (d *FormatDescriptor) samplesPerFrame(channelIndex int) (int, error) {
+	ratio := d.ChannelSampleRate(channelIndex) / d.SampleRate
+	spf := int(math.Round(ratio))
+	if spf < 1 || math.Abs(ratio-float64(spf)) > 1e-6*ratio {
+		return 0, fmt.Errorf("通道%d的采样率%g不是帧频率%g的整数倍", channelIndex, d.ChannelSampleRate(channelIndex), d.SampleRate)
+	}
+	return spf, nil
+}
+
+// 返回按采样类型和字节序解码单个样本的函数，以及该类型原始值的范围（浮点类型无固定范围）
+func (d *FormatDescriptor) sampleDecoder() (decode func([]byte) float64, min, max float64) {
+	var order binary.ByteOrder = binary.LittleEndian
+	if d.byteOrder() == ByteOrderBig {
+		order = binary.BigEndian
+	}
+
+	// 24位样本按字节序组装为无符号值
+	uint24 := func(b []byte) uint32 {
+		if order == binary.BigEndian {
+			return uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+		}
+		return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+	}
+
+	switch d.sampleType() {
+	case "int8":
+		return func(b []byte) float64 { return float64(int8(b[0])) }, math.MinInt8, math.MaxInt8
+	case "uint8":
+		return func(b []byte) float64 { return float64(b[0]) }, 0, math.MaxUint8
+	case "int16":
+		return func(b []byte) float64 { return float64(int16(order.Uint16(b))) }, math.MinInt16, math.MaxInt16
+	case "uint16":
+		return func(b []byte) float64 { return float64(order.Uint16(b)) }, 0, math.MaxUint16
+	case "int24":
+		return func(b []byte) float64 { return float64(int32(uint24(b)<<8) >> 8) }, -1 << 23, 1<<23 - 1
+	case "uint24":
+		return func(b []byte) float64 { return float64(uint24(b)) }, 0, 1<<24 - 1
+	case "int32":
+		return func(b []byte) float64 { return float64(int32(order.Uint32(b))) }, math.MinInt32, math.MaxInt32
+	case "uint32":
+		return func(b []byte) float64 { return float64(order.Uint32(b)) }, 0, math.MaxUint32
+	case "float32":
+		return func(b []byte) float64 { return float64(math.Float32frombits(order.Uint32(b))) }, 0, 0
+	default:
+		return func(b []byte) float64 { return math.Float64frombits(order.Uint64(b)) }, 0, 0
+	}
+}
+
+// RawReader 按格式描述读取原始二进制数据文件
+type RawReader struct {
+	desc       *FormatDescriptor
+	file       *os.File
+	decode     func([]byte) float64
+	rawMin     float64
+	rawMax     float64
+	sampleSize int64
+	spf        []int   // 各通道每帧的样本数
+	offsets    []int64 // 交错布局为通道在帧内的字节偏移，顺序布局为通道数据块的起始位置
+	frameSize  int64   // 一帧的字节数
+	numFrames  int64
+	warnings   []string
+}
+
+// OpenRaw 按格式描述打开原始二进制数据文件
+func OpenRaw(path string, desc *FormatDescriptor) (*RawReader, error) {
+	if err := desc.Validate(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	reader := &RawReader{
+		desc:       desc,
+		file:       file,
+		sampleSize: int64(desc.SampleSize()),
+		spf:        make([]int, len(desc.Channels)),
+		offsets:    make([]int64, len(desc.Channels)),
+	}
+	reader.decode, reader.rawMin, reader.rawMax = desc.sampleDecoder()
+
+	for i := range desc.Channels {
+		reader.spf[i], _ = desc.samplesPerFrame(i)
+		reader.frameSize += int64(reader.spf[i]) * reader.sampleSize
+	}
+
+	dataSize := info.Size() - desc.HeaderSize - desc.FooterSize
+	if dataSize < 0 {
+		file.Close()
+		return nil, fmt.Errorf("文件大小%d小于文件头和文件尾之和", info.Size())
+	}
+	reader.numFrames = dataSize / reader.frameSize
+	if rest := dataSize % reader.frameSize; rest != 0 {
+		reader.warnings = append(reader.warnings, fmt.Sprintf("数据区末尾有%d字节不足一帧，已忽略", rest))
+	}
+
+	// 计算各通道的起始位置
+	pos := int64(0)
+	for i := range desc.Channels {
+		if desc.layout() == LayoutSequential {
+			reader.offsets[i] = desc.HeaderSize + pos*reader.numFrames
+		} else {
+			reader.offsets[i] = pos
+		}
+		pos += int64(reader.spf[i]) * reader.sampleSize
+	}
+
+	return reader, nil
+}
+
+// Close 关闭文件
+func (r *RawReader) Close() error {
+	return r.file.Close()
+}
+
+// Descriptor 返回文件的格式描述
+func (r *RawReader) Descriptor() *FormatDescriptor {
+	return r.desc
+}
+
+// Warnings 返回打开文件时发现的非致命问题
+func (r *RawReader) Warnings() []string {
+	return r.warnings
+}
+
+// GetNumSignals 获取信号数量
+func (r *RawReader) GetNumSignals() int {
+	return len(r.desc.Channels)
+}
+
+// GetSignalSamplingRate 获取信号的采样率
+func (r *RawReader) GetSignalSamplingRate(signalIndex int) float64 {
+	if signalIndex < 0 || signalIndex >= len(r.desc.Channels) {
+		return 0
+	}
+	return r.desc.ChannelSampleRate(signalIndex)
+}
+
+// GetChannelInfo 获取通道信息，返回名称、单位和物理值范围
+// 浮点采样类型没有固定的原始值范围，物理值范围返回0。
+func (r *RawReader) GetChannelInfo(signalIndex int) (string, string, float64, float64) {
+	if signalIndex < 0 || signalIndex >= len(r.desc.Channels) {
+		return "", "", 0, 0
+	}
+
+	c := r.desc.Channels[signalIndex]
+	min, max := r.ConvertToPhysical(signalIndex, r.rawMin), r.ConvertToPhysical(signalIndex, r.rawMax)
+	if r.rawMin == r.rawMax {
+		min, max = 0, 0
+	} else if min > max {
+		min, max = max, min
+	}
+	return c.Name, c.Unit, min, max
+}
+
+// NumSamples 返回信号的样本数
+func (r *RawReader) NumSamples(signalIndex int) int64 {
+	if signalIndex < 0 || signalIndex >= len(r.desc.Channels) {
+		return 0
+	}
+	return r.numFrames * int64(r.spf[signalIndex])
+}
+
+// Duration 返回记录的总时长（秒）
+func (r *RawReader) Duration() float64 {
+	return float64(r.numFrames) / r.desc.SampleRate
+}
+
+// ConvertToPhysical 将原始值转换为物理值
+func (r *RawReader) ConvertToPhysical(signalIndex int, rawValue float64) float64 {
+	c := r.desc.Channels[signalIndex]
+	gain := c.Gain
+	if gain == 0 {
+		gain = 1
+	}
+	return rawValue*gain + c.Offset
+}
+
+// ReadSignalData 读取信号从startSample开始的numSamples个样本，返回物理值
+func (r *RawReader) ReadSignalData(signalIndex int, startSample, numSamples int64) ([]float64, error) {
+	if signalIndex < 0 || signalIndex >= len(r.desc.Channels) {
+		return nil, fmt.Errorf("信号索引超出范围: %d", signalIndex)
+	}
+	total := r.NumSamples(signalIndex)
+	if startSample < 0 || numSamples < 0 || startSample+numSamples > total {
+		return nil, fmt.Errorf("样本范围超出信号长度: [%d, %d)，共%d个样本", startSample, startSample+numSamples, total)
+	}
+
+	values := make([]float64, 0, numSamples)
+	if r.desc.layout() == LayoutSequential {
+		err := r.readChunks(r.offsets[signalIndex]+startSample*r.sampleSize, numSamples, r.sampleSize, func(buf []byte) {
+			for pos := int64(0); pos < int64(len(buf)); pos += r.sampleSize {
+				values = append(values, r.ConvertToPhysical(signalIndex, r.decode(buf[pos:])))
+			}
+		})
+		return values, err
+	}
+
+	// 交错布局按整帧读取，再从每帧中取出该通道的样本
+	spf := int64(r.spf[signalIndex])
+	firstFrame := startSample / spf
+	lastFrame := (startSample + numSamples + spf - 1) / spf
+	skip := startSample - firstFrame*spf
+	err := r.readChunks(r.desc.HeaderSize+firstFrame*r.frameSize, lastFrame-firstFrame, r.frameSize, func(buf []byte) {
+		for frame := int64(0); frame < int64(len(buf)); frame += r.frameSize {
+			for k := int64(0); k < spf; k++ {
+				if skip > 0 {
+					skip--
+					continue
+				}
+				if int64(len(values)) == numSamples {
+					return
+				}
+				pos := frame + r.offsets[signalIndex] + k*r.sampleSize
+				values = append(values, r.ConvertToPhysical(signalIndex, r.decode(buf[pos:])))
+			}
+		}
+	})
+	return values, err
+}
+
+// 从offset开始分块读取count个大小为unit字节的单元，每块交给fn处理
+func (r *RawReader) readChunks(offset, count, unit int64, fn func(buf []byte)) error {
+	perChunk := maxReadBytes / unit
+	if perChunk < 1 {
+		perChunk = 1
+	}
+
+	buf := make([]byte, unit*int64(math.Min(float64(perChunk), float64(count))))
+	for count > 0 {
+		n := perChunk
+		if n > count {
+			n = count
+		}
+		chunk := buf[:n*unit]
+		if _, err := r.file.ReadAt(chunk, offset); err != nil {
+			return fmt.Errorf("读取数据失败: %w", err)
+		}
+		fn(chunk)
+		offset += n * unit
+		count -= n
+	}
+	return nil
+}
+
+// ReadWindowPoints 读取信号在时间窗口[t0, t1)内的数据点，X为样本时间（秒）
+func (r *RawReader) ReadWindowPoints(signalIndex int, t0, t1 float64) ([]data.DataPoint, error) {
+	if t1 <= t0 {
+		return nil, fmt.Errorf("无效的时间窗口: [%g, %g)", t0, t1)
+	}
+
+	rate := r.GetSignalSamplingRate(signalIndex)
+	if rate <= 0 {
+		return nil, fmt.Errorf("信号索引超出范围: %d", signalIndex)
+	}
+	total := r.NumSamples(signalIndex)
+
+	start := int64(math.Max(0, math.Ceil(t0*rate-1e-9)))
+	end := int64(math.Min(float64(total), math.Ceil(t1*rate-1e-9)))
+	if start >= end {
+		return []data.DataPoint{}, nil
+	}
+
+	values, err := r.ReadSignalData(signalIndex, start, end-start)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]data.DataPoint, len(values))
+	for i, v := range values {
+		points[i] = data.DataPoint{X: float64(start+int64(i)) / rate, Y: v}
+	}
+	return points, nil
+}
+
+// LoadSignalToChannel 将信号的全部数据加载到通道
+func (r *RawReader) LoadSignalToChannel(signalIndex int, channel *data.Channel) error {
+	points, err := r.ReadWindowPoints(signalIndex, 0, r.Duration())
+	if err != nil {
+		return err
+	}
+
+	channel.ClearData()
+	channel.Data = points
+	channel.SampleRate = r.GetSignalSamplingRate(signalIndex)
+
+	return nil
+}
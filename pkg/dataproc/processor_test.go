@@ -0,0 +1,380 @@
+package dataproc
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+// 包文档中的示例描述
+const testDescriptorXML = `<BinaryFormat id="acme-dump">
+    <Name>ACME监护仪导出</Name>
+    <Extension>.acm</Extension>
+    <Magic offset="0">41 43 4D 45</Magic>
+    <HeaderSize>256</HeaderSize>
+    <SampleType>int16</SampleType>
+    <ByteOrder>little</ByteOrder>
+    <Layout>interleaved</Layout>
+    <SampleRate>250</SampleRate>
+    <Channels>
+        <Channel>
+            <Name>ECG II</Name>
+            <Unit>mV</Unit>
+            <Gain>0.005</Gain>
+            <SampleRate>500</SampleRate>
+        </Channel>
+        <Channel>
+            <Name>Resp</Name>
+            <Unit>Ohm</Unit>
+            <Gain>0.1</Gain>
+            <Offset>-100</Offset>
+        </Channel>
+    </Channels>
+</BinaryFormat>`
+
+// 将内容写入临时目录中的文件，返回文件路径
+func writeTestFile(t *testing.T, name string, content []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseDescriptor(t *testing.T) {
+	want := &FormatDescriptor{
+		ID:         "acme-dump",
+		Name:       "ACME监护仪导出",
+		Extension:  ".acm",
+		Magic:      &MagicDescriptor{Offset: 0, Value: "41 43 4D 45"},
+		HeaderSize: 256,
+		SampleType: "int16",
+		ByteOrder:  "little",
+		Layout:     "interleaved",
+		SampleRate: 250,
+		Channels: []ChannelDescriptor{
+			{Name: "ECG II", Unit: "mV", Gain: 0.005, SampleRate: 500},
+			{Name: "Resp", Unit: "Ohm", Gain: 0.1, Offset: -100},
+		},
+	}
+
+	desc, err := ParseDescriptor([]byte(testDescriptorXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc.XMLName.Local, desc.XMLName.Space = "", ""
+	if !reflect.DeepEqual(desc, want) {
+		t.Errorf("ParseDescriptor = %+v, want %+v", desc, want)
+	}
+	if magic, err := desc.MagicBytes(); err != nil || string(magic) != "ACME" {
+		t.Errorf("MagicBytes = %q, %v", magic, err)
+	}
+	if desc.SampleSize() != 2 || desc.ChannelSampleRate(0) != 500 || desc.ChannelSampleRate(1) != 250 {
+		t.Errorf("SampleSize = %d, ChannelSampleRate = %g, %g", desc.SampleSize(), desc.ChannelSampleRate(0), desc.ChannelSampleRate(1))
+	}
+
+	// XML序列化后能重新解析
+	content, err := MarshalDescriptor(desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := ParseDescriptor(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again.XMLName.Local, again.XMLName.Space = "", ""
+	if !reflect.DeepEqual(again, want) {
+		t.Errorf("重新解析 = %+v, want %+v", again, want)
+	}
+
+	// JSON描述使用小写下划线字段名，省略的字节序和布局使用默认值
+	desc, err = ParseDescriptor([]byte(`  {"format_id": "j", "name": "J", "sample_type": "UINT8", "sample_rate": 10,
+		"channels": [{"name": "a"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc.ID != "j" || desc.SampleSize() != 1 || desc.byteOrder() != ByteOrderLittle || desc.layout() != LayoutInterleaved || len(desc.Channels) != 1 {
+		t.Errorf("ParseDescriptor(JSON) = %+v", desc)
+	}
+
+	path := writeTestFile(t, "acme.xml", []byte(testDescriptorXML))
+	if desc, err := LoadDescriptor(path); err != nil || desc.ID != "acme-dump" {
+		t.Errorf("LoadDescriptor = %+v, %v", desc, err)
+	}
+	if _, err := LoadDescriptor(path + ".missing"); err == nil {
+		t.Error("文件不存在时应返回错误")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() *FormatDescriptor {
+		return &FormatDescriptor{ID: "x", SampleType: "int16", SampleRate: 100, Channels: []ChannelDescriptor{{Name: "a"}}}
+	}
+	if err := valid().Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(d *FormatDescriptor)
+	}{
+		{"ID为空", func(d *FormatDescriptor) { d.ID = "" }},
+		{"ID包含空格", func(d *FormatDescriptor) { d.ID = "a b" }},
+		{"采样类型", func(d *FormatDescriptor) { d.SampleType = "int12" }},
+		{"字节序", func(d *FormatDescriptor) { d.ByteOrder = "middle" }},
+		{"数据布局", func(d *FormatDescriptor) { d.Layout = "planar" }},
+		{"文件头为负数", func(d *FormatDescriptor) { d.HeaderSize = -1 }},
+		{"文件尾为负数", func(d *FormatDescriptor) { d.FooterSize = -1 }},
+		{"采样率为0", func(d *FormatDescriptor) { d.SampleRate = 0 }},
+		{"采样率为NaN", func(d *FormatDescriptor) { d.SampleRate = math.NaN() }},
+		{"采样率无穷大", func(d *FormatDescriptor) { d.SampleRate = math.Inf(1) }},
+		{"没有通道", func(d *FormatDescriptor) { d.Channels = nil }},
+		{"通道采样率为负数", func(d *FormatDescriptor) { d.Channels[0].SampleRate = -1 }},
+		{"通道采样率不是整数倍", func(d *FormatDescriptor) { d.Channels[0].SampleRate = 150 }},
+		{"通道采样率低于帧频率", func(d *FormatDescriptor) { d.Channels[0].SampleRate = 50 }},
+		{"增益无效", func(d *FormatDescriptor) { d.Channels[0].Gain = math.NaN() }},
+		{"偏移无效", func(d *FormatDescriptor) { d.Channels[0].Offset = math.Inf(-1) }},
+		{"文件头标识不是十六进制", func(d *FormatDescriptor) { d.Magic = &MagicDescriptor{Value: "ZZ"} }},
+		{"文件头标识为空", func(d *FormatDescriptor) { d.Magic = &MagicDescriptor{Value: " "} }},
+		{"文件头标识偏移为负数", func(d *FormatDescriptor) { d.Magic = &MagicDescriptor{Offset: -1, Value: "00"} }},
+	}
+	for _, tt := range tests {
+		d := valid()
+		tt.modify(d)
+		if err := d.Validate(); err == nil {
+			t.Errorf("%s: 应返回错误", tt.name)
+		}
+	}
+
+	for _, content := range []string{"<BinaryFormat", `{"format_id": 1}`, `<BinaryFormat id="x"/>`} {
+		if _, err := ParseDescriptor([]byte(content)); err == nil {
+			t.Errorf("ParseDescriptor(%q)应返回错误", content)
+		}
+	}
+}
+
+func TestSampleDecoder(t *testing.T) {
+	tests := []struct {
+		sampleType string
+		byteOrder  string
+		raw        []byte
+		want       float64
+		min, max   float64
+	}{
+		{"int8", "", []byte{0xFE}, -2, -128, 127},
+		{"uint8", "", []byte{0xFE}, 254, 0, 255},
+		{"int16", "little", []byte{0xFE, 0xFF}, -2, -32768, 32767},
+		{"int16", "big", []byte{0xFF, 0xFE}, -2, -32768, 32767},
+		{"uint16", "big", []byte{0x01, 0x02}, 0x0102, 0, 65535},
+		{"int24", "little", []byte{0x00, 0x00, 0x80}, -1 << 23, -1 << 23, 1<<23 - 1},
+		{"int24", "big", []byte{0xFF, 0xFF, 0xFE}, -2, -1 << 23, 1<<23 - 1},
+		{"uint24", "little", []byte{0x01, 0x02, 0x03}, 0x030201, 0, 1<<24 - 1},
+		{"int32", "little", []byte{0xFE, 0xFF, 0xFF, 0xFF}, -2, math.MinInt32, math.MaxInt32},
+		{"uint32", "big", []byte{0xFF, 0xFF, 0xFF, 0xFE}, math.MaxUint32 - 1, 0, math.MaxUint32},
+		{"float32", "big", binary.BigEndian.AppendUint32(nil, math.Float32bits(1.5)), 1.5, 0, 0},
+		{"float64", "little", binary.LittleEndian.AppendUint64(nil, math.Float64bits(-0.25)), -0.25, 0, 0},
+	}
+	for _, tt := range tests {
+		d := &FormatDescriptor{SampleType: tt.sampleType, ByteOrder: tt.byteOrder}
+		decode, min, max := d.sampleDecoder()
+		if got := decode(tt.raw); got != tt.want || min != tt.min || max != tt.max {
+			t.Errorf("%s %s: decode = %g, 范围[%g, %g], want %g, [%g, %g]", tt.sampleType, tt.byteOrder, got, min, max, tt.want, tt.min, tt.max)
+		}
+	}
+}
+
+func TestOpenRawInterleaved(t *testing.T) {
+	desc, err := ParseDescriptor([]byte(testDescriptorXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 每帧为ECG的2个样本和Resp的1个样本，末尾多出3字节
+	content := append([]byte("ACME"), make([]byte, 252)...)
+	for frame := 0; frame < 10; frame++ {
+		for _, v := range []int16{int16(2 * frame), int16(2*frame + 1), int16(1000 + frame)} {
+			content = binary.LittleEndian.AppendUint16(content, uint16(v))
+		}
+	}
+	content = append(content, 1, 2, 3)
+	path := writeTestFile(t, "test.acm", content)
+
+	r, err := OpenRaw(path, desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if r.GetNumSignals() != 2 || r.NumSamples(0) != 20 || r.NumSamples(1) != 10 || r.Duration() != 0.04 {
+		t.Errorf("GetNumSignals = %d, NumSamples = %d, %d, Duration = %g", r.GetNumSignals(), r.NumSamples(0), r.NumSamples(1), r.Duration())
+	}
+	if len(r.Warnings()) != 1 || r.Descriptor() != desc {
+		t.Errorf("Warnings = %v", r.Warnings())
+	}
+	if name, unit, min, max := r.GetChannelInfo(1); name != "Resp" || unit != "Ohm" || math.Abs(min+3376.8) > 1e-9 || math.Abs(max-3176.7) > 1e-9 {
+		t.Errorf("GetChannelInfo(1) = %s, %s, %g, %g", name, unit, min, max)
+	}
+	if r.GetSignalSamplingRate(2) != 0 || r.NumSamples(-1) != 0 {
+		t.Error("信号索引超出范围应返回0")
+	}
+
+	// 从帧中间开始读取
+	values, err := r.ReadSignalData(0, 3, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{0.015, 0.02, 0.025, 0.03}; !approxEqual(values, want) {
+		t.Errorf("ReadSignalData(0) = %v, want %v", values, want)
+	}
+	values, err = r.ReadSignalData(1, 8, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{0.8, 0.9}; !approxEqual(values, want) {
+		t.Errorf("ReadSignalData(1) = %v, want %v", values, want)
+	}
+
+	points, err := r.ReadWindowPoints(0, 0.01, 0.016)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 3 || math.Abs(points[0].X-0.01) > 1e-12 || math.Abs(points[0].Y-0.025) > 1e-12 {
+		t.Errorf("ReadWindowPoints = %v", points)
+	}
+
+	channel := data.NewChannel("1", "Resp")
+	if err := r.LoadSignalToChannel(1, channel); err != nil {
+		t.Fatal(err)
+	}
+	if len(channel.Data) != 10 || channel.SampleRate != 250 {
+		t.Errorf("LoadSignalToChannel加载了%d个点，采样率%g", len(channel.Data), channel.SampleRate)
+	}
+
+	if _, err := r.ReadSignalData(0, 18, 3); err == nil {
+		t.Error("样本范围超出信号长度应返回错误")
+	}
+	if _, err := r.ReadSignalData(2, 0, 1); err == nil {
+		t.Error("信号索引超出范围应返回错误")
+	}
+	if _, err := r.ReadWindowPoints(0, 1, 1); err == nil {
+		t.Error("空时间窗口应返回错误")
+	}
+}
+
+func TestOpenRawSequential(t *testing.T) {
+	desc := &FormatDescriptor{
+		ID:         "seq",
+		HeaderSize: 2,
+		FooterSize: 4,
+		SampleType: "int24",
+		ByteOrder:  "big",
+		Layout:     "Sequential",
+		SampleRate: 2,
+		Channels:   []ChannelDescriptor{{Name: "a"}, {Name: "b", Gain: -1, Offset: 1}},
+	}
+
+	// 通道a的3个样本之后是通道b的3个样本
+	content := []byte{0xAA, 0xBB}
+	for _, v := range []int32{1, -2, 3, 10, 20, 30} {
+		content = append(content, byte(v>>16), byte(v>>8), byte(v))
+	}
+	content = append(content, "TAIL"...)
+	path := writeTestFile(t, "test.raw", content)
+
+	r, err := OpenRaw(path, desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if r.NumSamples(1) != 3 || r.Duration() != 1.5 || len(r.Warnings()) != 0 {
+		t.Errorf("NumSamples = %d, Duration = %g, Warnings = %v", r.NumSamples(1), r.Duration(), r.Warnings())
+	}
+	if values, err := r.ReadSignalData(0, 0, 3); err != nil || !approxEqual(values, []float64{1, -2, 3}) {
+		t.Errorf("ReadSignalData(0) = %v, %v", values, err)
+	}
+	if values, err := r.ReadSignalData(1, 1, 2); err != nil || !approxEqual(values, []float64{-19, -29}) {
+		t.Errorf("ReadSignalData(1) = %v, %v", values, err)
+	}
+
+	// 负增益时物理范围须交换
+	if _, _, min, max := r.GetChannelInfo(1); min != 2-1<<23 || max != 1+1<<23 {
+		t.Errorf("GetChannelInfo(1)范围 = [%g, %g]", min, max)
+	}
+}
+
+func TestOpenRawErrors(t *testing.T) {
+	desc := &FormatDescriptor{ID: "x", HeaderSize: 100, SampleType: "float32", SampleRate: 1, Channels: []ChannelDescriptor{{Name: "a"}}}
+	path := writeTestFile(t, "short.raw", make([]byte, 10))
+	if _, err := OpenRaw(path, desc); err == nil {
+		t.Error("文件小于文件头时应返回错误")
+	}
+	if _, err := OpenRaw(path+".missing", desc); err == nil {
+		t.Error("文件不存在时应返回错误")
+	}
+	desc.SampleRate = 0
+	if _, err := OpenRaw(path, desc); err == nil {
+		t.Error("描述无效时应返回错误")
+	}
+
+	// 浮点类型没有固定的物理范围
+	desc = &FormatDescriptor{ID: "x", SampleType: "float32", SampleRate: 1, Channels: []ChannelDescriptor{{Name: "a"}}}
+	r, err := OpenRaw(path, desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, _, min, max := r.GetChannelInfo(0); min != 0 || max != 0 {
+		t.Errorf("GetChannelInfo范围 = [%g, %g]", min, max)
+	}
+	if len(r.Warnings()) != 1 || r.NumSamples(0) != 2 {
+		t.Errorf("Warnings = %v, NumSamples = %d", r.Warnings(), r.NumSamples(0))
+	}
+}
+
+// 比较物理值，允许浮点误差
+func approxEqual(got, want []float64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+// 大文件按块读取时结果不变
+func TestReadChunks(t *testing.T) {
+	const frames = maxReadBytes/4 + 100
+	content := make([]byte, 0, frames*4)
+	for i := 0; i < frames; i++ {
+		content = binary.LittleEndian.AppendUint16(content, uint16(i))
+		content = binary.LittleEndian.AppendUint16(content, uint16(-i))
+	}
+	path := writeTestFile(t, "big.raw", content)
+
+	desc := &FormatDescriptor{ID: "x", SampleType: "uint16", SampleRate: 1, Channels: []ChannelDescriptor{{Name: "a"}, {Name: "b"}}}
+	r, err := OpenRaw(path, desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	values, err := r.ReadSignalData(0, 0, frames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range values {
+		if v != float64(uint16(i)) {
+			t.Fatalf("第%d个样本 = %g", i, v)
+		}
+	}
+}
@@ -0,0 +1,445 @@
+package fileio
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+	"github.com/ljx520ljx/chartSystem/internal/model"
+)
+
+// aECG中的代码
+const (
+	aecgTimeAbsolute = "TIME_ABSOLUTE"
+	aecgTimeRelative = "TIME_RELATIVE"
+	aecgLeadPrefix   = "MDC_ECG_LEAD_"
+	aecgCodePrefix   = "MDC_ECG_"
+)
+
+// AECGRecord 表示一个HL7 aECG（Annotated ECG）文档
+type AECGRecord struct {
+	ID        string
+	StartTime time.Time
+	Series    []AECGSeries
+}
+
+// AECGSeries 表示aECG中的一个波形序列组，通常为节律（RHYTHM）序列
+type AECGSeries struct {
+	Code        string    // 序列组代码，如"RHYTHM"
+	StartTime   time.Time // 第一个样本的绝对时间，时间序列为相对时间时为零值
+	SampleRate  float64
+	Leads       []AECGLead
+	Annotations []AECGAnnotation
+}
+
+// AECGLead 表示一个导联的波形
+type AECGLead struct {
+	Code   string    // 导联代码，如"MDC_ECG_LEAD_II"
+	Name   string    // 导联名称，如"II"
+	Unit   string    // 物理单位，如"uV"
+	Origin float64   // 数字值为0时的物理值
+	Scale  float64   // 每个数字单位对应的物理值
+	Values []float64 // 物理值: origin + scale * digit
+}
+
+// AECGAnnotation 表示aECG注释集中的一条带时间范围的注释
+type AECGAnnotation struct {
+	Code     string  // 注释代码，如"MDC_ECG_BEAT"、"MDC_ECG_WAVC_PWAVE"
+	Value    string  // 注释值代码，如"MDC_ECG_BEAT_NORMAL"，没有时为空
+	Onset    float64 // 相对序列开始的时间（秒）
+	Duration float64 // 持续时间（秒），只有开始时间时为0
+}
+
+// Label 返回注释的显示文本，优先使用注释值，并去掉"MDC_ECG_"前缀
+func (a AECGAnnotation) Label() string {
+	label := a.Value
+	if label == "" {
+		label = a.Code
+	}
+	return strings.TrimPrefix(label, aecgCodePrefix)
+}
+
+// aECG XML结构，只映射用到的元素
+type aecgDocument struct {
+	XMLName       xml.Name     `xml:"AnnotatedECG"`
+	ID            aecgID       `xml:"id"`
+	EffectiveTime aecgInterval `xml:"effectiveTime"`
+	Series        []aecgSeries `xml:"component>series"`
+}
+
+type aecgID struct {
+	Root      string `xml:"root,attr"`
+	Extension string `xml:"extension,attr"`
+}
+
+type aecgCode struct {
+	Code string `xml:"code,attr"`
+}
+
+type aecgQuantity struct {
+	Value string `xml:"value,attr"`
+	Unit  string `xml:"unit,attr"`
+	Code  string `xml:"code,attr"`
+}
+
+type aecgInterval struct {
+	Low  aecgQuantity `xml:"low"`
+	High aecgQuantity `xml:"high"`
+}
+
+type aecgSeries struct {
+	Code        aecgCode         `xml:"code"`
+	Sequences   []aecgSequence   `xml:"component>sequenceSet>component>sequence"`
+	Annotations []aecgAnnotation `xml:"subjectOf>annotationSet>component>annotation"`
+}
+
+type aecgSequence struct {
+	Code  aecgCode          `xml:"code"`
+	Value aecgSequenceValue `xml:"value"`
+}
+
+// 时间序列（GLIST_TS/GLIST_PQ）和波形序列（SLIST_PQ）共用的值结构
+type aecgSequenceValue struct {
+	Head      aecgQuantity `xml:"head"`
+	Increment aecgQuantity `xml:"increment"`
+	Origin    aecgQuantity `xml:"origin"`
+	Scale     aecgQuantity `xml:"scale"`
+	Digits    string       `xml:"digits"`
+}
+
+type aecgAnnotation struct {
+	Code       aecgCode         `xml:"code"`
+	Value      aecgQuantity     `xml:"value"`
+	Boundaries []aecgBoundary   `xml:"support>supportingROI>component>boundary"`
+	Children   []aecgAnnotation `xml:"component>annotation"`
+}
+
+type aecgBoundary struct {
+	Code  aecgCode     `xml:"code"`
+	Value aecgInterval `xml:"value"`
+}
+
+// OpenAECG 读取一个HL7 aECG文件
+func OpenAECG(path string) (*AECGRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadAECG(file)
+}
+
+// ReadAECG 从r中解析HL7 aECG文档
+// 只读取顶层的波形序列组，代表性心搏等派生序列被忽略。
+func ReadAECG(r io.Reader) (*AECGRecord, error) {
+	var doc aecgDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("解析aECG文档失败: %w", err)
+	}
+
+	record := &AECGRecord{ID: doc.ID.Root}
+	if doc.ID.Extension != "" {
+		record.ID += "^" + doc.ID.Extension
+	}
+	if doc.EffectiveTime.Low.Value != "" {
+		t, err := parseHL7Time(doc.EffectiveTime.Low.Value)
+		if err != nil {
+			return nil, err
+		}
+		record.StartTime = t
+	}
+
+	for i, s := range doc.Series {
+		series, err := convertAECGSeries(s)
+		if err != nil {
+			return nil, fmt.Errorf("解析第%d个序列组失败: %w", i, err)
+		}
+		record.Series = append(record.Series, series)
+	}
+
+	return record, nil
+}
+
+// 将XML序列组转换为导联波形和注释
+func convertAECGSeries(s aecgSeries) (AECGSeries, error) {
+	series := AECGSeries{Code: s.Code.Code}
+
+	// relativeStart为相对时间序列的起点，注释时间以序列开始为0
+	var relativeStart float64
+	timeFound := false
+	for _, seq := range s.Sequences {
+		v := seq.Value
+		switch seq.Code.Code {
+		case aecgTimeAbsolute, aecgTimeRelative:
+			if timeFound {
+				return series, fmt.Errorf("存在多个时间序列")
+			}
+			timeFound = true
+
+			increment, err := parseAECGSeconds(v.Increment)
+			if err != nil || increment <= 0 {
+				return series, fmt.Errorf("无效的采样间隔: %q%s", v.Increment.Value, v.Increment.Unit)
+			}
+			series.SampleRate = 1 / increment
+
+			if seq.Code.Code == aecgTimeAbsolute {
+				series.StartTime, err = parseHL7Time(v.Head.Value)
+			} else {
+				relativeStart, err = parseAECGSeconds(v.Head)
+			}
+			if err != nil {
+				return series, err
+			}
+		default:
+			lead, err := convertAECGLead(seq)
+			if err != nil {
+				return series, err
+			}
+			series.Leads = append(series.Leads, lead)
+		}
+	}
+	if !timeFound {
+		return series, fmt.Errorf("缺少时间序列")
+	}
+
+	var collect func(annotations []aecgAnnotation) error
+	collect = func(annotations []aecgAnnotation) error {
+		for _, a := range annotations {
+			ann, ok, err := convertAECGAnnotation(a, series.StartTime, relativeStart)
+			if err != nil {
+				return err
+			}
+			if ok {
+				series.Annotations = append(series.Annotations, ann)
+			}
+			if err := collect(a.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := collect(s.Annotations); err != nil {
+		return series, err
+	}
+
+	return series, nil
+}
+
+// 解析一个导联的SLIST_PQ波形
+func convertAECGLead(seq aecgSequence) (AECGLead, error) {
+	v := seq.Value
+	lead := AECGLead{
+		Code:  seq.Code.Code,
+		Name:  strings.TrimPrefix(seq.Code.Code, aecgLeadPrefix),
+		Unit:  v.Scale.Unit,
+		Scale: 1,
+	}
+
+	if v.Origin.Value != "" {
+		origin, err := strconv.ParseFloat(v.Origin.Value, 64)
+		if err != nil {
+			return lead, fmt.Errorf("导联%s的origin无效: %q", lead.Name, v.Origin.Value)
+		}
+		lead.Origin = origin
+	}
+	if v.Scale.Value != "" {
+		scale, err := strconv.ParseFloat(v.Scale.Value, 64)
+		if err != nil {
+			return lead, fmt.Errorf("导联%s的scale无效: %q", lead.Name, v.Scale.Value)
+		}
+		lead.Scale = scale
+	}
+	if lead.Unit == "" {
+		lead.Unit = v.Origin.Unit
+	}
+
+	digits := strings.Fields(v.Digits)
+	lead.Values = make([]float64, len(digits))
+	for i, d := range digits {
+		digit, err := strconv.ParseFloat(d, 64)
+		if err != nil {
+			return lead, fmt.Errorf("导联%s的第%d个样本无效: %q", lead.Name, i, d)
+		}
+		lead.Values[i] = lead.Origin + lead.Scale*digit
+	}
+
+	return lead, nil
+}
+
+// 转换一条注释，没有时间范围的注释（如全局测量值）返回false
+func convertAECGAnnotation(a aecgAnnotation, start time.Time, relativeStart float64) (AECGAnnotation, bool, error) {
+	ann := AECGAnnotation{Code: a.Code.Code, Value: a.Value.Code}
+
+	for _, b := range a.Boundaries {
+		var low, high float64
+		var hasHigh bool
+		var err error
+
+		switch b.Code.Code {
+		case aecgTimeAbsolute:
+			if start.IsZero() {
+				return ann, false, fmt.Errorf("注释%s使用绝对时间，但序列没有绝对开始时间", ann.Code)
+			}
+			low, err = hl7Offset(b.Value.Low.Value, start)
+			if err == nil && b.Value.High.Value != "" {
+				high, err = hl7Offset(b.Value.High.Value, start)
+				hasHigh = true
+			}
+		case aecgTimeRelative:
+			low, err = parseAECGSeconds(b.Value.Low)
+			low -= relativeStart
+			if err == nil && b.Value.High.Value != "" {
+				high, err = parseAECGSeconds(b.Value.High)
+				high -= relativeStart
+				hasHigh = true
+			}
+		default:
+			// 导联等其他边界只限定注释所属的导联
+			continue
+		}
+		if err != nil {
+			return ann, false, fmt.Errorf("注释%s的时间范围无效: %w", ann.Code, err)
+		}
+
+		ann.Onset = low
+		if hasHigh && high > low {
+			ann.Duration = high - low
+		}
+		return ann, true, nil
+	}
+
+	return ann, false, nil
+}
+
+// 将带单位的时间量转换为秒，支持s和ms
+func parseAECGSeconds(q aecgQuantity) (float64, error) {
+	v, err := strconv.ParseFloat(q.Value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("无效的时间值: %q", q.Value)
+	}
+
+	switch q.Unit {
+	case "", "s":
+		return v, nil
+	case "ms":
+		return v / 1000, nil
+	case "us":
+		return v / 1e6, nil
+	default:
+		return 0, fmt.Errorf("不支持的时间单位: %q", q.Unit)
+	}
+}
+
+// 计算HL7时间戳相对start的秒数
+func hl7Offset(value string, start time.Time) (float64, error) {
+	t, err := parseHL7Time(value)
+	if err != nil {
+		return 0, err
+	}
+	return t.Sub(start).Seconds(), nil
+}
+
+// 解析HL7 TS时间戳，格式为YYYYMMDD[HH[MM[SS[.UUUU]]]][+/-ZZZZ]
+// 没有时区的时间戳以UTC表示设备本地时间，与EDF开始时间的处理一致。
+func parseHL7Time(value string) (time.Time, error) {
+	s := strings.TrimSpace(value)
+	loc := time.UTC
+
+	if i := strings.IndexAny(s, "+-"); i >= 0 {
+		zone := s[i:]
+		s = s[:i]
+		offset, err := time.Parse("-0700", zone)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("无效的HL7时区: %q", value)
+		}
+		_, secs := offset.Zone()
+		loc = time.FixedZone(zone, secs)
+	}
+
+	digits, fraction, _ := strings.Cut(s, ".")
+	layouts := map[int]string{
+		8:  "20060102",
+		10: "2006010215",
+		12: "200601021504",
+		14: "20060102150405",
+	}
+	layout, ok := layouts[len(digits)]
+	if !ok {
+		return time.Time{}, fmt.Errorf("无效的HL7时间戳: %q", value)
+	}
+
+	t, err := time.ParseInLocation(layout, digits, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的HL7时间戳: %q", value)
+	}
+	if fraction != "" {
+		f, err := strconv.ParseFloat("0."+fraction, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("无效的HL7时间戳: %q", value)
+		}
+		t = t.Add(time.Duration(f * float64(time.Second)))
+	}
+
+	return t, nil
+}
+
+// LoadLeadToChannel 将导联波形加载到通道
+func (s *AECGSeries) LoadLeadToChannel(leadIndex int, channel *data.Channel) error {
+	if leadIndex < 0 || leadIndex >= len(s.Leads) {
+		return fmt.Errorf("导联索引超出范围: %d", leadIndex)
+	}
+
+	channel.ClearData()
+	channel.StartTime = s.StartTime
+	channel.SampleRate = s.SampleRate
+	channel.Data = make([]data.DataPoint, len(s.Leads[leadIndex].Values))
+	for i, v := range s.Leads[leadIndex].Values {
+		channel.Data[i] = data.DataPoint{X: float64(i) / s.SampleRate, Y: v}
+	}
+
+	return nil
+}
+
+// Duration 返回序列组的时长（秒），按最长的导联计算
+func (s *AECGSeries) Duration() float64 {
+	samples := 0
+	for _, lead := range s.Leads {
+		samples = max(samples, len(lead.Values))
+	}
+	return float64(samples) / s.SampleRate
+}
+
+// AECGAnnotationsToMarkers 将aECG注释转换为标记点，心搏注释的类型为"beat"
+func AECGAnnotationsToMarkers(annotations []AECGAnnotation, fileID, channelID, createdBy uint) []*model.Marker {
+	markers := make([]*model.Marker, 0, len(annotations))
+
+	for _, a := range annotations {
+		markerType := "annotation"
+		if strings.HasPrefix(a.Code, aecgCodePrefix+"BEAT") {
+			markerType = "beat"
+		}
+
+		description := ""
+		if a.Duration > 0 {
+			description = fmt.Sprintf("持续时间: %g秒", a.Duration)
+		}
+
+		markers = append(markers, &model.Marker{
+			FileID:      fileID,
+			ChannelID:   channelID,
+			Position:    a.Onset,
+			Type:        markerType,
+			Label:       a.Label(),
+			Description: description,
+			CreatedBy:   createdBy,
+		})
+	}
+
+	return markers
+}
@@ -0,0 +1,243 @@
+package fileio
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+// testAECG 是包含节律序列和代表心搏序列的aECG文档
+const testAECG = `<?xml version="1.0" encoding="UTF-8"?>
+<AnnotatedECG xmlns="urn:hl7-org:v3">
+  <id root="2.16.840.1.113883" extension="ECG-1"/>
+  <effectiveTime><low value="20020102030400"/><high value="20020102030410"/></effectiveTime>
+  <component>
+    <series>
+      <code code="RHYTHM"/>
+      <component><sequenceSet>
+        <component><sequence>
+          <code code="TIME_ABSOLUTE"/>
+          <value><head value="20020102030405"/><increment value="2" unit="ms"/></value>
+        </sequence></component>
+        <component><sequence>
+          <code code="MDC_ECG_LEAD_I"/>
+          <value><origin value="0" unit="uV"/><scale value="5" unit="uV"/><digits>1 2 -3</digits></value>
+        </sequence></component>
+        <component><sequence>
+          <code code="MDC_ECG_LEAD_II"/>
+          <value><origin value="10" unit="uV"/><scale value="0.5" unit="uV"/><digits>4 0 2 6</digits></value>
+        </sequence></component>
+      </sequenceSet></component>
+      <subjectOf><annotationSet>
+        <component><annotation>
+          <code code="MDC_ECG_BEAT"/>
+          <value code="MDC_ECG_BEAT_NORMAL"/>
+          <support><supportingROI><component><boundary>
+            <code code="TIME_ABSOLUTE"/>
+            <value><low value="20020102030405.5"/><high value="20020102030406"/></value>
+          </boundary></component></supportingROI></support>
+          <component><annotation>
+            <code code="MDC_ECG_WAVC_PWAVE"/>
+            <support><supportingROI>
+              <component><boundary><code code="MDC_ECG_LEAD_II"/></boundary></component>
+              <component><boundary>
+                <code code="TIME_ABSOLUTE"/>
+                <value><low value="20020102030405.52"/></value>
+              </boundary></component>
+            </supportingROI></support>
+          </annotation></component>
+        </annotation></component>
+        <component><annotation>
+          <code code="MDC_ECG_HEART_RATE"/>
+          <value value="72" unit="bpm"/>
+        </annotation></component>
+      </annotationSet></subjectOf>
+    </series>
+  </component>
+  <component>
+    <series>
+      <code code="REPRESENTATIVE_BEAT"/>
+      <component><sequenceSet>
+        <component><sequence>
+          <code code="TIME_RELATIVE"/>
+          <value><head value="100" unit="ms"/><increment value="1000" unit="us"/></value>
+        </sequence></component>
+        <component><sequence>
+          <code code="MDC_ECG_LEAD_V1"/>
+          <value><digits>7 8</digits></value>
+        </sequence></component>
+      </sequenceSet></component>
+      <subjectOf><annotationSet>
+        <component><annotation>
+          <code code="MDC_ECG_WAVC_QRSWAVE"/>
+          <support><supportingROI><component><boundary>
+            <code code="TIME_RELATIVE"/>
+            <value><low value="0.15" unit="s"/><high value="250" unit="ms"/></value>
+          </boundary></component></supportingROI></support>
+        </annotation></component>
+      </annotationSet></subjectOf>
+    </series>
+  </component>
+</AnnotatedECG>
+`
+
+func TestReadAECG(t *testing.T) {
+	record, err := ReadAECG(strings.NewReader(testAECG))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if record.ID != "2.16.840.1.113883^ECG-1" {
+		t.Errorf("ID = %q", record.ID)
+	}
+	if want := time.Date(2002, 1, 2, 3, 4, 0, 0, time.UTC); !record.StartTime.Equal(want) {
+		t.Errorf("StartTime = %v, want %v", record.StartTime, want)
+	}
+	if len(record.Series) != 2 {
+		t.Fatalf("len(Series) = %d, want 2", len(record.Series))
+	}
+
+	rhythm := record.Series[0]
+	if want := time.Date(2002, 1, 2, 3, 4, 5, 0, time.UTC); rhythm.Code != "RHYTHM" || !rhythm.StartTime.Equal(want) || rhythm.SampleRate != 500 {
+		t.Errorf("节律序列 = %s, %v, %g", rhythm.Code, rhythm.StartTime, rhythm.SampleRate)
+	}
+	if len(rhythm.Leads) != 2 {
+		t.Fatalf("len(Leads) = %d, want 2", len(rhythm.Leads))
+	}
+	lead := rhythm.Leads[1]
+	if lead.Code != "MDC_ECG_LEAD_II" || lead.Name != "II" || lead.Unit != "uV" || lead.Origin != 10 || lead.Scale != 0.5 {
+		t.Errorf("Leads[1] = %+v", lead)
+	}
+	if want := []float64{12, 10, 11, 13}; !reflect.DeepEqual(lead.Values, want) {
+		t.Errorf("Leads[1].Values = %v, want %v", lead.Values, want)
+	}
+	if want := []float64{5, 10, -15}; !reflect.DeepEqual(rhythm.Leads[0].Values, want) {
+		t.Errorf("Leads[0].Values = %v, want %v", rhythm.Leads[0].Values, want)
+	}
+	if d := rhythm.Duration(); d != 0.008 {
+		t.Errorf("Duration = %g, want 0.008", d)
+	}
+
+	// 没有时间范围的心率测量值被跳过，嵌套注释跳过导联边界
+	if len(rhythm.Annotations) != 2 {
+		t.Fatalf("Annotations = %+v", rhythm.Annotations)
+	}
+	beat, pwave := rhythm.Annotations[0], rhythm.Annotations[1]
+	if beat.Code != "MDC_ECG_BEAT" || beat.Label() != "BEAT_NORMAL" || math.Abs(beat.Onset-0.5) > 1e-9 || math.Abs(beat.Duration-0.5) > 1e-9 {
+		t.Errorf("Annotations[0] = %+v", beat)
+	}
+	if pwave.Label() != "WAVC_PWAVE" || math.Abs(pwave.Onset-0.52) > 1e-9 || pwave.Duration != 0 {
+		t.Errorf("Annotations[1] = %+v", pwave)
+	}
+
+	// 相对时间序列的注释以序列开始为0
+	beatSeries := record.Series[1]
+	if !beatSeries.StartTime.IsZero() || math.Abs(beatSeries.SampleRate-1000) > 1e-9 {
+		t.Errorf("代表心搏序列 = %v, %g", beatSeries.StartTime, beatSeries.SampleRate)
+	}
+	if v1 := beatSeries.Leads[0]; v1.Scale != 1 || !reflect.DeepEqual(v1.Values, []float64{7, 8}) {
+		t.Errorf("Leads[0] = %+v", v1)
+	}
+	if a := beatSeries.Annotations; len(a) != 1 || math.Abs(a[0].Onset-0.05) > 1e-9 || math.Abs(a[0].Duration-0.1) > 1e-9 {
+		t.Errorf("Annotations = %+v", a)
+	}
+}
+
+func TestReadAECGErrors(t *testing.T) {
+	// 节律序列的部分替换为错误内容
+	replace := func(old, new string) string {
+		if !strings.Contains(testAECG, old) {
+			t.Fatalf("测试文档中没有%q", old)
+		}
+		return strings.Replace(testAECG, old, new, 1)
+	}
+
+	tests := map[string]string{
+		"不是XML":    "not xml",
+		"缺少时间序列":   replace(`<code code="TIME_ABSOLUTE"/>`+"\n          <value><head", `<code code="MDC_ECG_LEAD_III"/>`+"\n          <value><head"),
+		"多个时间序列":   replace(`<code code="MDC_ECG_LEAD_I"/>`, `<code code="TIME_RELATIVE"/>`),
+		"采样间隔为0":   replace(`<increment value="2" unit="ms"/>`, `<increment value="0" unit="ms"/>`),
+		"不支持的时间单位": replace(`<increment value="2" unit="ms"/>`, `<increment value="2" unit="min"/>`),
+		"scale无效":  replace(`<scale value="5" unit="uV"/>`, `<scale value="x" unit="uV"/>`),
+		"样本无效":     replace(`<digits>1 2 -3</digits>`, `<digits>1 two 3</digits>`),
+		"开始时间无效":   replace(`<low value="20020102030400"/>`, `<low value="2002"/>`),
+		"注释时间无效":   replace(`<low value="20020102030405.5"/>`, `<low value="soon"/>`),
+		"注释使用绝对时间": strings.Replace(testAECG, `<code code="TIME_RELATIVE"/>
+            <value><low value="0.15"`, `<code code="TIME_ABSOLUTE"/>
+            <value><low value="20020102030405"`, 1),
+	}
+	for name, doc := range tests {
+		if _, err := ReadAECG(strings.NewReader(doc)); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+}
+
+func TestParseHL7Time(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"20020102", time.Date(2002, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"2002010203", time.Date(2002, 1, 2, 3, 0, 0, 0, time.UTC)},
+		{"200201020304", time.Date(2002, 1, 2, 3, 4, 0, 0, time.UTC)},
+		{" 20020102030405 ", time.Date(2002, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"20020102030405.25", time.Date(2002, 1, 2, 3, 4, 5, 250e6, time.UTC)},
+		{"20020102030405+0130", time.Date(2002, 1, 2, 3, 4, 5, 0, time.FixedZone("", 90*60))},
+		{"20020102030405.5-0500", time.Date(2002, 1, 2, 3, 4, 5, 500e6, time.FixedZone("", -5*3600))},
+	}
+	for _, tt := range tests {
+		got, err := parseHL7Time(tt.value)
+		if err != nil {
+			t.Errorf("parseHL7Time(%q): %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseHL7Time(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "2002", "200201020304050", "20021302", "20020102+5", "20020102.x"} {
+		if _, err := parseHL7Time(value); err == nil {
+			t.Errorf("parseHL7Time(%q)应返回错误", value)
+		}
+	}
+}
+
+func TestAECGSeriesChannel(t *testing.T) {
+	record, err := ReadAECG(strings.NewReader(testAECG))
+	if err != nil {
+		t.Fatal(err)
+	}
+	series := &record.Series[0]
+
+	channel := data.NewChannel("0", "I")
+	if err := series.LoadLeadToChannel(0, channel); err != nil {
+		t.Fatal(err)
+	}
+	if len(channel.Data) != 3 || channel.SampleRate != 500 || !channel.StartTime.Equal(series.StartTime) {
+		t.Errorf("通道有%d个点，采样率%g", len(channel.Data), channel.SampleRate)
+	}
+	if p := channel.Data[2]; p.X != 0.004 || p.Y != -15 {
+		t.Errorf("Data[2] = %+v", p)
+	}
+	if err := series.LoadLeadToChannel(2, channel); err == nil {
+		t.Error("导联索引超出范围应返回错误")
+	}
+
+	markers := AECGAnnotationsToMarkers(series.Annotations, 1, 2, 3)
+	if len(markers) != 2 {
+		t.Fatalf("len(markers) = %d, want 2", len(markers))
+	}
+	m := markers[0]
+	if m.FileID != 1 || m.ChannelID != 2 || m.CreatedBy != 3 || m.Type != "beat" || m.Label != "BEAT_NORMAL" || m.Description != "持续时间: 0.5秒" {
+		t.Errorf("markers[0] = %+v", m)
+	}
+	if m := markers[1]; m.Type != "annotation" || m.Label != "WAVC_PWAVE" || m.Description != "" {
+		t.Errorf("markers[1] = %+v", m)
+	}
+}
@@ -0,0 +1,770 @@
+package fileio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+// CSV时间列的特殊取值
+const (
+	CSVNoTimeColumn   = -1 // 没有时间列，按采样率计算时间
+	CSVAutoTimeColumn = -2 // 根据表头名称或时间戳格式自动识别时间列
+)
+
+// CSV表头模式
+const (
+	CSVHeaderAuto    = iota // 第一行包含非数值字段时视为表头
+	CSVHeaderPresent        // 第一行为表头
+	CSVHeaderAbsent         // 没有表头
+)
+
+const (
+	csvSniffBytes    = 64 << 10 // 检测格式时读取的最大字节数
+	csvSniffLines    = 20       // 检测格式时分析的最大行数
+	csvIndexInterval = 1024     // 建立索引时每隔多少行记录一个检查点
+)
+
+// 自动检测分隔符时的候选，按优先级排列（分号优先于逗号，以支持小数逗号）
+var csvDelimiters = []rune{'\t', ';', ',', '|'}
+
+// 表头中的单位标注，如"ECG [mV]"、"ECG (mV)"
+var csvUnitPattern = regexp.MustCompile(`^(.*?)\s*[\[(]([^\])]*)[\])]\s*$`)
+
+// 可识别为时间列的表头名称
+var csvTimeNames = map[string]bool{
+	"time": true, "t": true, "timestamp": true, "datetime": true, "date": true,
+	"seconds": true, "sec": true, "elapsed": true, "zeit": true, "时间": true,
+}
+
+// 时间单位换算为秒的系数
+var csvTimeUnits = map[string]float64{
+	"s": 1, "sec": 1, "seconds": 1, "秒": 1,
+	"ms": 1e-3, "毫秒": 1e-3,
+	"us": 1e-6, "µs": 1e-6, "μs": 1e-6,
+	"min": 60, "分": 60,
+	"h": 3600, "hr": 3600,
+}
+
+// 时间戳列支持的格式
+var csvTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006/01/02 15:04:05.999999999",
+	"02.01.2006 15:04:05.999999999",
+	"15:04:05.999999999",
+}
+
+// CSVOptions 表示读取CSV/TSV文件的选项
+// 零值表示自动检测分隔符、小数点和表头，并以第一列作为以秒为单位的时间列。
+type CSVOptions struct {
+	Delimiter  rune    // 列分隔符，0表示自动检测（制表符、分号、逗号、竖线）
+	Decimal    rune    // 小数点，0表示自动检测（'.'或','）
+	Header     int     // 表头模式：CSVHeaderAuto、CSVHeaderPresent或CSVHeaderAbsent
+	TimeColumn int     // 时间列索引（从0开始），或CSVNoTimeColumn、CSVAutoTimeColumn
+	TimeScale  float64 // 数值时间列换算为秒的系数，0表示按表头中的时间单位确定（默认秒）
+	SampleRate float64 // 没有时间列时的采样率（Hz）
+	Columns    []int   // 要读取的数值列索引，为空时读取时间列以外的所有列
+}
+
+// CSVColumn 表示CSV文件中一个数值列的信息
+type CSVColumn struct {
+	Index int     // 在文件中的列索引
+	Name  string  // 列名，去掉了单位标注；没有表头时为"列N"
+	Unit  string  // 表头中标注的单位
+	Min   float64 // 最小值
+	Max   float64 // 最大值
+	Count int64   // 有效值的数量
+}
+
+// 检测或指定后的CSV布局
+type csvLayout struct {
+	delimiter  rune
+	decimal    rune
+	header     bool
+	bom        int64 // 文件开头UTF-8 BOM的字节数
+	timeColumn int
+	timeScale  float64
+	sampleRate float64
+	columns    []CSVColumn
+}
+
+// 将一行记录解析为时间和数值
+type csvRowParser struct {
+	layout   *csvLayout
+	collect  bool  // 是否统计列的范围和有效值数量
+	row      int64 // 下一行的数据行序号
+	hasBase  bool
+	base     float64   // 数值时间列第一行的值（秒）
+	baseTime time.Time // 时间戳列第一行的时间
+	values   []float64
+}
+
+// ScanCSV 流式读取CSV/TSV数据，对每个数据行调用fn，返回数值列的信息
+// t为相对第一行的时间（秒），values与返回的列一一对应，缺失值为NaN，在调用之间复用。
+func ScanCSV(r io.Reader, opts CSVOptions, fn func(t float64, values []float64) error) ([]CSVColumn, error) {
+	br := bufio.NewReaderSize(r, csvSniffBytes)
+	layout, err := readCSVLayout(br, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	cr := newCSVRecordReader(br, layout)
+	if layout.header {
+		if _, err := cr.Read(); err != nil {
+			return nil, err
+		}
+	}
+
+	parser := &csvRowParser{layout: layout, collect: true}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := cr.FieldPos(0)
+		t, err := parser.parse(record, line)
+		if err != nil {
+			return nil, err
+		}
+		if err := fn(t, parser.values); err != nil {
+			return nil, err
+		}
+	}
+
+	layout.finishColumns()
+	return layout.columns, nil
+}
+
+// ReadCSVChannels 流式读取CSV/TSV数据，每个数值列生成一个通道
+func ReadCSVChannels(r io.Reader, opts CSVOptions) ([]*data.Channel, error) {
+	var channels []*data.Channel
+
+	columns, err := ScanCSV(r, opts, func(t float64, values []float64) error {
+		if channels == nil {
+			channels = make([]*data.Channel, len(values))
+			for i := range channels {
+				channels[i] = data.NewChannel("", "")
+			}
+		}
+		for i, v := range values {
+			if !math.IsNaN(v) {
+				channels[i].AddDataPoint(t, v)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if channels == nil {
+		channels = make([]*data.Channel, len(columns))
+		for i := range channels {
+			channels[i] = data.NewChannel("", "")
+		}
+	}
+	for i, c := range columns {
+		channels[i].ID = strconv.Itoa(c.Index)
+		channels[i].Name = c.Name
+		channels[i].YAxisMin = c.Min
+		channels[i].YAxisMax = c.Max
+	}
+
+	return channels, nil
+}
+
+// CSVReader 表示CSV/TSV文件读取器
+// 打开时流式扫描一遍文件，统计每列的范围并每隔若干行记录字节偏移，按时间窗口读取时只解析窗口附近的行。
+type CSVReader struct {
+	file      *os.File
+	layout    *csvLayout
+	numRows   int64
+	duration  float64
+	startTime time.Time
+	parser    csvRowParser // 记录了时间基准的解析器模板
+	index     []csvCheckpoint
+	warnings  []string
+}
+
+// 索引检查点
+type csvCheckpoint struct {
+	offset int64   // 该行在文件中的字节偏移
+	row    int64   // 数据行序号
+	time   float64 // 该行的时间（秒）
+}
+
+// OpenCSV 打开一个CSV/TSV文件
+func OpenCSV(path string, opts CSVOptions) (*CSVReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := &CSVReader{file: file}
+	if err := reader.scan(opts); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return reader, nil
+}
+
+// 扫描整个文件，确定布局、统计列信息并建立索引
+func (r *CSVReader) scan(opts CSVOptions) error {
+	br := bufio.NewReaderSize(r.file, csvSniffBytes)
+	layout, err := readCSVLayout(br, opts)
+	if err != nil {
+		return err
+	}
+	if layout.timeColumn < 0 && opts.SampleRate <= 0 {
+		r.warnings = append(r.warnings, "没有时间列且未指定采样率，按1Hz计算时间")
+	}
+	r.layout = layout
+
+	cr := newCSVRecordReader(br, layout)
+	if layout.header {
+		if _, err := cr.Read(); err != nil {
+			return err
+		}
+	}
+
+	parser := csvRowParser{layout: layout, collect: true}
+	var firstTime, lastTime float64
+	for {
+		offset := layout.bom + cr.InputOffset()
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		line, _ := cr.FieldPos(0)
+		t, err := parser.parse(record, line)
+		if err != nil {
+			return err
+		}
+
+		if r.numRows == 0 {
+			firstTime = t
+			// 保存时间基准，按窗口读取时从检查点开始解析也能得到相同的时间
+			r.parser = parser
+			r.parser.collect = false
+			r.parser.values = nil
+		} else if t < lastTime {
+			return fmt.Errorf("第%d行的时间%g早于上一行的%g，时间列必须单调递增", line, t, lastTime)
+		}
+		if r.numRows%csvIndexInterval == 0 {
+			r.index = append(r.index, csvCheckpoint{offset: offset, row: r.numRows, time: t})
+		}
+
+		lastTime = t
+		r.numRows++
+	}
+
+	r.startTime = parser.baseTime
+	if layout.timeColumn >= 0 {
+		// 时间列的平均采样率，无法估计时按1Hz计算
+		layout.sampleRate = 1
+		if r.numRows > 1 && lastTime > firstTime {
+			layout.sampleRate = float64(r.numRows-1) / (lastTime - firstTime)
+		}
+	}
+	if r.numRows > 0 {
+		r.duration = lastTime + 1/layout.sampleRate
+	}
+
+	layout.finishColumns()
+	return nil
+}
+
+// Close 关闭文件
+func (r *CSVReader) Close() error {
+	return r.file.Close()
+}
+
+// Columns 返回数值列的信息
+func (r *CSVReader) Columns() []CSVColumn {
+	return r.layout.columns
+}
+
+// GetNumSignals 获取数值列的数量
+func (r *CSVReader) GetNumSignals() int {
+	return len(r.layout.columns)
+}
+
+// GetSignalSamplingRate 获取采样率，有时间列时为平均采样率
+func (r *CSVReader) GetSignalSamplingRate(signalIndex int) float64 {
+	return r.layout.sampleRate
+}
+
+// GetChannelInfo 获取通道信息，物理范围为该列的最小值和最大值
+func (r *CSVReader) GetChannelInfo(signalIndex int) (string, string, float64, float64) {
+	if signalIndex < 0 || signalIndex >= len(r.layout.columns) {
+		return "", "", 0, 0
+	}
+
+	c := r.layout.columns[signalIndex]
+	return c.Name, c.Unit, c.Min, c.Max
+}
+
+// NumRows 返回数据行数
+func (r *CSVReader) NumRows() int64 {
+	return r.numRows
+}
+
+// Duration 返回记录时长（秒）
+func (r *CSVReader) Duration() float64 {
+	return r.duration
+}
+
+// StartTime 返回时间戳列第一行的时间，数值时间列或没有时间列时为零值
+func (r *CSVReader) StartTime() time.Time {
+	return r.startTime
+}
+
+// Warnings 返回打开文件时产生的警告
+func (r *CSVReader) Warnings() []string {
+	return r.warnings
+}
+
+// ReadWindowPoints 读取第signalIndex个数值列在时间窗口[t0, t1)内的数据点，跳过缺失值
+func (r *CSVReader) ReadWindowPoints(signalIndex int, t0, t1 float64) ([]data.DataPoint, error) {
+	if signalIndex < 0 || signalIndex >= len(r.layout.columns) {
+		return nil, fmt.Errorf("信号索引超出范围: %d", signalIndex)
+	}
+	if t1 <= t0 {
+		return nil, fmt.Errorf("无效的时间窗口: [%g, %g)", t0, t1)
+	}
+
+	points := make([]data.DataPoint, 0)
+	if len(r.index) == 0 {
+		return points, nil
+	}
+
+	// 从时间不晚于t0的最后一个检查点开始解析
+	i := sort.Search(len(r.index), func(i int) bool { return r.index[i].time > t0 }) - 1
+	checkpoint := r.index[max(i, 0)]
+
+	info, err := r.file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	section := io.NewSectionReader(r.file, checkpoint.offset, info.Size()-checkpoint.offset)
+	cr := newCSVRecordReader(bufio.NewReader(section), r.layout)
+
+	parser := r.parser
+	parser.row = checkpoint.row
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := cr.FieldPos(0)
+		t, err := parser.parse(record, line)
+		if err != nil {
+			return nil, err
+		}
+		if t >= t1 {
+			break
+		}
+		if t < t0 {
+			continue
+		}
+
+		if v := parser.values[signalIndex]; !math.IsNaN(v) {
+			points = append(points, data.DataPoint{X: t, Y: v})
+		}
+	}
+
+	return points, nil
+}
+
+// LoadSignalToChannel 将第signalIndex个数值列加载到通道
+func (r *CSVReader) LoadSignalToChannel(signalIndex int, channel *data.Channel) error {
+	points, err := r.ReadWindowPoints(signalIndex, 0, math.Inf(1))
+	if err != nil {
+		return err
+	}
+
+	channel.ClearData()
+	channel.Data = points
+	channel.StartTime = r.startTime
+	channel.SampleRate = r.GetSignalSamplingRate(signalIndex)
+
+	return nil
+}
+
+// 从缓冲读取器开头检测CSV布局，并跳过UTF-8 BOM
+func readCSVLayout(br *bufio.Reader, opts CSVOptions) (*csvLayout, error) {
+	sample, err := br.Peek(csvSniffBytes)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	complete := err == io.EOF
+
+	var bom int64
+	if bytes.HasPrefix(sample, []byte("\xef\xbb\xbf")) {
+		bom = 3
+		sample = sample[3:]
+	}
+
+	layout, err := detectCSVLayout(sample, complete, opts)
+	if err != nil {
+		return nil, err
+	}
+	layout.bom = bom
+
+	if _, err := br.Discard(int(bom)); err != nil {
+		return nil, err
+	}
+	return layout, nil
+}
+
+// 根据文件开头的样本确定分隔符、小数点、表头、时间列和数值列
+// complete表示样本包含整个文件，否则丢弃可能不完整的最后一行。
+func detectCSVLayout(sample []byte, complete bool, opts CSVOptions) (*csvLayout, error) {
+	lines := csvSampleLines(sample, complete)
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("CSV文件为空")
+	}
+
+	layout := &csvLayout{
+		delimiter:  opts.Delimiter,
+		decimal:    opts.Decimal,
+		timeColumn: opts.TimeColumn,
+		timeScale:  opts.TimeScale,
+		sampleRate: opts.SampleRate,
+	}
+	if layout.delimiter == 0 {
+		layout.delimiter = detectCSVDelimiter(lines)
+	}
+
+	// 用检测到的分隔符解析样本行
+	cr := newCSVRecordReader(strings.NewReader(strings.Join(lines, "\n")), layout)
+	var rows [][]string
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// 记录读取器复用了切片，需要复制
+		rows = append(rows, append([]string(nil), record...))
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("CSV文件没有数据行")
+	}
+
+	if layout.decimal == 0 {
+		layout.decimal = detectCSVDecimal(rows, layout.delimiter)
+	}
+
+	switch opts.Header {
+	case CSVHeaderPresent:
+		layout.header = true
+	case CSVHeaderAbsent:
+		layout.header = false
+	default:
+		layout.header = !layout.isDataRow(rows[0])
+	}
+
+	var names []string
+	dataRows := rows
+	if layout.header {
+		names = rows[0]
+		dataRows = rows[1:]
+	}
+
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+
+	// 拆分列名和单位
+	columnNames := make([]string, width)
+	columnUnits := make([]string, width)
+	for i := range columnNames {
+		columnNames[i] = fmt.Sprintf("列%d", i+1)
+		if i < len(names) {
+			name := strings.TrimSpace(names[i])
+			if m := csvUnitPattern.FindStringSubmatch(name); m != nil {
+				name, columnUnits[i] = m[1], strings.TrimSpace(m[2])
+			}
+			if name != "" {
+				columnNames[i] = name
+			}
+		}
+	}
+
+	if layout.timeColumn == CSVAutoTimeColumn {
+		layout.timeColumn = detectCSVTimeColumn(columnNames, layout.header, dataRows)
+	}
+	if layout.timeColumn >= width {
+		return nil, fmt.Errorf("时间列%d超出列数%d", layout.timeColumn, width)
+	}
+	if layout.timeColumn >= 0 {
+		if layout.timeScale == 0 {
+			layout.timeScale = 1
+			if scale, ok := csvTimeUnits[strings.ToLower(columnUnits[layout.timeColumn])]; ok {
+				layout.timeScale = scale
+			}
+		}
+	} else if layout.sampleRate <= 0 {
+		layout.sampleRate = 1
+	}
+
+	// 确定数值列
+	indices := opts.Columns
+	if len(indices) == 0 {
+		for i := 0; i < width; i++ {
+			if i != layout.timeColumn {
+				indices = append(indices, i)
+			}
+		}
+	}
+	for _, i := range indices {
+		if i < 0 || i >= width {
+			return nil, fmt.Errorf("数值列%d超出列数%d", i, width)
+		}
+		if i == layout.timeColumn {
+			return nil, fmt.Errorf("列%d已作为时间列", i)
+		}
+		layout.columns = append(layout.columns, CSVColumn{
+			Index: i,
+			Name:  columnNames[i],
+			Unit:  columnUnits[i],
+			Min:   math.Inf(1),
+			Max:   math.Inf(-1),
+		})
+	}
+	if len(layout.columns) == 0 {
+		return nil, fmt.Errorf("CSV文件没有数值列")
+	}
+
+	return layout, nil
+}
+
+// 提取样本中的非空行，跳过注释行
+func csvSampleLines(sample []byte, complete bool) []string {
+	text := string(sample)
+	if !complete {
+		if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+			text = text[:i]
+		}
+	}
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+		if len(lines) == csvSniffLines {
+			break
+		}
+	}
+	return lines
+}
+
+// 选择在所有样本行中出现次数一致且大于0的第一个候选分隔符
+func detectCSVDelimiter(lines []string) rune {
+	for _, d := range csvDelimiters {
+		count := strings.Count(lines[0], string(d))
+		if count == 0 {
+			continue
+		}
+
+		consistent := true
+		for _, line := range lines[1:] {
+			if strings.Count(line, string(d)) != count {
+				consistent = false
+				break
+			}
+		}
+		if consistent {
+			return d
+		}
+	}
+	return ','
+}
+
+// 分隔符不是逗号且数值中出现"1,5"形式时使用小数逗号
+func detectCSVDecimal(rows [][]string, delimiter rune) rune {
+	if delimiter == ',' {
+		return '.'
+	}
+
+	for _, row := range rows {
+		for _, field := range row {
+			field = strings.TrimSpace(field)
+			if strings.Count(field, ",") == 1 && !strings.Contains(field, ".") {
+				if _, err := strconv.ParseFloat(strings.Replace(field, ",", ".", 1), 64); err == nil {
+					return ','
+				}
+			}
+		}
+	}
+	return '.'
+}
+
+// 表头名称为时间类名称的列优先，否则第一列为时间戳时作为时间列，都不满足时没有时间列
+func detectCSVTimeColumn(names []string, header bool, rows [][]string) int {
+	if header {
+		for i, name := range names {
+			if csvTimeNames[strings.ToLower(name)] {
+				return i
+			}
+		}
+	}
+
+	if len(rows) > 0 && len(rows[0]) > 0 {
+		if _, ok := parseCSVTimestamp(strings.TrimSpace(rows[0][0])); ok {
+			return 0
+		}
+	}
+	return CSVNoTimeColumn
+}
+
+// 判断一行是否为数据行：所有非空字段都是数值或时间戳
+func (l *csvLayout) isDataRow(row []string) bool {
+	for _, field := range row {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if _, err := l.parseNumber(field); err == nil {
+			continue
+		}
+		if _, ok := parseCSVTimestamp(field); ok {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// 扫描结束后将没有有效值的列范围置为0
+func (l *csvLayout) finishColumns() {
+	for i := range l.columns {
+		if c := &l.columns[i]; c.Count == 0 {
+			c.Min, c.Max = 0, 0
+		}
+	}
+}
+
+// 按布局的小数点解析数值
+func (l *csvLayout) parseNumber(field string) (float64, error) {
+	if l.decimal == ',' {
+		field = strings.Replace(field, ",", ".", 1)
+	}
+	return strconv.ParseFloat(field, 64)
+}
+
+// 解析时间戳
+func parseCSVTimestamp(field string) (time.Time, bool) {
+	for _, layout := range csvTimeLayouts {
+		if t, err := time.Parse(layout, field); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// 创建按布局解析记录的csv.Reader
+func newCSVRecordReader(r io.Reader, layout *csvLayout) *csv.Reader {
+	cr := csv.NewReader(r)
+	cr.Comma = layout.delimiter
+	if layout.delimiter != '#' {
+		cr.Comment = '#'
+	}
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.ReuseRecord = true
+	return cr
+}
+
+// 解析一个数据行，返回相对第一行的时间，数值保存在p.values中
+func (p *csvRowParser) parse(record []string, line int) (float64, error) {
+	l := p.layout
+	if p.values == nil {
+		p.values = make([]float64, len(l.columns))
+	}
+
+	var t float64
+	if l.timeColumn >= 0 {
+		if l.timeColumn >= len(record) {
+			return 0, fmt.Errorf("第%d行缺少时间列", line)
+		}
+		field := strings.TrimSpace(record[l.timeColumn])
+
+		if v, err := l.parseNumber(field); err == nil {
+			v *= l.timeScale
+			if !p.hasBase {
+				p.base, p.hasBase = v, true
+			}
+			t = v - p.base
+		} else if ts, ok := parseCSVTimestamp(field); ok {
+			if !p.hasBase {
+				p.baseTime, p.hasBase = ts, true
+			}
+			t = ts.Sub(p.baseTime).Seconds()
+		} else {
+			return 0, fmt.Errorf("第%d行的时间无法解析: %q", line, field)
+		}
+	} else {
+		t = float64(p.row) / l.sampleRate
+	}
+	p.row++
+
+	for i := range l.columns {
+		c := &l.columns[i]
+		p.values[i] = math.NaN()
+		if c.Index >= len(record) {
+			continue
+		}
+
+		field := strings.TrimSpace(record[c.Index])
+		if field == "" {
+			continue
+		}
+		v, err := l.parseNumber(field)
+		if err != nil {
+			return 0, fmt.Errorf("第%d行第%d列不是数值: %q", line, c.Index+1, field)
+		}
+		p.values[i] = v
+
+		if p.collect {
+			c.Min = math.Min(c.Min, v)
+			c.Max = math.Max(c.Max, v)
+			c.Count++
+		}
+	}
+
+	return t, nil
+}
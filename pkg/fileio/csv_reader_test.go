@@ -0,0 +1,262 @@
+package fileio
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectCSVLayout(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		opts      CSVOptions
+		delimiter rune
+		decimal   rune
+		header    bool
+		timeCol   int
+		timeScale float64
+		columns   []CSVColumn
+	}{
+		{
+			name:      "逗号分隔带单位",
+			content:   "time [ms],ECG (mV),Resp\n0,0.5,1\n4,0.6,2\n",
+			opts:      CSVOptions{TimeColumn: CSVAutoTimeColumn},
+			delimiter: ',', decimal: '.', header: true, timeCol: 0, timeScale: 1e-3,
+			columns: []CSVColumn{{Index: 1, Name: "ECG", Unit: "mV"}, {Index: 2, Name: "Resp"}},
+		},
+		{
+			name:      "分号分隔小数逗号",
+			content:   "Zeit;Druck [mmHg]\n0;80,5\n0,5;81,25\n",
+			opts:      CSVOptions{TimeColumn: CSVAutoTimeColumn},
+			delimiter: ';', decimal: ',', header: true, timeCol: 0, timeScale: 1,
+			columns: []CSVColumn{{Index: 1, Name: "Druck", Unit: "mmHg"}},
+		},
+		{
+			name:      "制表符分隔时间列不在第一列",
+			content:   "ch1\tt (min)\tch2\n1\t0\t2\n",
+			opts:      CSVOptions{TimeColumn: CSVAutoTimeColumn},
+			delimiter: '\t', decimal: '.', header: true, timeCol: 1, timeScale: 60,
+			columns: []CSVColumn{{Index: 0, Name: "ch1"}, {Index: 2, Name: "ch2"}},
+		},
+		{
+			name:      "没有表头和时间列",
+			content:   "1|2\n3|4\n",
+			opts:      CSVOptions{TimeColumn: CSVAutoTimeColumn, SampleRate: 250},
+			delimiter: '|', decimal: '.', header: false, timeCol: CSVNoTimeColumn,
+			columns: []CSVColumn{{Index: 0, Name: "列1"}, {Index: 1, Name: "列2"}},
+		},
+		{
+			name:      "指定列和表头",
+			content:   "1,2,3\n4,5,6\n",
+			opts:      CSVOptions{Header: CSVHeaderPresent, Columns: []int{2}},
+			delimiter: ',', decimal: '.', header: true, timeCol: 0, timeScale: 1,
+			columns: []CSVColumn{{Index: 2, Name: "3"}},
+		},
+		{
+			name:      "注释行和时间戳",
+			content:   "# 设备导出\n2024-01-02 03:04:05,1\n2024-01-02 03:04:06,2\n",
+			opts:      CSVOptions{TimeColumn: CSVAutoTimeColumn},
+			delimiter: ',', decimal: '.', header: false, timeCol: 0, timeScale: 1,
+			columns: []CSVColumn{{Index: 1, Name: "列2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout, err := detectCSVLayout([]byte(tt.content), true, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if layout.delimiter != tt.delimiter || layout.decimal != tt.decimal || layout.header != tt.header {
+				t.Errorf("分隔符%q，小数点%q，表头%v", layout.delimiter, layout.decimal, layout.header)
+			}
+			if layout.timeColumn != tt.timeCol || (tt.timeCol >= 0 && layout.timeScale != tt.timeScale) {
+				t.Errorf("时间列%d，换算系数%g", layout.timeColumn, layout.timeScale)
+			}
+			got := make([]CSVColumn, len(layout.columns))
+			for i, c := range layout.columns {
+				got[i] = CSVColumn{Index: c.Index, Name: c.Name, Unit: c.Unit}
+			}
+			if !reflect.DeepEqual(got, tt.columns) {
+				t.Errorf("columns = %+v, want %+v", got, tt.columns)
+			}
+		})
+	}
+}
+
+func TestDetectCSVLayoutErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		opts    CSVOptions
+	}{
+		{"空文件", "\n# 只有注释\n", CSVOptions{}},
+		{"时间列超出列数", "1,2\n", CSVOptions{TimeColumn: 5}},
+		{"数值列超出列数", "1,2\n", CSVOptions{Columns: []int{3}}},
+		{"数值列是时间列", "1,2\n", CSVOptions{Columns: []int{0}}},
+		{"没有数值列", "1\n2\n", CSVOptions{}},
+	}
+	for _, tt := range tests {
+		if _, err := detectCSVLayout([]byte(tt.content), true, tt.opts); err == nil {
+			t.Errorf("%s: 应返回错误", tt.name)
+		}
+	}
+}
+
+func TestScanCSV(t *testing.T) {
+	content := "\xef\xbb\xbftime [ms];a;b\n1000;1,5;\n1004;2;-1\n\n# 暂停\n1012;;3e2\n"
+
+	var times []float64
+	var rows [][]float64
+	columns, err := ScanCSV(strings.NewReader(content), CSVOptions{TimeColumn: CSVAutoTimeColumn}, func(t float64, values []float64) error {
+		times = append(times, t)
+		rows = append(rows, append([]float64(nil), values...))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []float64{0, 0.004, 0.012}; len(times) != 3 || math.Abs(times[1]-want[1]) > 1e-12 || math.Abs(times[2]-want[2]) > 1e-12 {
+		t.Errorf("times = %v, want %v", times, want)
+	}
+	want := [][]float64{{1.5, math.NaN()}, {2, -1}, {math.NaN(), 300}}
+	for i := range want {
+		for j := range want[i] {
+			if got := rows[i][j]; got != want[i][j] && !(math.IsNaN(got) && math.IsNaN(want[i][j])) {
+				t.Errorf("第%d行第%d列 = %g, want %g", i, j, got, want[i][j])
+			}
+		}
+	}
+
+	wantColumns := []CSVColumn{
+		{Index: 1, Name: "a", Min: 1.5, Max: 2, Count: 2},
+		{Index: 2, Name: "b", Min: -1, Max: 300, Count: 2},
+	}
+	if !reflect.DeepEqual(columns, wantColumns) {
+		t.Errorf("columns = %+v, want %+v", columns, wantColumns)
+	}
+}
+
+func TestScanCSVErrors(t *testing.T) {
+	tests := map[string]string{
+		"数值无法解析": "t,a\n0,1\n1,x\n",
+		"时间无法解析": "t,a\n0,1\nabc,2\n",
+		"缺少时间列":  "a,t\n1,0\n2\n",
+	}
+	for name, content := range tests {
+		opts := CSVOptions{TimeColumn: CSVAutoTimeColumn}
+		if _, err := ScanCSV(strings.NewReader(content), opts, func(float64, []float64) error { return nil }); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+
+	// 回调的错误原样返回
+	stop := fmt.Errorf("stop")
+	if _, err := ScanCSV(strings.NewReader("1,2\n"), CSVOptions{}, func(float64, []float64) error { return stop }); err != stop {
+		t.Errorf("err = %v, want %v", err, stop)
+	}
+}
+
+func TestReadCSVChannels(t *testing.T) {
+	channels, err := ReadCSVChannels(strings.NewReader("1\t2\n3\t\n5\t6\n"), CSVOptions{TimeColumn: CSVNoTimeColumn, SampleRate: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(channels) != 2 {
+		t.Fatalf("len(channels) = %d, want 2", len(channels))
+	}
+
+	// 缺失值不生成数据点
+	b := channels[1]
+	if b.ID != "1" || b.Name != "列2" || b.YAxisMin != 2 || b.YAxisMax != 6 || len(b.Data) != 2 || b.Data[1].X != 1 {
+		t.Errorf("channels[1] = %+v", b)
+	}
+	if a := channels[0]; len(a.Data) != 3 || a.Data[2].X != 1 || a.Data[2].Y != 5 {
+		t.Errorf("channels[0].Data = %v", a.Data)
+	}
+}
+
+func TestOpenCSV(t *testing.T) {
+	// 超过一个索引间隔的行数，按窗口读取需要从中间的检查点开始
+	const rows = 3*csvIndexInterval + 17
+	var b strings.Builder
+	b.WriteString("time,ecg,spo2\n")
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&b, "%g,%d,%d\n", float64(i)/100, i, 90+i%10)
+	}
+	path := writeTestFile(t, "test.csv", []byte(b.String()))
+
+	r, err := OpenCSV(path, CSVOptions{TimeColumn: CSVAutoTimeColumn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if r.NumRows() != rows || r.GetNumSignals() != 2 || len(r.index) != 4 {
+		t.Fatalf("NumRows = %d, GetNumSignals = %d, 检查点%d个", r.NumRows(), r.GetNumSignals(), len(r.index))
+	}
+	if rate := r.GetSignalSamplingRate(0); math.Abs(rate-100) > 1e-6 {
+		t.Errorf("采样率 = %g, want 100", rate)
+	}
+	if d := r.Duration(); math.Abs(d-float64(rows)/100) > 1e-6 {
+		t.Errorf("Duration = %g", d)
+	}
+	if name, unit, lo, hi := r.GetChannelInfo(1); name != "spo2" || unit != "" || lo != 90 || hi != 99 {
+		t.Errorf("GetChannelInfo = %s, %s, %g, %g", name, unit, lo, hi)
+	}
+
+	points, err := r.ReadWindowPoints(0, 25, 25.05)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 5 || points[0].Y != 2500 || math.Abs(points[0].X-25) > 1e-9 {
+		t.Errorf("ReadWindowPoints = %v", points)
+	}
+
+	if _, err := r.ReadWindowPoints(2, 0, 1); err == nil {
+		t.Error("信号索引超出范围应返回错误")
+	}
+	if _, err := r.ReadWindowPoints(0, 1, 1); err == nil {
+		t.Error("空时间窗口应返回错误")
+	}
+}
+
+func TestOpenCSVTimestamps(t *testing.T) {
+	path := writeTestFile(t, "hr.csv", []byte("timestamp,hr\n2024-01-02 03:04:05,60\n2024-01-02 03:04:05.5,61\n2024-01-02 03:04:06,62\n"))
+	r, err := OpenCSV(path, CSVOptions{TimeColumn: CSVAutoTimeColumn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC); !r.StartTime().Equal(want) {
+		t.Errorf("StartTime = %v, want %v", r.StartTime(), want)
+	}
+	points, err := r.ReadWindowPoints(0, 0.5, 10)
+	if err != nil || len(points) != 2 || points[0].X != 0.5 || points[1].Y != 62 {
+		t.Errorf("ReadWindowPoints = %v, %v", points, err)
+	}
+}
+
+func TestOpenCSVErrors(t *testing.T) {
+	path := writeTestFile(t, "bad.csv", []byte("t,a\n0,1\n2,2\n1,3\n"))
+	if _, err := OpenCSV(path, CSVOptions{}); err == nil {
+		t.Error("时间不单调递增时应返回错误")
+	}
+
+	// 没有时间列且未指定采样率时按1Hz计算并给出警告
+	path = writeTestFile(t, "norate.csv", []byte("1\n2\n"))
+	r, err := OpenCSV(path, CSVOptions{TimeColumn: CSVNoTimeColumn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if len(r.Warnings()) != 1 || r.Duration() != 2 {
+		t.Errorf("Warnings = %v, Duration = %g", r.Warnings(), r.Duration())
+	}
+}
@@ -0,0 +1,211 @@
+package fileio
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+	"github.com/ljx520ljx/chartSystem/pkg/fileio/dicomtest"
+)
+
+// 两个多路复用组的测试数据集：
+// 节律组2个通道4个样本，第2个通道的第2个样本为填充值；中位心搏组1个通道，偏移250ms
+func testDICOMDataset(e dicomtest.Encoder) []byte {
+	rhythm := bytes.Join([][]byte{
+		e.Str(tagMultiplexGroupLabel, "CS", "RHYTHM"),
+		e.US(tagNumberOfWaveformChannels, 2),
+		e.UL(tagNumberOfWaveformSamples, 4),
+		e.Str(tagSamplingFrequency, "DS", "500"),
+		e.Seq(tagChannelDefinitionSequence,
+			bytes.Join([][]byte{
+				e.Seq(tagChannelSourceSequence, bytes.Join([][]byte{
+					e.Str(tagCodeValue, "SH", "5.6.3-9-1"),
+					e.Str(tagCodeMeaning, "LO", "Lead I"),
+				}, nil)),
+				e.Str(tagChannelSensitivity, "DS", "2.5"),
+				e.Seq(tagChannelSensitivityUnitsSeq, e.Str(tagCodeValue, "SH", "uV")),
+				e.Str(tagChannelSensitivityCorrFact, "DS", "1"),
+				e.Str(tagChannelBaseline, "DS", "0"),
+			}, nil),
+			bytes.Join([][]byte{
+				e.Str(tagWaveformChannelNumber, "IS", "7"),
+				e.Str(tagChannelLabel, "SH", "II"),
+				e.Str(tagChannelSensitivity, "DS", "0.5\\1"),
+				e.Str(tagChannelSensitivityCorrFact, "DS", "2"),
+				e.Str(tagChannelBaseline, "DS", "10"),
+				e.Str(tagChannelTimeSkew, "DS", "0.001"),
+			}, nil),
+		),
+		e.US(tagWaveformBitsAllocated, 16),
+		e.Str(tagWaveformSampleInterpretation, "CS", "SS"),
+		e.Elem(tagWaveformPaddingValue, "OW", dicomtest.Int16Data(math.MinInt16)),
+		e.Elem(tagWaveformData, "OW", dicomtest.Int16Data(1, 2, 3, math.MinInt16, -4, 5, 6, 7)),
+	}, nil)
+
+	median := bytes.Join([][]byte{
+		e.Str(tagMultiplexGroupLabel, "CS", "MEDIAN BEAT"),
+		e.US(tagNumberOfWaveformChannels, 1),
+		e.UL(tagNumberOfWaveformSamples, 2),
+		e.Str(tagSamplingFrequency, "DS", "1000"),
+		e.Str(tagMultiplexGroupTimeOffset, "DS", "250"),
+		e.Seq(tagChannelDefinitionSequence, e.Str(tagChannelBaseline, "DS", "1")),
+		e.US(tagWaveformBitsAllocated, 8),
+		e.Str(tagWaveformSampleInterpretation, "CS", "SB"),
+		e.Elem(tagWaveformData, "OB", []byte{0xFF, 0x02}),
+	}, nil)
+
+	return bytes.Join([][]byte{
+		e.Str(tagSOPClassUID, "UI", "1.2.840.10008.5.1.4.1.1.9.1.1"),
+		e.Str(tagAcquisitionDateTime, "DT", "20240102030405"),
+		e.Seq(tagWaveformSequence, rhythm, median),
+	}, nil)
+}
+
+// 比较物理值，NaN与NaN视为相等
+func equalValues(got, want []float64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if math.IsNaN(want[i]) != math.IsNaN(got[i]) || (!math.IsNaN(want[i]) && math.Abs(got[i]-want[i]) > 1e-9) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestReadDICOMWaveform(t *testing.T) {
+	tests := map[string][]byte{
+		"显式VR":    dicomtest.File(dicomtest.ExplicitVRLittleEndian, testDICOMDataset(dicomtest.Encoder{Explicit: true})),
+		"隐式VR":    dicomtest.File(dicomtest.ImplicitVRLittleEndian, testDICOMDataset(dicomtest.Encoder{})),
+		"没有文件元信息": testDICOMDataset(dicomtest.Encoder{Explicit: true}),
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			waveform, err := ReadDICOMWaveform(bytes.NewReader(content))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC); waveform.SOPClassUID != "1.2.840.10008.5.1.4.1.1.9.1.1" || !waveform.StartTime.Equal(want) {
+				t.Errorf("SOPClassUID = %s, StartTime = %v", waveform.SOPClassUID, waveform.StartTime)
+			}
+			if len(waveform.Groups) != 2 {
+				t.Fatalf("len(Groups) = %d, want 2", len(waveform.Groups))
+			}
+
+			rhythm := waveform.Groups[0]
+			if rhythm.Label != "RHYTHM" || rhythm.SampleRate != 500 || rhythm.NumSamples != 4 || rhythm.TimeOffset != 0 || len(rhythm.Channels) != 2 {
+				t.Fatalf("Groups[0] = %+v", rhythm)
+			}
+			lead1 := rhythm.Channels[0]
+			if lead1.Number != 1 || lead1.Label != "Lead I" || lead1.SourceCode != "5.6.3-9-1" || lead1.Unit != "uV" || lead1.Sensitivity != 2.5 {
+				t.Errorf("Channels[0] = %+v", lead1)
+			}
+			if want := []float64{2.5, 7.5, -10, 15}; !equalValues(lead1.Values, want) {
+				t.Errorf("Channels[0].Values = %v, want %v", lead1.Values, want)
+			}
+
+			// 多值的灵敏度取第一个值，填充样本为NaN
+			lead2 := rhythm.Channels[1]
+			if lead2.Number != 7 || lead2.Label != "II" || lead2.Sensitivity != 0.5 || lead2.CorrectionFactor != 2 || lead2.Baseline != 10 || lead2.TimeSkew != 0.001 {
+				t.Errorf("Channels[1] = %+v", lead2)
+			}
+			if want := []float64{12, math.NaN(), 15, 17}; !equalValues(lead2.Values, want) {
+				t.Errorf("Channels[1].Values = %v, want %v", lead2.Values, want)
+			}
+
+			median := waveform.Groups[1]
+			if median.Label != "MEDIAN BEAT" || median.SampleRate != 1000 || median.TimeOffset != 0.25 || len(median.Channels) != 1 {
+				t.Fatalf("Groups[1] = %+v", median)
+			}
+			if c := median.Channels[0]; c.Label != "通道1" || c.Sensitivity != 1 || !equalValues(c.Values, []float64{0, 3}) {
+				t.Errorf("Groups[1].Channels[0] = %+v", c)
+			}
+		})
+	}
+}
+
+func TestReadDICOMWaveformErrors(t *testing.T) {
+	e := dicomtest.Encoder{Explicit: true}
+	valid := testDICOMDataset(e)
+
+	// 替换数据集中的一个元素
+	replace := func(old, new []byte) []byte {
+		if !bytes.Contains(valid, old) {
+			t.Fatalf("测试数据集中没有%x", old)
+		}
+		return dicomtest.File(dicomtest.ExplicitVRLittleEndian, bytes.Replace(valid, old, new, 1))
+	}
+
+	tests := map[string][]byte{
+		"大端传输语法":  dicomtest.File("1.2.840.10008.1.2.2", valid),
+		"没有波形序列":  dicomtest.File(dicomtest.ExplicitVRLittleEndian, e.Str(tagSOPClassUID, "UI", "1.2.3")),
+		"截断":      dicomtest.File(dicomtest.ExplicitVRLittleEndian, valid[:len(valid)-5]),
+		"缺少通道数":   replace(e.US(tagNumberOfWaveformChannels, 2), e.US(tagNumberOfWaveformChannels, 0)),
+		"采样频率无效":  replace(e.Str(tagSamplingFrequency, "DS", "500"), e.Str(tagSamplingFrequency, "DS", "abc")),
+		"采样频率为0":  replace(e.Str(tagSamplingFrequency, "DS", "500"), e.Str(tagSamplingFrequency, "DS", "0")),
+		"通道定义不足":  replace(e.US(tagNumberOfWaveformChannels, 2), e.US(tagNumberOfWaveformChannels, 3)),
+		"样本数过多":   replace(e.UL(tagNumberOfWaveformSamples, 4), e.UL(tagNumberOfWaveformSamples, 5)),
+		"样本格式不支持": replace(e.Str(tagWaveformSampleInterpretation, "CS", "SS"), e.Str(tagWaveformSampleInterpretation, "CS", "FL")),
+		"基线无效":    replace(e.Str(tagChannelBaseline, "DS", "10"), e.Str(tagChannelBaseline, "DS", "x")),
+	}
+	for name, content := range tests {
+		if _, err := ReadDICOMWaveform(bytes.NewReader(content)); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+}
+
+func TestDICOMStartTime(t *testing.T) {
+	e := dicomtest.Encoder{Explicit: true}
+	ds, err := parseDICOM(bytes.Join([][]byte{
+		e.Str(tagStudyDate, "DA", "20240102"),
+		e.Str(tagStudyTime, "TM", "03:04:05"),
+	}, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC); !ds.startTime().Equal(want) {
+		t.Errorf("startTime = %v, want %v", ds.startTime(), want)
+	}
+	if !(dicomDataset{}).startTime().IsZero() {
+		t.Error("没有日期时应返回零值")
+	}
+}
+
+func TestDICOMGroupLoadChannel(t *testing.T) {
+	waveform, err := ReadDICOMWaveform(bytes.NewReader(dicomtest.File(dicomtest.ExplicitVRLittleEndian, testDICOMDataset(dicomtest.Encoder{Explicit: true}))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 填充样本不生成数据点，时间包含通道偏斜
+	channel := data.NewChannel("1", "II")
+	if err := waveform.Groups[0].LoadChannel(1, channel); err != nil {
+		t.Fatal(err)
+	}
+	if len(channel.Data) != 3 || channel.SampleRate != 500 {
+		t.Fatalf("通道有%d个点，采样率%g", len(channel.Data), channel.SampleRate)
+	}
+	if p := channel.Data[1]; math.Abs(p.X-0.005) > 1e-12 || p.Y != 15 {
+		t.Errorf("Data[1] = %+v", p)
+	}
+	if err := waveform.Groups[0].LoadChannel(2, channel); err == nil {
+		t.Error("通道索引超出范围应返回错误")
+	}
+}
+
+func TestIsDICOM(t *testing.T) {
+	file := dicomtest.File(dicomtest.ExplicitVRLittleEndian)
+	if !IsDICOM(file) {
+		t.Error("带前导区的文件应识别为DICOM")
+	}
+	if IsDICOM(file[:131]) || IsDICOM([]byte(strings.Repeat("x", 132))) {
+		t.Error("没有DICM标识时不应识别为DICOM")
+	}
+}
@@ -0,0 +1,114 @@
+// Package dicomtest 提供构造DICOM测试文件的编码工具，供DICOM波形读取的测试使用
+package dicomtest
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// 支持的传输语法UID
+const (
+	ImplicitVRLittleEndian = "1.2.840.10008.1.2"
+	ExplicitVRLittleEndian = "1.2.840.10008.1.2.1"
+)
+
+// 文件元信息中的传输语法UID标签
+const tagTransferSyntaxUID = 0x00020010
+
+// 未定义长度
+const undefinedLength = 0xFFFFFFFF
+
+// 显式VR中使用4字节长度的VR
+var longVRs = map[string]bool{
+	"OB": true, "OD": true, "OF": true, "OL": true, "OV": true, "OW": true,
+	"SQ": true, "SV": true, "UC": true, "UN": true, "UR": true, "UT": true, "UV": true,
+}
+
+// Encoder 按显式或隐式VR小端编码数据元素
+type Encoder struct {
+	Explicit bool
+}
+
+// Elem 编码一个数据元素，值补齐为偶数长度
+func (e Encoder) Elem(tag uint32, vr string, value []byte) []byte {
+	if len(value)%2 == 1 {
+		pad := byte(0)
+		if vr != "OB" && vr != "UI" {
+			pad = ' '
+		}
+		value = append(append([]byte(nil), value...), pad)
+	}
+
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, [2]uint16{uint16(tag >> 16), uint16(tag)})
+	switch {
+	case !e.Explicit:
+		binary.Write(&b, binary.LittleEndian, uint32(len(value)))
+	case longVRs[vr]:
+		b.WriteString(vr)
+		binary.Write(&b, binary.LittleEndian, uint16(0))
+		binary.Write(&b, binary.LittleEndian, uint32(len(value)))
+	default:
+		b.WriteString(vr)
+		binary.Write(&b, binary.LittleEndian, uint16(len(value)))
+	}
+	b.Write(value)
+	return b.Bytes()
+}
+
+// Str 编码字符串元素
+func (e Encoder) Str(tag uint32, vr, value string) []byte {
+	return e.Elem(tag, vr, []byte(value))
+}
+
+// US 编码US元素
+func (e Encoder) US(tag uint32, v uint16) []byte {
+	return e.Elem(tag, "US", binary.LittleEndian.AppendUint16(nil, v))
+}
+
+// UL 编码UL元素
+func (e Encoder) UL(tag uint32, v uint32) []byte {
+	return e.Elem(tag, "UL", binary.LittleEndian.AppendUint32(nil, v))
+}
+
+// Seq 编码序列：显式VR使用确定长度，隐式VR使用未定义长度和结束标记
+func (e Encoder) Seq(tag uint32, items ...[]byte) []byte {
+	var content bytes.Buffer
+	for _, item := range items {
+		binary.Write(&content, binary.LittleEndian, [2]uint16{0xFFFE, 0xE000})
+		if e.Explicit {
+			binary.Write(&content, binary.LittleEndian, uint32(len(item)))
+			content.Write(item)
+		} else {
+			binary.Write(&content, binary.LittleEndian, uint32(undefinedLength))
+			content.Write(item)
+			binary.Write(&content, binary.LittleEndian, [4]uint16{0xFFFE, 0xE00D, 0, 0})
+		}
+	}
+
+	if e.Explicit {
+		return e.Elem(tag, "SQ", content.Bytes())
+	}
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, [2]uint16{uint16(tag >> 16), uint16(tag)})
+	binary.Write(&b, binary.LittleEndian, uint32(undefinedLength))
+	b.Write(content.Bytes())
+	binary.Write(&b, binary.LittleEndian, [4]uint16{0xFFFE, 0xE0DD, 0, 0})
+	return b.Bytes()
+}
+
+// File 生成带前导区和文件元信息的DICOM文件，syntax为数据集的传输语法
+func File(syntax string, dataset ...[]byte) []byte {
+	b := append(make([]byte, 128), "DICM"...)
+	b = append(b, Encoder{Explicit: true}.Str(tagTransferSyntaxUID, "UI", syntax)...)
+	return append(b, bytes.Join(dataset, nil)...)
+}
+
+// Int16Data 按小端编码int16波形样本
+func Int16Data(samples ...int16) []byte {
+	var b []byte
+	for _, s := range samples {
+		b = binary.LittleEndian.AppendUint16(b, uint16(s))
+	}
+	return b
+}
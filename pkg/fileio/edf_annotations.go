@@ -0,0 +1,193 @@
+package fileio
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ljx520ljx/chartSystem/internal/model"
+)
+
+// EDF+/BDF+注释信号的标签
+const (
+	edfAnnotationsLabel = "EDF Annotations"
+	bdfAnnotationsLabel = "BDF Annotations"
+)
+
+// TAL中使用的分隔符
+const (
+	talDurationSep = "\x15" // 开始时间与持续时间之间的分隔符
+	talTextSep     = "\x14" // 注释文本的分隔/结束符
+	talEnd         = 0x00   // TAL结束符
+)
+
+// Annotation 表示EDF+注释信号中的一条事件
+type Annotation struct {
+	Onset    float64 // 相对记录开始的时间（秒）
+	Duration float64 // 持续时间（秒），未指定时为0
+	Text     string  // 注释文本
+}
+
+// tal 表示一个时间戳注释列表（Time-stamped Annotations List）
+type tal struct {
+	onset       float64
+	duration    float64
+	texts       []string
+	timekeeping bool // 第一个文本为空，即记录开始时间的计时注释，其后仍可带有注释文本
+}
+
+// IsAnnotation 判断信号是否为EDF+/BDF+注释信号
+func (sh SignalHeader) IsAnnotation() bool {
+	return sh.Label == edfAnnotationsLabel || sh.Label == bdfAnnotationsLabel
+}
+
+// IsAnnotationSignal 判断指定索引的信号是否为注释信号
+func (r *EDFReader) IsAnnotationSignal(signalIndex int) bool {
+	if signalIndex < 0 || signalIndex >= r.header.NumSignals {
+		return false
+	}
+	return r.header.SignalHeaders[signalIndex].IsAnnotation()
+}
+
+// ReadAnnotations 读取文件中所有注释信号的事件
+func (r *EDFReader) ReadAnnotations() ([]Annotation, error) {
+	annotations := make([]Annotation, 0)
+
+	for i := 0; i < r.header.NumSignals; i++ {
+		if !r.IsAnnotationSignal(i) {
+			continue
+		}
+
+		for rec := 0; rec < r.header.DataRecords; rec++ {
+			raw, err := r.readSignalBytes(i, rec)
+			if err != nil {
+				return nil, err
+			}
+
+			tals, err := parseTALs(raw)
+			if err != nil {
+				return nil, fmt.Errorf("解析第%d个数据记录的注释失败: %w", rec, err)
+			}
+
+			// 计时注释的空文本已在解析时去掉，其余文本仍作为事件读取
+			for _, t := range tals {
+				for _, text := range t.texts {
+					annotations = append(annotations, Annotation{
+						Onset:    t.onset,
+						Duration: t.duration,
+						Text:     text,
+					})
+				}
+			}
+		}
+	}
+
+	return annotations, nil
+}
+
+// 读取某个数据记录中指定信号的原始字节
+func (r *EDFReader) readSignalBytes(signalIndex, record int) ([]byte, error) {
+	offsets, recordBytes := r.recordLayout()
+
+	recordPos := int64(r.header.HeaderBytes) + int64(record)*int64(recordBytes)
+	buf := make([]byte, r.header.SignalHeaders[signalIndex].Samples*r.header.SampleBytes())
+	if err := r.readFullAt(buf, recordPos+int64(offsets[signalIndex])); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// 解析一个数据记录中注释信号的全部TAL
+func parseTALs(raw []byte) ([]tal, error) {
+	tals := make([]tal, 0)
+
+	for start := 0; start < len(raw); {
+		// 跳过TAL之间及记录末尾的填充字节
+		if raw[start] == talEnd {
+			start++
+			continue
+		}
+
+		end := start
+		for end < len(raw) && raw[end] != talEnd {
+			end++
+		}
+
+		t, err := parseTAL(raw[start:end])
+		if err != nil {
+			return nil, err
+		}
+		tals = append(tals, t)
+		start = end + 1
+	}
+
+	return tals, nil
+}
+
+// 解析单个TAL，格式为 +Onset[\x15Duration]\x14[Text\x14]...
+func parseTAL(b []byte) (tal, error) {
+	parts := strings.Split(string(b), talTextSep)
+	if len(parts) < 2 {
+		return tal{}, fmt.Errorf("无效的TAL: %q", string(b))
+	}
+
+	timing := parts[0]
+	if timing == "" || (timing[0] != '+' && timing[0] != '-') {
+		return tal{}, fmt.Errorf("TAL开始时间缺少符号: %q", timing)
+	}
+
+	var t tal
+	onsetStr, durationStr, hasDuration := strings.Cut(timing, talDurationSep)
+
+	onset, err := strconv.ParseFloat(onsetStr, 64)
+	if err != nil {
+		return tal{}, fmt.Errorf("无效的TAL开始时间: %q", onsetStr)
+	}
+	t.onset = onset
+
+	if hasDuration {
+		duration, err := strconv.ParseFloat(durationStr, 64)
+		if err != nil {
+			return tal{}, fmt.Errorf("无效的TAL持续时间: %q", durationStr)
+		}
+		t.duration = duration
+	}
+
+	t.timekeeping = parts[1] == ""
+	for _, text := range parts[1:] {
+		if text != "" {
+			t.texts = append(t.texts, text)
+		}
+	}
+
+	return t, nil
+}
+
+// AnnotationsToMarkers 将注释事件转换为标记点
+func AnnotationsToMarkers(annotations []Annotation, fileID, channelID, createdBy uint) []*model.Marker {
+	markers := make([]*model.Marker, 0, len(annotations))
+
+	for _, a := range annotations {
+		label := a.Text
+		if len([]rune(label)) > 200 {
+			label = string([]rune(label)[:200])
+		}
+
+		description := ""
+		if a.Duration > 0 {
+			description = fmt.Sprintf("持续时间: %g秒", a.Duration)
+		}
+
+		markers = append(markers, &model.Marker{
+			FileID:      fileID,
+			ChannelID:   channelID,
+			Position:    a.Onset,
+			Type:        "annotation",
+			Label:       label,
+			Description: description,
+			CreatedBy:   createdBy,
+		})
+	}
+
+	return markers
+}
@@ -0,0 +1,120 @@
+package fileio
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTAL(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    tal
+		wantErr bool
+	}{
+		{"计时注释", "+0\x14\x14", tal{onset: 0, timekeeping: true}, false},
+		{"带文本的计时注释", "+5\x14\x14Recording starts\x14", tal{onset: 5, texts: []string{"Recording starts"}, timekeeping: true}, false},
+		{"带持续时间", "+1.5\x1530\x14Sleep stage 1\x14", tal{onset: 1.5, duration: 30, texts: []string{"Sleep stage 1"}}, false},
+		{"多条文本", "+12\x14Arousal\x14Apnea\x14", tal{onset: 12, texts: []string{"Arousal", "Apnea"}}, false},
+		{"负开始时间", "-0.25\x14Lights off\x14", tal{onset: -0.25, texts: []string{"Lights off"}}, false},
+		{"UTF-8文本", "+3\x14睡眠分期W\x14", tal{onset: 3, texts: []string{"睡眠分期W"}}, false},
+		{"缺少符号", "12\x14x\x14", tal{}, true},
+		{"开始时间无效", "+1a\x14x\x14", tal{}, true},
+		{"持续时间无效", "+1\x15abc\x14x\x14", tal{}, true},
+		{"缺少文本分隔符", "+1", tal{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTAL([]byte(tt.in))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTAL(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTAL(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTALsSkipsPadding(t *testing.T) {
+	raw := talBytes(64, "+2\x14\x14", "+2.5\x14Event\x14")
+	tals, err := parseTALs(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []tal{{onset: 2, timekeeping: true}, {onset: 2.5, texts: []string{"Event"}}}
+	if !reflect.DeepEqual(tals, want) {
+		t.Errorf("parseTALs = %+v, want %+v", tals, want)
+	}
+}
+
+func TestReadAnnotations(t *testing.T) {
+	path := testEDF{
+		Reserved: "EDF+C",
+		Signals:  []testSignal{int16Signal("ECG", 4), annotationSignal(30)},
+		Records: [][]byte{
+			record(int16Samples(1, 2, 3, 4), talBytes(60, "+0\x14\x14")),
+			record(int16Samples(5, 6, 7, 8), talBytes(60, "+1\x14\x14", "+1.5\x1530\x14Sleep stage 1\x14Arousal\x14")),
+			record(int16Samples(9, 10, 11, 12), talBytes(60, "+2\x14\x14", "+2.75\x14Lights on\x14")),
+		},
+	}.write(t)
+
+	r := openTestEDF(t, path)
+	if r.IsAnnotationSignal(0) || !r.IsAnnotationSignal(1) || r.IsAnnotationSignal(2) {
+		t.Fatal("IsAnnotationSignal返回错误的结果")
+	}
+
+	got, err := r.ReadAnnotations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Annotation{
+		{Onset: 1.5, Duration: 30, Text: "Sleep stage 1"},
+		{Onset: 1.5, Duration: 30, Text: "Arousal"},
+		{Onset: 2.75, Text: "Lights on"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadAnnotations = %+v, want %+v", got, want)
+	}
+
+	// 注释信号不能作为波形加载
+	if err := r.LoadSignalToChannel(1, nil); err == nil {
+		t.Error("加载注释信号应返回错误")
+	}
+}
+
+func TestReadAnnotationsInvalidTAL(t *testing.T) {
+	path := testEDF{
+		Reserved: "EDF+C",
+		Signals:  []testSignal{int16Signal("ECG", 1), annotationSignal(10)},
+		Records:  [][]byte{record(int16Samples(0), talBytes(20, "+0\x14\x14", "oops\x14x\x14"))},
+	}.write(t)
+
+	if _, err := openTestEDF(t, path).ReadAnnotations(); err == nil {
+		t.Error("无效的TAL应返回错误")
+	}
+}
+
+func TestAnnotationsToMarkers(t *testing.T) {
+	long := strings.Repeat("注", 250)
+	markers := AnnotationsToMarkers([]Annotation{
+		{Onset: 1.5, Duration: 30, Text: "Sleep stage 1"},
+		{Onset: 4, Text: long},
+	}, 7, 8, 9)
+
+	if len(markers) != 2 {
+		t.Fatalf("len(markers) = %d, want 2", len(markers))
+	}
+	m := markers[0]
+	if m.FileID != 7 || m.ChannelID != 8 || m.CreatedBy != 9 || m.Position != 1.5 || m.Type != "annotation" || m.Label != "Sleep stage 1" {
+		t.Errorf("markers[0] = %+v", *m)
+	}
+	if m.Description != "持续时间: 30秒" {
+		t.Errorf("Description = %q", m.Description)
+	}
+	if got := []rune(markers[1].Label); len(got) != 200 || markers[1].Description != "" {
+		t.Errorf("长文本应截断为200个字符，得到%d个", len(got))
+	}
+}
@@ -0,0 +1,261 @@
+package fileio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 文件头中可识别身份字段的字节偏移
+const (
+	patientIDOffset   = 8
+	recordingIDOffset = 88
+	startDateOffset   = 168
+	startTimeOffset   = 176
+)
+
+// DeidentifyOptions 表示EDF去标识化选项
+type DeidentifyOptions struct {
+	PatientCode string        // 替换后的病人代码，为空时写入"X"
+	KeepSex     bool          // 是否保留EDF+病人ID中的性别子字段
+	DateShift   time.Duration // 开始日期的偏移量，可为负数；同一病人的多个文件使用相同偏移可保留时间间隔
+	ScrubTerms  []string      // 需要从注释中清除的文本（不区分大小写），EDF+病人姓名会自动加入
+}
+
+// DeidentifyEDF 对EDF/EDF+文件去标识化
+// dst为空或与src相同时原地修改，否则先将src复制到dst再修改副本。
+// 病人ID和记录ID按EDF+子字段规则改写，开始日期按DateShift偏移，注释中的敏感文本替换为等长的"X"。
+func DeidentifyEDF(src, dst string, opts DeidentifyOptions) error {
+	path := src
+	if dst != "" && dst != src {
+		if err := copyFile(src, dst); err != nil {
+			return err
+		}
+		path = dst
+	}
+
+	// 以宽松模式读取文件头，确保格式不规范的文件也能去标识化
+	reader, err := OpenEDFWithOptions(path, EDFOpenOptions{Lenient: true})
+	if err != nil {
+		return err
+	}
+	header := reader.GetHeader()
+	offsets, recordBytes := reader.recordLayout()
+	reader.Close()
+
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	patientID, recordingID, startTime := deidentifyHeader(header, opts)
+
+	fields := []struct {
+		offset int64
+		value  string
+	}{
+		{patientIDOffset, headerString(patientID, 80)},
+		{recordingIDOffset, headerString(recordingID, 80)},
+		{startDateOffset, formatStartDate(startTime)},
+		{startTimeOffset, startTime.Format("15.04.05")},
+	}
+	for _, f := range fields {
+		if _, err := file.WriteAt([]byte(f.value), f.offset); err != nil {
+			return err
+		}
+	}
+
+	// 清除注释中的敏感文本
+	terms := append(append([]string{}, opts.ScrubTerms...), patientNameTerms(header)...)
+	scrubber := newScrubber(terms)
+	if scrubber == nil {
+		return nil
+	}
+
+	sampleBytes := header.SampleBytes()
+	for i, sh := range header.SignalHeaders {
+		if !sh.IsAnnotation() {
+			continue
+		}
+
+		buf := make([]byte, sh.Samples*sampleBytes)
+		for rec := 0; rec < header.DataRecords; rec++ {
+			pos := int64(header.HeaderBytes) + int64(rec)*int64(recordBytes) + int64(offsets[i])
+			if n, err := file.ReadAt(buf, pos); n < len(buf) {
+				return fmt.Errorf("读取第%d个数据记录的注释失败: %w", rec, err)
+			}
+
+			// 只替换注释文本，开始时间、持续时间和分隔符保持不变
+			ranges, err := talTextRanges(buf)
+			if err != nil {
+				return fmt.Errorf("第%d个数据记录的注释无效: %w", rec, err)
+			}
+			changed := false
+			for _, r := range ranges {
+				text := buf[r[0]:r[1]]
+				scrubbed := scrubber.ReplaceAllFunc(text, func(match []byte) []byte {
+					return bytes.Repeat([]byte("X"), len(match))
+				})
+				if !bytes.Equal(scrubbed, text) {
+					copy(text, scrubbed)
+					changed = true
+				}
+			}
+			if !changed {
+				continue
+			}
+			if _, err := file.WriteAt(buf, pos); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// 计算去标识化后的病人ID、记录ID和开始时间
+func deidentifyHeader(header EDFHeader, opts DeidentifyOptions) (string, string, time.Time) {
+	code := opts.PatientCode
+	if code == "" {
+		code = "X"
+	}
+	code = strings.ReplaceAll(code, " ", "_")
+
+	// 无法解析的开始时间按未知日期写出
+	startTime := unknownStartTime
+	if !header.StartTime.IsZero() {
+		startTime = header.StartTime.Add(opts.DateShift)
+	}
+
+	if !header.IsEDFPlus() {
+		// 普通EDF的病人ID和记录ID为自由文本，整体替换
+		return code, "X", startTime
+	}
+
+	// EDF+病人ID子字段: 代码 性别 出生日期 姓名
+	patient := edfPlusSubfields(header.PatientID, 4)
+	sex := "X"
+	if opts.KeepSex {
+		sex = patient[1]
+	}
+	patientID := strings.Join([]string{code, sex, "X", "X"}, " ")
+
+	// EDF+记录ID子字段: Startdate 日期 管理代码 技术人员 设备，只保留设备
+	recording := edfPlusSubfields(strings.TrimPrefix(header.RecordingID, startdatePrefix), 4)
+	recordingID := strings.Join([]string{formatRecordingStartdate(startTime), "X", "X", recording[3]}, " ")
+
+	return patientID, recordingID, startTime
+}
+
+// 按空格拆分EDF+子字段，不足n个时以"X"补齐，多余的附加子字段丢弃
+func edfPlusSubfields(s string, n int) []string {
+	fields := strings.Fields(s)
+	for len(fields) < n {
+		fields = append(fields, "X")
+	}
+	return fields[:n]
+}
+
+// 返回一个数据记录的注释信号中每段注释文本的字节范围[start, end)
+// 注释先由parseTALs校验，范围不包含开始时间、持续时间和分隔符。
+func talTextRanges(raw []byte) ([][2]int, error) {
+	if _, err := parseTALs(raw); err != nil {
+		return nil, err
+	}
+
+	var ranges [][2]int
+	for start := 0; start < len(raw); {
+		if raw[start] == talEnd {
+			start++
+			continue
+		}
+
+		end := start
+		for end < len(raw) && raw[end] != talEnd {
+			end++
+		}
+
+		// 第一个分隔符之前为开始时间和持续时间，之后每个分隔符结束一段文本
+		textStart := start + bytes.IndexByte(raw[start:end], talTextSep[0]) + 1
+		for i := textStart; i < end; i++ {
+			if raw[i] != talTextSep[0] {
+				continue
+			}
+			if i > textStart {
+				ranges = append(ranges, [2]int{textStart, i})
+			}
+			textStart = i + 1
+		}
+		if textStart < end {
+			ranges = append(ranges, [2]int{textStart, end})
+		}
+		start = end + 1
+	}
+
+	return ranges, nil
+}
+
+// 从EDF+病人ID中提取姓名，作为需要清除的文本
+func patientNameTerms(header EDFHeader) []string {
+	if !header.IsEDFPlus() {
+		return nil
+	}
+
+	name := edfPlusSubfields(header.PatientID, 4)[3]
+	if name == "X" {
+		return nil
+	}
+
+	// EDF+姓名中的空格以下划线表示
+	terms := []string{name}
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == ',' }) {
+		if len(part) > 1 {
+			terms = append(terms, part)
+		}
+	}
+	return terms
+}
+
+// 根据敏感文本构造不区分大小写的匹配表达式，没有可清除的文本时返回nil
+func newScrubber(terms []string) *regexp.Regexp {
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		if term != "" {
+			quoted = append(quoted, regexp.QuoteMeta(term))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+
+	// 较长的文本优先匹配，避免姓名只被部分替换
+	sort.Slice(quoted, func(i, j int) bool { return len(quoted[i]) > len(quoted[j]) })
+	return regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+}
+
+// 复制文件
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,216 @@
+package fileio
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// 病人ID和注释中带有姓名的EDF+文件
+func identifiedFile(t *testing.T) string {
+	return testEDF{
+		PatientID:   "MCH-0234567 F 02-MAY-1951 Haagse_Harry",
+		RecordingID: "Startdate 02-MAR-2002 EMG561 BK/JOP Sony",
+		Reserved:    "EDF+C",
+		Signals:     []testSignal{int16Signal("ECG", 2), annotationSignal(30)},
+		Records: [][]byte{
+			record(int16Samples(1, 2), talBytes(60, "+0\x14\x14", "+0.5\x14Harry awake\x14")),
+			record(int16Samples(3, 4), talBytes(60, "+1\x14\x14", "+1.5\x14moved to ROOM 12\x14")),
+		},
+	}.write(t)
+}
+
+func TestDeidentifyEDFPlus(t *testing.T) {
+	src := identifiedFile(t)
+	original, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "anon.edf")
+	err = DeidentifyEDF(src, dst, DeidentifyOptions{
+		PatientCode: "P 001",
+		KeepSex:     true,
+		DateShift:   -48 * time.Hour,
+		ScrubTerms:  []string{"room 12"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 复制模式不修改源文件
+	if after, _ := os.ReadFile(src); !bytes.Equal(after, original) {
+		t.Error("源文件被修改")
+	}
+
+	r := openTestEDF(t, dst)
+	h := r.GetHeader()
+	if h.PatientID != "P_001 F X X" {
+		t.Errorf("PatientID = %q", h.PatientID)
+	}
+	if h.RecordingID != "Startdate 28-FEB-2002 X X Sony" {
+		t.Errorf("RecordingID = %q", h.RecordingID)
+	}
+	if want := time.Date(2002, 2, 28, 10, 11, 12, 0, time.UTC); !h.StartTime.Equal(want) {
+		t.Errorf("StartTime = %v, want %v", h.StartTime, want)
+	}
+
+	// 注释中的姓名和指定文本替换为等长的X，开始时间不变
+	annotations, err := r.ReadAnnotations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Annotation{{Onset: 0.5, Text: "XXXXX awake"}, {Onset: 1.5, Text: "moved to XXXXXXX"}}
+	if !reflect.DeepEqual(annotations, want) {
+		t.Errorf("ReadAnnotations = %+v, want %+v", annotations, want)
+	}
+
+	values, err := r.ReadSignalData(0, 0, 2)
+	if err != nil || !reflect.DeepEqual(values, []int32{1, 2, 3, 4}) {
+		t.Errorf("信号数据 = %v, %v，去标识化不应改变样本", values, err)
+	}
+}
+
+func TestDeidentifyEDFInPlace(t *testing.T) {
+	path := testEDF{
+		PatientID:   "John Smith 1970",
+		RecordingID: "Ward 3 bed 7",
+		StartDate:   "05.06.07",
+		Signals:     []testSignal{int16Signal("ECG", 1)},
+		Records:     [][]byte{int16Samples(7)},
+	}.write(t)
+
+	if err := DeidentifyEDF(path, path, DeidentifyOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 普通EDF的病人ID和记录ID整体替换
+	h := openTestEDF(t, path).GetHeader()
+	if h.PatientID != "X" || h.RecordingID != "X" {
+		t.Errorf("PatientID = %q, RecordingID = %q", h.PatientID, h.RecordingID)
+	}
+	if want := time.Date(2007, 6, 5, 10, 11, 12, 0, time.UTC); !h.StartTime.Equal(want) {
+		t.Errorf("StartTime = %v, want %v", h.StartTime, want)
+	}
+}
+
+func TestDeidentifyNumericTerm(t *testing.T) {
+	// 清除的文本同时出现在开始时间和持续时间中
+	path := testEDF{
+		Reserved: "EDF+C",
+		Duration: "12",
+		Signals:  []testSignal{int16Signal("ECG", 2), annotationSignal(30)},
+		Records: [][]byte{
+			record(int16Samples(1, 2), talBytes(60, "+0\x14\x14", "+1.2\x1512\x14bed 12\x14room 120\x14")),
+			record(int16Samples(3, 4), talBytes(60, "+12\x14\x14", "+12.5\x14\x14", "+120\x14bed 12\x14")),
+		},
+	}.write(t)
+
+	if err := DeidentifyEDF(path, "", DeidentifyOptions{ScrubTerms: []string{"12"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := openTestEDF(t, path)
+	times, err := r.RecordStartTimes()
+	if err != nil || !reflect.DeepEqual(times, []float64{0, 12}) {
+		t.Errorf("RecordStartTimes = %v, %v", times, err)
+	}
+	annotations, err := r.ReadAnnotations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Annotation{
+		{Onset: 1.2, Duration: 12, Text: "bed XX"},
+		{Onset: 1.2, Duration: 12, Text: "room XX0"},
+		{Onset: 120, Text: "bed XX"},
+	}
+	if !reflect.DeepEqual(annotations, want) {
+		t.Errorf("ReadAnnotations = %+v, want %+v", annotations, want)
+	}
+}
+
+func TestTALTextRanges(t *testing.T) {
+	raw := talBytes(40, "+0\x14\x14", "+1\x152\x14ab\x14\x14cd\x14", "-3\x14e")
+	ranges, err := talTextRanges(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, r := range ranges {
+		texts = append(texts, string(raw[r[0]:r[1]]))
+	}
+	if want := []string{"ab", "cd", "e"}; !reflect.DeepEqual(texts, want) {
+		t.Errorf("注释文本 = %q, want %q", texts, want)
+	}
+
+	if _, err := talTextRanges(talBytes(10, "1\x14a\x14")); err == nil {
+		t.Error("无效的TAL应返回错误")
+	}
+}
+
+func TestDeidentifyHeader(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      EDFHeader
+		opts        DeidentifyOptions
+		patientID   string
+		recordingID string
+		startTime   time.Time
+	}{
+		{
+			name:        "不保留性别",
+			header:      EDFHeader{Reserved: "EDF+C", PatientID: "X M X X", RecordingID: "Startdate X X X X", StartTime: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+			opts:        DeidentifyOptions{PatientCode: "A"},
+			patientID:   "A X X X",
+			recordingID: "Startdate 01-JAN-2020 X X X",
+			startTime:   time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "子字段不完整",
+			header:      EDFHeader{Reserved: "BDF+C", PatientID: "123", RecordingID: "", StartTime: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+			opts:        DeidentifyOptions{KeepSex: true, DateShift: 24 * time.Hour},
+			patientID:   "X X X X",
+			recordingID: "Startdate 02-JAN-2020 X X X",
+			startTime:   time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "开始时间未知",
+			header:      EDFHeader{Reserved: "EDF+C", PatientID: "X X X X"},
+			opts:        DeidentifyOptions{DateShift: 24 * time.Hour},
+			patientID:   "X X X X",
+			recordingID: "Startdate X X X X",
+			startTime:   unknownStartTime,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patientID, recordingID, startTime := deidentifyHeader(tt.header, tt.opts)
+			if patientID != tt.patientID || recordingID != tt.recordingID || !startTime.Equal(tt.startTime) {
+				t.Errorf("deidentifyHeader = %q, %q, %v, want %q, %q, %v", patientID, recordingID, startTime, tt.patientID, tt.recordingID, tt.startTime)
+			}
+		})
+	}
+}
+
+func TestPatientNameTerms(t *testing.T) {
+	header := EDFHeader{Reserved: "EDF+C", PatientID: "X X X Smith,_John_A"}
+	if got, want := patientNameTerms(header), []string{"Smith,_John_A", "Smith", "John"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("patientNameTerms = %q, want %q", got, want)
+	}
+	if terms := patientNameTerms(EDFHeader{PatientID: "X X X Smith"}); terms != nil {
+		t.Errorf("普通EDF不应提取姓名: %q", terms)
+	}
+
+	// 较长的文本优先匹配
+	scrubber := newScrubber([]string{"John", " ", "John Smith"})
+	if got := scrubber.ReplaceAllString("john smith and John", "#"); got != "# and #" {
+		t.Errorf("替换结果 = %q", got)
+	}
+	if newScrubber([]string{"", "  "}) != nil {
+		t.Error("没有可清除的文本时应返回nil")
+	}
+}
@@ -0,0 +1,94 @@
+package fileio
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+// 相邻数据记录之间被视为间断的最小时间差（秒）
+const gapTolerance = 1e-6
+
+// IsEDFPlus 判断文件是否为EDF+（或BDF+）格式
+func (h EDFHeader) IsEDFPlus() bool {
+	return strings.HasPrefix(h.Reserved, "EDF+") || strings.HasPrefix(h.Reserved, "BDF+")
+}
+
+// IsDiscontinuous 判断文件是否为不连续记录（EDF+D或BDF+D）
+func (h EDFHeader) IsDiscontinuous() bool {
+	return strings.HasPrefix(h.Reserved, "EDF+D") || strings.HasPrefix(h.Reserved, "BDF+D")
+}
+
+// RecordStartTimes 返回每个数据记录相对文件开始时间的开始时间（秒）
+// EDF+D文件从注释信号的计时注释中读取，其他文件按记录时长连续计算。
+func (r *EDFReader) RecordStartTimes() ([]float64, error) {
+	r.recordTimesOnce.Do(func() {
+		r.recordTimes, r.recordTimesErr = r.readRecordStartTimes()
+	})
+	return r.recordTimes, r.recordTimesErr
+}
+
+// Gaps 返回数据记录之间的间断，连续记录的文件返回空
+func (r *EDFReader) Gaps() ([]data.Gap, error) {
+	recordTimes, err := r.RecordStartTimes()
+	if err != nil {
+		return nil, err
+	}
+
+	var gaps []data.Gap
+	for i := 1; i < len(recordTimes); i++ {
+		end := recordTimes[i-1] + r.header.Duration
+		if recordTimes[i]-end > gapTolerance {
+			gaps = append(gaps, data.Gap{Start: end, End: recordTimes[i]})
+		}
+	}
+	return gaps, nil
+}
+
+// 读取每个数据记录的开始时间
+func (r *EDFReader) readRecordStartTimes() ([]float64, error) {
+	times := make([]float64, r.header.DataRecords)
+
+	if !r.header.IsDiscontinuous() {
+		for i := range times {
+			times[i] = float64(i) * r.header.Duration
+		}
+		return times, nil
+	}
+
+	// 计时注释位于第一个注释信号中
+	annotationSignal := -1
+	for i := 0; i < r.header.NumSignals; i++ {
+		if r.IsAnnotationSignal(i) {
+			annotationSignal = i
+			break
+		}
+	}
+	if annotationSignal < 0 {
+		return nil, fmt.Errorf("不连续记录的文件缺少注释信号")
+	}
+
+	for rec := range times {
+		raw, err := r.readSignalBytes(annotationSignal, rec)
+		if err != nil {
+			return nil, err
+		}
+
+		tals, err := parseTALs(raw)
+		if err != nil {
+			return nil, fmt.Errorf("解析第%d个数据记录的注释失败: %w", rec, err)
+		}
+		// 计时注释是记录中第一个TAL，以空文本标识，可以同时带有其他注释
+		if len(tals) == 0 || !tals[0].timekeeping {
+			return nil, fmt.Errorf("第%d个数据记录缺少计时注释", rec)
+		}
+
+		times[rec] = tals[0].onset
+		if rec > 0 && times[rec] < times[rec-1]+r.header.Duration-gapTolerance {
+			return nil, fmt.Errorf("第%d个数据记录的开始时间%g早于上一个记录结束", rec, times[rec])
+		}
+	}
+
+	return times, nil
+}
@@ -0,0 +1,156 @@
+package fileio
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+// 构造EDF+D文件，每个记录1秒、2个样本，tals[i]为第i个记录的注释信号内容
+func discontinuousFile(t *testing.T, tals ...[]string) string {
+	records := make([][]byte, len(tals))
+	for i, recordTALs := range tals {
+		records[i] = record(int16Samples(2*i, 2*i+1), talBytes(60, recordTALs...))
+	}
+	return testEDF{
+		Reserved: "EDF+D",
+		Signals:  []testSignal{int16Signal("ECG", 2), annotationSignal(30)},
+		Records:  records,
+	}.write(t)
+}
+
+func TestRecordStartTimesDiscontinuous(t *testing.T) {
+	r := openTestEDF(t, discontinuousFile(t,
+		[]string{"+0\x14\x14"},
+		[]string{"+1\x14\x14"},
+		[]string{"+4.5\x14\x14", "+4.75\x14Electrode off\x14"},
+	))
+
+	times, err := r.RecordStartTimes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{0, 1, 4.5}; !reflect.DeepEqual(times, want) {
+		t.Errorf("RecordStartTimes = %v, want %v", times, want)
+	}
+
+	gaps, err := r.Gaps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []data.Gap{{Start: 2, End: 4.5}}; !reflect.DeepEqual(gaps, want) {
+		t.Errorf("Gaps = %v, want %v", gaps, want)
+	}
+
+	// 样本时间跟随记录开始时间，间断后不连续
+	channel := data.NewChannel("0", "ECG")
+	if err := r.LoadSignalToChannel(0, channel); err != nil {
+		t.Fatal(err)
+	}
+	wantX := []float64{0, 0.5, 1, 1.5, 4.5, 5}
+	for i, p := range channel.Data {
+		if math.Abs(p.X-wantX[i]) > 1e-9 || math.Abs(p.Y-float64(i)/10) > 1e-9 {
+			t.Errorf("样本%d = (%g, %g), want (%g, %g)", i, p.X, p.Y, wantX[i], float64(i)/10)
+		}
+	}
+	if !reflect.DeepEqual(channel.Gaps, gaps) {
+		t.Errorf("通道间断 = %v, want %v", channel.Gaps, gaps)
+	}
+
+	// 窗口跨越间断时只返回实际存在的样本
+	points, err := r.ReadWindowPoints(0, 1.5, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 2 || points[0].X != 1.5 || points[1].X != 4.5 {
+		t.Errorf("ReadWindowPoints = %v", points)
+	}
+}
+
+func TestRecordStartTimesTimekeepingWithText(t *testing.T) {
+	// 计时注释以空文本标识，其后可以直接带有注释
+	r := openTestEDF(t, discontinuousFile(t,
+		[]string{"+10\x14\x14Recording starts\x14"},
+		[]string{"+20\x14\x14", "+20.5\x1510\x14Apnea\x14"},
+	))
+
+	times, err := r.RecordStartTimes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{10, 20}; !reflect.DeepEqual(times, want) {
+		t.Errorf("RecordStartTimes = %v, want %v", times, want)
+	}
+
+	annotations, err := r.ReadAnnotations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Annotation{
+		{Onset: 10, Text: "Recording starts"},
+		{Onset: 20.5, Duration: 10, Text: "Apnea"},
+	}
+	if !reflect.DeepEqual(annotations, want) {
+		t.Errorf("ReadAnnotations = %+v, want %+v", annotations, want)
+	}
+}
+
+func TestRecordStartTimesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		tals [][]string
+	}{
+		{"缺少计时注释", [][]string{{"+0\x14\x14"}, {"+1\x14Event\x14"}}},
+		{"记录为空", [][]string{{"+0\x14\x14"}, {}}},
+		{"记录重叠", [][]string{{"+0\x14\x14"}, {"+0.5\x14\x14"}}},
+		{"无效的TAL", [][]string{{"0\x14\x14"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := openTestEDF(t, discontinuousFile(t, tt.tals...))
+			if _, err := r.RecordStartTimes(); err == nil {
+				t.Error("RecordStartTimes应返回错误")
+			}
+			if err := r.LoadSignalToChannel(0, data.NewChannel("0", "ECG")); err == nil {
+				t.Error("LoadSignalToChannel应返回错误")
+			}
+		})
+	}
+}
+
+func TestRecordStartTimesContinuous(t *testing.T) {
+	// EDF+C文件按记录时长计算，不读取计时注释
+	path := testEDF{
+		Reserved: "EDF+C",
+		Duration: "2.5",
+		Signals:  []testSignal{int16Signal("ECG", 1), annotationSignal(10)},
+		Records: [][]byte{
+			record(int16Samples(0), talBytes(20, "+0\x14\x14")),
+			record(int16Samples(0), talBytes(20, "+7\x14\x14")),
+			record(int16Samples(0), talBytes(20, "+9\x14\x14")),
+		},
+	}.write(t)
+	r := openTestEDF(t, path)
+
+	times, err := r.RecordStartTimes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{0, 2.5, 5}; !reflect.DeepEqual(times, want) {
+		t.Errorf("RecordStartTimes = %v, want %v", times, want)
+	}
+	if gaps, err := r.Gaps(); err != nil || len(gaps) != 0 {
+		t.Errorf("Gaps = %v, %v，连续文件不应有间断", gaps, err)
+	}
+
+	for _, reserved := range []string{"EDF+D", "BDF+D"} {
+		if !(EDFHeader{Reserved: reserved}).IsDiscontinuous() {
+			t.Errorf("%s应为不连续记录", reserved)
+		}
+	}
+	if h := (EDFHeader{Reserved: "EDF+C"}); h.IsDiscontinuous() || !h.IsEDFPlus() {
+		t.Error("EDF+C应为连续的EDF+文件")
+	}
+}
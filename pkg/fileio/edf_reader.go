@@ -7,11 +7,16 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/liujiaxin/chartSystem/internal/data"
+	"github.com/ljx520ljx/chartSystem/internal/data"
 )
 
+// BDF文件版本字段（首字节为0xFF）
+const bdfVersion = "\xffBIOSEMI"
+
 // EDFHeader 表示EDF文件头
 type EDFHeader struct {
 	Version       string    // 8字节
@@ -28,26 +33,73 @@ type EDFHeader struct {
 
 // SignalHeader 表示信号头
 type SignalHeader struct {
-	Label       string  // 16字节
-	Transducer  string  // 80字节
-	PhysicalDim string  // 8字节
-	PhysicalMin float64 // 8字节
-	PhysicalMax float64 // 8字节
-	DigitalMin  float64 // 8字节
-	DigitalMax  float64 // 8字节
+	Label        string  // 16字节
+	Transducer   string  // 80字节
+	PhysicalDim  string  // 8字节
+	PhysicalMin  float64 // 8字节
+	PhysicalMax  float64 // 8字节
+	DigitalMin   float64 // 8字节
+	DigitalMax   float64 // 8字节
 	Prefiltering string  // 80字节
-	Samples     int     // 8字节
-	Reserved    string  // 32字节
+	Samples      int     // 8字节
+	Reserved     string  // 32字节
+}
+
+// IsBDF 判断文件是否为BioSemi的24位BDF格式
+func (h EDFHeader) IsBDF() bool {
+	return h.Version == bdfVersion
+}
+
+// SampleBytes 返回每个样本占用的字节数，EDF为2字节，BDF为3字节
+func (h EDFHeader) SampleBytes() int {
+	if h.IsBDF() {
+		return 3
+	}
+	return 2
+}
+
+// 将小端字节批量解码为有符号样本值，sampleBytes为2（EDF）或3（BDF）
+func decodeSamples(dst []int32, raw []byte, sampleBytes int) {
+	if sampleBytes == 3 {
+		for j := range dst {
+			b := raw[j*3 : j*3+3]
+			// 符号扩展24位补码
+			dst[j] = (int32(b[0]) | int32(b[1])<<8 | int32(b[2])<<16) << 8 >> 8
+		}
+		return
+	}
+
+	for j := range dst {
+		dst[j] = int32(int16(binary.LittleEndian.Uint16(raw[j*2:])))
+	}
 }
 
-// EDFReader 表示EDF文件读取器
+// EDFReader 表示EDF文件读取器（同时支持BDF）
 type EDFReader struct {
-	file   *os.File
-	header EDFHeader
+	file         *os.File
+	header       EDFHeader
+	cache        atomic.Pointer[recordCache] // 可选的已解码数据记录缓存，可在读取过程中替换
+	warnings     []ValidationIssue           // 文件头校验产生的警告
+	startTimeErr error                       // 开始日期和时间的解析错误
+
+	// 每个数据记录的开始时间，首次使用时计算
+	recordTimesOnce sync.Once
+	recordTimes     []float64
+	recordTimesErr  error
+}
+
+// EDFOpenOptions 表示打开EDF文件时的选项
+type EDFOpenOptions struct {
+	Lenient bool // 宽松模式：修复可修复的文件头问题，并以警告形式记录
 }
 
-// OpenEDF 打开一个EDF文件
+// OpenEDF 以严格模式打开一个EDF文件，文件头不合法时返回*ValidationError
 func OpenEDF(path string) (*EDFReader, error) {
+	return OpenEDFWithOptions(path, EDFOpenOptions{})
+}
+
+// OpenEDFWithOptions 按选项打开一个EDF文件
+func OpenEDFWithOptions(path string, opts EDFOpenOptions) (*EDFReader, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
@@ -62,6 +114,17 @@ func OpenEDF(path string) (*EDFReader, error) {
 		return nil, err
 	}
 
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if err := reader.validate(info.Size(), opts.Lenient); err != nil {
+		file.Close()
+		return nil, err
+	}
+
 	return reader, nil
 }
 
@@ -117,68 +180,72 @@ func (r *EDFReader) readHeader() error {
 	// 读取版本
 	r.header.Version, err = readString(r.file, 8)
 	if err != nil {
-		return err
+		return headerFieldError("Version", -1, err)
 	}
 
 	// 读取病人ID
 	r.header.PatientID, err = readString(r.file, 80)
 	if err != nil {
-		return err
+		return headerFieldError("PatientID", -1, err)
 	}
 
 	// 读取记录ID
 	r.header.RecordingID, err = readString(r.file, 80)
 	if err != nil {
-		return err
+		return headerFieldError("RecordingID", -1, err)
 	}
 
 	// 读取开始日期
 	startDate, err := readString(r.file, 8)
 	if err != nil {
-		return err
+		return headerFieldError("StartDate", -1, err)
 	}
 
 	// 读取开始时间
 	startTime, err := readString(r.file, 8)
 	if err != nil {
-		return err
-	}
-
-	// 解析日期和时间
-	r.header.StartTime, err = time.Parse("02.01.06 15.04.05", startDate+" "+startTime)
-	if err != nil {
-		// 如果解析失败，使用当前时间
-		r.header.StartTime = time.Now()
+		return headerFieldError("StartTime", -1, err)
 	}
 
 	// 读取头部大小
 	r.header.HeaderBytes, err = readInt(r.file, 8)
 	if err != nil {
-		return err
+		return headerFieldError("HeaderBytes", -1, err)
 	}
 
 	// 读取保留字段
 	r.header.Reserved, err = readString(r.file, 44)
 	if err != nil {
-		return err
+		return headerFieldError("Reserved", -1, err)
+	}
+
+	// 解析日期和时间（EDF+的四位年份位于记录ID中，需在读取保留字段后解析）
+	r.header.StartTime, err = parseStartTime(startDate, startTime, r.header.RecordingID, r.header.IsEDFPlus())
+	if err != nil {
+		// 解析失败时保留零值，由文件头校验决定拒绝还是给出警告
+		r.header.StartTime = time.Time{}
+		r.startTimeErr = err
 	}
 
 	// 读取数据记录数
 	r.header.DataRecords, err = readInt(r.file, 8)
 	if err != nil {
-		return err
+		return headerFieldError("DataRecords", -1, err)
 	}
 
 	// 读取每个数据记录的持续时间
 	r.header.Duration, err = readFloat(r.file, 8)
 	if err != nil {
-		return err
+		return headerFieldError("Duration", -1, err)
 	}
 
 	// 读取信号数量
 	r.header.NumSignals, err = readInt(r.file, 4)
 	if err != nil {
-		return err
+		return headerFieldError("NumSignals", -1, err)
+	}
+	if r.header.NumSignals <= 0 {
+		return headerIssueError("NumSignals", -1, fmt.Sprintf("信号数量必须大于0: %d", r.header.NumSignals))
 	}
 
 	// 读取信号头
@@ -188,7 +255,7 @@ func (r *EDFReader) readHeader() error {
 	for i := 0; i < r.header.NumSignals; i++ {
 		label, err := readString(r.file, 16)
 		if err != nil {
-			return err
+			return headerFieldError("Label", i, err)
 		}
 		r.header.SignalHeaders[i].Label = label
 	}
@@ -196,7 +263,7 @@ func (r *EDFReader) readHeader() error {
 	for i := 0; i < r.header.NumSignals; i++ {
 		transducer, err := readString(r.file, 80)
 		if err != nil {
-			return err
+			return headerFieldError("Transducer", i, err)
 		}
 		r.header.SignalHeaders[i].Transducer = transducer
 	}
@@ -204,7 +271,7 @@ func (r *EDFReader) readHeader() error {
 	for i := 0; i < r.header.NumSignals; i++ {
 		physicalDim, err := readString(r.file, 8)
 		if err != nil {
-			return err
+			return headerFieldError("PhysicalDim", i, err)
 		}
 		r.header.SignalHeaders[i].PhysicalDim = physicalDim
 	}
@@ -212,7 +279,7 @@ func (r *EDFReader) readHeader() error {
 	for i := 0; i < r.header.NumSignals; i++ {
 		physicalMin, err := readFloat(r.file, 8)
 		if err != nil {
-			return err
+			return headerFieldError("PhysicalMin", i, err)
 		}
 		r.header.SignalHeaders[i].PhysicalMin = physicalMin
 	}
@@ -220,7 +287,7 @@ func (r *EDFReader) readHeader() error {
 	for i := 0; i < r.header.NumSignals; i++ {
 		physicalMax, err := readFloat(r.file, 8)
 		if err != nil {
-			return err
+			return headerFieldError("PhysicalMax", i, err)
 		}
 		r.header.SignalHeaders[i].PhysicalMax = physicalMax
 	}
@@ -228,7 +295,7 @@ func (r *EDFReader) readHeader() error {
 	for i := 0; i < r.header.NumSignals; i++ {
 		digitalMin, err := readFloat(r.file, 8)
 		if err != nil {
-			return err
+			return headerFieldError("DigitalMin", i, err)
 		}
 		r.header.SignalHeaders[i].DigitalMin = digitalMin
 	}
@@ -236,7 +303,7 @@ func (r *EDFReader) readHeader() error {
 	for i := 0; i < r.header.NumSignals; i++ {
 		digitalMax, err := readFloat(r.file, 8)
 		if err != nil {
-			return err
+			return headerFieldError("DigitalMax", i, err)
 		}
 		r.header.SignalHeaders[i].DigitalMax = digitalMax
 	}
@@ -244,7 +311,7 @@ func (r *EDFReader) readHeader() error {
 	for i := 0; i < r.header.NumSignals; i++ {
 		prefiltering, err := readString(r.file, 80)
 		if err != nil {
-			return err
+			return headerFieldError("Prefiltering", i, err)
 		}
 		r.header.SignalHeaders[i].Prefiltering = prefiltering
 	}
@@ -252,7 +319,7 @@ func (r *EDFReader) readHeader() error {
 	for i := 0; i < r.header.NumSignals; i++ {
 		samples, err := readInt(r.file, 8)
 		if err != nil {
-			return err
+			return headerFieldError("Samples", i, err)
 		}
 		r.header.SignalHeaders[i].Samples = samples
 	}
@@ -260,7 +327,7 @@ func (r *EDFReader) readHeader() error {
 	for i := 0; i < r.header.NumSignals; i++ {
 		reserved, err := readString(r.file, 32)
 		if err != nil {
-			return err
+			return headerFieldError("Reserved", i, err)
 		}
 		r.header.SignalHeaders[i].Reserved = reserved
 	}
@@ -268,10 +335,25 @@ func (r *EDFReader) readHeader() error {
 	return nil
 }
 
-// ReadSignalData 读取信号数据
-func (r *EDFReader) ReadSignalData(signalIndex int, startRecord, numRecords int) ([]int16, error) {
-	if signalIndex < 0 || signalIndex >= r.header.NumSignals {
-		return nil, fmt.Errorf("信号索引超出范围: %d", signalIndex)
+// 批量读取时每次读取的最大字节数
+const bulkReadBytes = 4 << 20
+
+// ReadSignalData 读取信号数据，EDF的16位样本和BDF的24位样本均以int32返回
+func (r *EDFReader) ReadSignalData(signalIndex int, startRecord, numRecords int) ([]int32, error) {
+	result, err := r.ReadSignals([]int{signalIndex}, startRecord, numRecords)
+	if err != nil {
+		return nil, err
+	}
+	return result[0], nil
+}
+
+// ReadSignals 一次遍历读取多个信号的数据
+// 每次读取若干个完整的数据记录并在同一遍中拆分出所有请求的信号，结果与signalIndices一一对应。
+func (r *EDFReader) ReadSignals(signalIndices []int, startRecord, numRecords int) ([][]int32, error) {
+	for _, signalIndex := range signalIndices {
+		if signalIndex < 0 || signalIndex >= r.header.NumSignals {
+			return nil, fmt.Errorf("信号索引超出范围: %d", signalIndex)
+		}
 	}
 
 	if startRecord < 0 || startRecord >= r.header.DataRecords {
@@ -287,94 +369,141 @@ func (r *EDFReader) ReadSignalData(signalIndex int, startRecord, numRecords int)
 		numRecords = r.header.DataRecords - startRecord
 	}
 
-	// 计算一个数据记录中所有信号的样本总数
-	totalSamplesPerRecord := 0
-	for i := 0; i < r.header.NumSignals; i++ {
-		totalSamplesPerRecord += r.header.SignalHeaders[i].Samples
-	}
+	sampleBytes := r.header.SampleBytes()
+	offsets, recordBytes := r.recordLayout()
 
-	// 计算数据记录大小（字节数）
-	recordSize := totalSamplesPerRecord * 2 // 每个样本2字节
+	// 分配结果数组
+	result := make([][]int32, len(signalIndices))
+	for k, signalIndex := range signalIndices {
+		result[k] = make([]int32, r.header.SignalHeaders[signalIndex].Samples*numRecords)
+	}
 
-	// 计算目标信号在记录中的偏移
-	signalOffset := 0
-	for i := 0; i < signalIndex; i++ {
-		signalOffset += r.header.SignalHeaders[i].Samples * 2
+	// 每次读取的记录数
+	chunkRecords := bulkReadBytes / recordBytes
+	if chunkRecords < 1 {
+		chunkRecords = 1
+	}
+	if chunkRecords > numRecords {
+		chunkRecords = numRecords
 	}
+	buf := make([]byte, chunkRecords*recordBytes)
 
-	// 每个记录中当前信号的样本数
-	samplesPerSignal := r.header.SignalHeaders[signalIndex].Samples
+	for done := 0; done < numRecords; {
+		n := chunkRecords
+		if done+n > numRecords {
+			n = numRecords - done
+		}
 
-	// 分配结果数组
-	result := make([]int16, samplesPerSignal*numRecords)
-
-	// 读取每个记录中的信号数据
-	for i := 0; i < numRecords; i++ {
-		// 计算记录在文件中的位置
-		recordPos := int64(r.header.HeaderBytes) + int64(startRecord+i)*int64(recordSize)
-		
-		// 跳转到记录中当前信号的起始位置
-		_, err := r.file.Seek(recordPos+int64(signalOffset), 0)
-		if err != nil {
+		// 一次读取n个完整的数据记录
+		chunk := buf[:n*recordBytes]
+		recordPos := int64(r.header.HeaderBytes) + int64(startRecord+done)*int64(recordBytes)
+		if err := r.readFullAt(chunk, recordPos); err != nil {
 			return nil, err
 		}
 
-		// 读取当前信号的所有样本
-		for j := 0; j < samplesPerSignal; j++ {
-			var sample int16
-			err := binary.Read(r.file, binary.LittleEndian, &sample)
-			if err != nil {
-				return nil, err
+		// 在同一遍中拆分出每个请求的信号
+		for i := 0; i < n; i++ {
+			record := chunk[i*recordBytes : (i+1)*recordBytes]
+			for k, signalIndex := range signalIndices {
+				samples := r.header.SignalHeaders[signalIndex].Samples
+				dst := result[k][(done+i)*samples : (done+i+1)*samples]
+				decodeSamples(dst, record[offsets[signalIndex]:], sampleBytes)
 			}
-			result[i*samplesPerSignal+j] = sample
 		}
+
+		done += n
 	}
 
 	return result, nil
 }
 
 // ConvertToPhysical 将数字值转换为物理值
-func (r *EDFReader) ConvertToPhysical(signalIndex int, digitalValue int16) float64 {
+func (r *EDFReader) ConvertToPhysical(signalIndex int, digitalValue int32) float64 {
 	if signalIndex < 0 || signalIndex >= r.header.NumSignals {
 		return 0
 	}
 
 	sh := r.header.SignalHeaders[signalIndex]
-	
+
 	// 计算转换因子
 	scale := (sh.PhysicalMax - sh.PhysicalMin) / (sh.DigitalMax - sh.DigitalMin)
-	
+
 	// 转换数值
-	physicalValue := sh.PhysicalMin + float64(digitalValue-int16(sh.DigitalMin))*scale
-	
+	physicalValue := sh.PhysicalMin + (float64(digitalValue)-sh.DigitalMin)*scale
+
 	return physicalValue
 }
 
+// ReadAllPoints 一次遍历读取多个数据信号全部记录的数据点，结果与signalIndices一一对应
+// X为样本的实际时间，EDF+D文件中记录之间的间断被保留。
+func (r *EDFReader) ReadAllPoints(signalIndices []int) ([][]data.DataPoint, error) {
+	for _, signalIndex := range signalIndices {
+		if signalIndex < 0 || signalIndex >= r.header.NumSignals {
+			return nil, fmt.Errorf("信号索引超出范围: %d", signalIndex)
+		}
+		// 注释信号不包含波形数据
+		if r.IsAnnotationSignal(signalIndex) {
+			return nil, fmt.Errorf("信号%d是注释信号，不能作为波形加载", signalIndex)
+		}
+	}
+
+	result := make([][]data.DataPoint, len(signalIndices))
+	if r.header.DataRecords == 0 {
+		for k := range result {
+			result[k] = []data.DataPoint{}
+		}
+		return result, nil
+	}
+
+	// 读取所有数据记录
+	digitalData, err := r.ReadSignals(signalIndices, 0, r.header.DataRecords)
+	if err != nil {
+		return nil, err
+	}
+
+	// 每个数据记录的开始时间（EDF+D文件中记录之间可能存在间断）
+	recordTimes, err := r.RecordStartTimes()
+	if err != nil {
+		return nil, err
+	}
+
+	for k, signalIndex := range signalIndices {
+		// 计算每个样本的时间间隔和转换因子
+		sh := r.header.SignalHeaders[signalIndex]
+		timeStep := r.header.Duration / float64(sh.Samples)
+		scale := (sh.PhysicalMax - sh.PhysicalMin) / (sh.DigitalMax - sh.DigitalMin)
+
+		// 将数字值转换为物理值
+		points := make([]data.DataPoint, len(digitalData[k]))
+		for i, digitalValue := range digitalData[k] {
+			points[i] = data.DataPoint{
+				X: recordTimes[i/sh.Samples] + float64(i%sh.Samples)*timeStep,
+				Y: sh.PhysicalMin + (float64(digitalValue)-sh.DigitalMin)*scale,
+			}
+		}
+		result[k] = points
+	}
+
+	return result, nil
+}
+
 // LoadSignalToChannel 将信号数据加载到通道
 func (r *EDFReader) LoadSignalToChannel(signalIndex int, channel *data.Channel) error {
-	// 读取所有数据记录
-	digitalData, err := r.ReadSignalData(signalIndex, 0, r.header.DataRecords)
+	points, err := r.ReadAllPoints([]int{signalIndex})
+	if err != nil {
+		return err
+	}
+	gaps, err := r.Gaps()
 	if err != nil {
 		return err
 	}
 
 	// 清除通道中现有数据
 	channel.ClearData()
-
-	// 计算每个样本的时间间隔
-	timeStep := r.header.Duration / float64(r.header.SignalHeaders[signalIndex].Samples)
-
-	// 将数字值转换为物理值并添加到通道
-	for i, digitalValue := range digitalData {
-		// 计算时间
-		t := float64(i) * timeStep
-		
-		// 转换为物理值
-		y := r.ConvertToPhysical(signalIndex, digitalValue)
-		
-		// 添加到通道
-		channel.AddDataPoint(t, y)
-	}
+	channel.Data = points[0]
+	channel.Gaps = gaps
+	channel.StartTime = r.header.StartTime
+	channel.SampleRate = r.GetSignalSamplingRate(signalIndex)
 
 	return nil
 }
@@ -404,7 +533,7 @@ func (r *EDFReader) GetSignalSamplingRate(signalIndex int) float64 {
 	if signalIndex < 0 || signalIndex >= r.header.NumSignals {
 		return 0
 	}
-	
+
 	return float64(r.header.SignalHeaders[signalIndex].Samples) / r.header.Duration
 }
 
@@ -412,27 +541,28 @@ func (r *EDFReader) GetSignalSamplingRate(signalIndex int) float64 {
 func CreateSimulatedEDFData(channel *data.Channel, dataType string, duration float64, samplingRate float64) {
 	// 清除通道中现有数据
 	channel.ClearData()
-	
+	channel.SampleRate = samplingRate
+
 	// 计算总样本数
 	totalSamples := int(duration * samplingRate)
-	
+
 	// 时间步长
 	timeStep := 1.0 / samplingRate
-	
+
 	// 生成数据
 	for i := 0; i < totalSamples; i++ {
 		t := float64(i) * timeStep
 		var y float64
-		
+
 		switch dataType {
 		case "sine":
 			// 生成正弦波
 			y = math.Sin(2 * math.Pi * t)
 		case "ecg":
 			// 模拟心电图数据
-			period := 1.0 // 心跳周期（秒）
+			period := 1.0                            // 心跳周期（秒）
 			phase := t - math.Floor(t/period)*period // 0到period之间的相位
-			
+
 			if phase < 0.1 {
 				// P波
 				y = 0.25 * math.Sin(2*math.Pi*phase/0.2)
@@ -444,14 +574,14 @@ func CreateSimulatedEDFData(channel *data.Channel, dataType string, duration flo
 				y = -0.5 * (phase - 0.4) / 0.05
 			} else if phase >= 0.45 && phase < 0.5 {
 				// R波
-				y = -0.5 + 2 * (phase - 0.45) / 0.05
+				y = -0.5 + 2*(phase-0.45)/0.05
 			} else if phase >= 0.5 && phase < 0.55 {
 				// S波
-				y = 1.5 - 2 * (phase - 0.5) / 0.05
+				y = 1.5 - 2*(phase-0.5)/0.05
 			} else if phase >= 0.55 && phase < 0.7 {
 				// T波
 				peak := (phase - 0.55) / 0.15
-				y = -0.5 + 0.75 * math.Sin(math.Pi * peak)
+				y = -0.5 + 0.75*math.Sin(math.Pi*peak)
 			} else {
 				// 平坦段
 				y = 0
@@ -460,14 +590,14 @@ func CreateSimulatedEDFData(channel *data.Channel, dataType string, duration flo
 			// 模拟血压数据
 			period := 1.0 // 心跳周期（秒）
 			phase := t - math.Floor(t/period)*period
-			
+
 			// 收缩压和舒张压的波形
 			if phase < 0.3 {
 				// 快速上升（收缩）
-				y = 80 + 40 * math.Sin(math.Pi/2 + math.Pi*phase/0.3)
+				y = 80 + 40*math.Sin(math.Pi/2+math.Pi*phase/0.3)
 			} else {
 				// 缓慢下降（舒张）
-				y = 80 + 40 * math.Sin(math.Pi/2 + math.Pi*0.3/0.3) * math.Exp(-(phase-0.3)/0.5)
+				y = 80 + 40*math.Sin(math.Pi/2+math.Pi*0.3/0.3)*math.Exp(-(phase-0.3)/0.5)
 			}
 		case "resp":
 			// 模拟呼吸数据
@@ -475,15 +605,15 @@ func CreateSimulatedEDFData(channel *data.Channel, dataType string, duration flo
 			y = math.Sin(2 * math.Pi * t / period)
 		case "spo2":
 			// 模拟血氧数据
-			y = 98 + math.Sin(2*math.Pi*t) * 1 // 98% 左右波动
+			y = 98 + math.Sin(2*math.Pi*t)*1 // 98% 左右波动
 		default:
 			// 默认为噪声
 			y = (rand() - 0.5) * 2
 		}
-		
+
 		// 添加一些随机噪声
 		y += (rand() - 0.5) * 0.1
-		
+
 		// 添加到通道
 		channel.AddDataPoint(t, y)
 	}
@@ -0,0 +1,253 @@
+package fileio
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+func TestDecodeSamples(t *testing.T) {
+	got16 := make([]int32, 4)
+	decodeSamples(got16, int16Samples(0, 1, -1, -32768), 2)
+	if want := []int32{0, 1, -1, -32768}; !reflect.DeepEqual(got16, want) {
+		t.Errorf("16位样本 = %v, want %v", got16, want)
+	}
+
+	got24 := make([]int32, 5)
+	decodeSamples(got24, int24Samples(0, 8388607, -8388608, -1, 0x123456), 3)
+	if want := []int32{0, 8388607, -8388608, -1, 0x123456}; !reflect.DeepEqual(got24, want) {
+		t.Errorf("24位样本 = %v, want %v", got24, want)
+	}
+}
+
+func TestReadBDF(t *testing.T) {
+	ecg := testSignal{Label: "ECG", Dim: "uV", PhysMin: -8388608, PhysMax: 8388607, DigMin: -8388608, DigMax: 8388607, Samples: 4}
+	annotations := testSignal{Label: bdfAnnotationsLabel, PhysMin: -1, PhysMax: 1, DigMin: -8388608, DigMax: 8388607, Samples: 10}
+	path := testEDF{
+		Version:  bdfVersion,
+		Reserved: "BDF+C",
+		Duration: "2",
+		Signals:  []testSignal{ecg, annotations},
+		Records: [][]byte{
+			record(int24Samples(-8388608, -1, 0, 1), talBytes(30, "+0\x14\x14")),
+			record(int24Samples(8388607, 32, -32, 100000), talBytes(30, "+2\x14\x14", "+3\x14R\x14")),
+		},
+	}.write(t)
+
+	r := openTestEDF(t, path)
+	h := r.GetHeader()
+	if !h.IsBDF() || h.SampleBytes() != 3 {
+		t.Fatalf("IsBDF = %v, SampleBytes = %d", h.IsBDF(), h.SampleBytes())
+	}
+	if !r.IsAnnotationSignal(1) {
+		t.Error("BDF Annotations应识别为注释信号")
+	}
+	if rate := r.GetSignalSamplingRate(0); rate != 2 {
+		t.Errorf("GetSignalSamplingRate = %g, want 2", rate)
+	}
+
+	digital, err := r.ReadSignalData(0, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int32{-8388608, -1, 0, 1, 8388607, 32, -32, 100000}; !reflect.DeepEqual(digital, want) {
+		t.Errorf("ReadSignalData = %v, want %v", digital, want)
+	}
+
+	// 物理值与数字值相同
+	channel := data.NewChannel("0", "ECG")
+	if err := r.LoadSignalToChannel(0, channel); err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range channel.Data {
+		if want := float64(digital[i]); math.Abs(p.Y-want) > 1e-6 {
+			t.Errorf("样本%d的物理值 = %g, want %g", i, p.Y, want)
+		}
+		if want := float64(i/4)*2 + float64(i%4)*0.5; math.Abs(p.X-want) > 1e-9 {
+			t.Errorf("样本%d的时间 = %g, want %g", i, p.X, want)
+		}
+	}
+
+	a, err := r.ReadAnnotations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Annotation{{Onset: 3, Text: "R"}}; !reflect.DeepEqual(a, want) {
+		t.Errorf("ReadAnnotations = %+v, want %+v", a, want)
+	}
+}
+
+func TestBDFDigitalRangeLimit(t *testing.T) {
+	// 24位的数字范围不能用于16位EDF
+	wide := testSignal{Label: "ECG", PhysMin: -1, PhysMax: 1, DigMin: -8388608, DigMax: 8388607, Samples: 1}
+	path := testEDF{Signals: []testSignal{wide}, Records: [][]byte{int16Samples(0)}}.write(t)
+	if _, err := OpenEDF(path); err == nil {
+		t.Error("EDF文件使用24位数字范围应校验失败")
+	}
+
+	path = testEDF{Version: bdfVersion, Signals: []testSignal{wide}, Records: [][]byte{int24Samples(0)}}.write(t)
+	openTestEDF(t, path)
+}
+
+// 生成numSignals个信号、每个记录samples个样本的EDF文件，信号k第i个样本的数字值为(k*1000+i)%65536-32768
+func multiSignalFile(t testing.TB, numSignals, samples, records int) string {
+	signals := make([]testSignal, numSignals)
+	for k := range signals {
+		signals[k] = int16Signal(fmt.Sprintf("S%d", k), samples+k)
+	}
+
+	raw := make([][]byte, records)
+	for rec := range raw {
+		var b []byte
+		for k, s := range signals {
+			values := make([]int, s.Samples)
+			for j := range values {
+				values[j] = multiSignalValue(k, rec*s.Samples+j)
+			}
+			b = append(b, int16Samples(values...)...)
+		}
+		raw[rec] = b
+	}
+	return testEDF{Signals: signals, Records: raw}.write(t)
+}
+
+// multiSignalFile中信号k第i个样本的数字值
+func multiSignalValue(k, i int) int {
+	return (k*1000+i)%65536 - 32768
+}
+
+func TestReadSignals(t *testing.T) {
+	r := openTestEDF(t, multiSignalFile(t, 4, 50, 20))
+
+	// 请求的信号可以乱序和重复，记录数超出末尾时截断
+	indices := []int{3, 0, 3, 1}
+	result, err := r.ReadSignals(indices, 5, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n, k := range indices {
+		samples := 50 + k
+		if len(result[n]) != 15*samples {
+			t.Fatalf("信号%d读取了%d个样本, want %d", k, len(result[n]), 15*samples)
+		}
+		for j, v := range result[n] {
+			if want := multiSignalValue(k, 5*samples+j); int(v) != want {
+				t.Fatalf("信号%d样本%d = %d, want %d", k, j, v, want)
+			}
+		}
+	}
+
+	single, err := r.ReadSignalData(2, 19, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(single) != 52 || int(single[0]) != multiSignalValue(2, 19*52) {
+		t.Errorf("ReadSignalData返回%d个样本，第一个为%d", len(single), single[0])
+	}
+
+	for _, args := range [][3]int{{4, 0, 1}, {0, 20, 1}, {0, -1, 1}, {0, 0, 0}} {
+		if _, err := r.ReadSignals([]int{args[0]}, args[1], args[2]); err == nil {
+			t.Errorf("ReadSignals(%v)应返回错误", args)
+		}
+	}
+}
+
+func TestReadSignalsAcrossChunks(t *testing.T) {
+	// 每个记录32KB，200个记录需要多次批量读取
+	r := openTestEDF(t, multiSignalFile(t, 2, 8192, 200))
+	if chunk := bulkReadBytes / (2*8192 + 2*8193); chunk >= 200 {
+		t.Fatalf("测试文件应大于一次批量读取的%d个记录", chunk)
+	}
+
+	result, err := r.ReadSignals([]int{1, 0}, 0, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n, k := range []int{1, 0} {
+		for j, v := range result[n] {
+			if want := multiSignalValue(k, j); int(v) != want {
+				t.Fatalf("信号%d样本%d = %d, want %d", k, j, v, want)
+			}
+		}
+	}
+}
+
+func TestReadAllPoints(t *testing.T) {
+	r := openTestEDF(t, multiSignalFile(t, 3, 10, 4))
+
+	indices := []int{2, 0}
+	result, err := r.ReadAllPoints(indices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n, k := range indices {
+		samples := 10 + k
+		if len(result[n]) != 4*samples {
+			t.Fatalf("信号%d读取了%d个点, want %d", k, len(result[n]), 4*samples)
+		}
+		for j, p := range result[n] {
+			if want := float64(j) / float64(samples); math.Abs(p.X-want) > 1e-9 {
+				t.Fatalf("信号%d点%d的时间 = %g, want %g", k, j, p.X, want)
+			}
+			if want := float64(multiSignalValue(k, j)) / 10; math.Abs(p.Y-want) > 1e-9 {
+				t.Fatalf("信号%d点%d = %g, want %g", k, j, p.Y, want)
+			}
+		}
+	}
+
+	if _, err := r.ReadAllPoints([]int{3}); err == nil {
+		t.Error("信号索引超出范围应返回错误")
+	}
+
+	// 没有数据记录时返回空结果
+	empty := openTestEDF(t, testEDF{Signals: []testSignal{int16Signal("ECG", 10)}}.write(t))
+	result, err = empty.ReadAllPoints([]int{0})
+	if err != nil || len(result) != 1 || len(result[0]) != 0 {
+		t.Errorf("ReadAllPoints = %v, %v", result, err)
+	}
+}
+
+// BenchmarkReadSignals 测量一次读取全部信号的吞吐量，需求文档的目标为不低于10MB/s
+func BenchmarkReadSignals(b *testing.B) {
+	const numSignals, samples, records = 8, 512, 600
+	path := multiSignalFile(b, numSignals, samples, records)
+	r := openTestEDF(b, path)
+
+	indices := make([]int, numSignals)
+	recordBytes := 0
+	for k := range indices {
+		indices[k] = k
+		recordBytes += 2 * (samples + k)
+	}
+
+	b.SetBytes(int64(recordBytes * records))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.ReadSignals(indices, 0, records); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLoadSignalToChannel 测量读取单个信号并转换为物理值的吞吐量，按文件中全部数据记录的字节数计
+func BenchmarkLoadSignalToChannel(b *testing.B) {
+	const numSignals, samples, records = 8, 512, 600
+	r := openTestEDF(b, multiSignalFile(b, numSignals, samples, records))
+
+	recordBytes := 0
+	for k := 0; k < numSignals; k++ {
+		recordBytes += 2 * (samples + k)
+	}
+	channel := data.NewChannel("0", "S0")
+
+	b.SetBytes(int64(recordBytes * records))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := r.LoadSignalToChannel(0, channel); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
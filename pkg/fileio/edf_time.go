@@ -0,0 +1,111 @@
+package fileio
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EDF+记录ID中开始日期子字段的前缀
+const startdatePrefix = "Startdate "
+
+// 开始时间未知时写入文件头的日期，EDF+规范约定为01.01.85
+var unknownStartTime = time.Date(1985, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// 解析文件头中的开始日期和时间
+// 两位年份85-99表示1900年代，00-84表示2000年代；EDF+文件优先使用记录ID中"Startdate dd-MMM-yyyy"的四位年份。
+// EDF文件头没有时区信息，返回的时间以UTC表示记录设备的本地时钟。
+func parseStartTime(startDate, startTime, recordingID string, edfPlus bool) (time.Time, error) {
+	dateParts := strings.Split(startDate, ".")
+	if len(dateParts) != 3 {
+		return time.Time{}, fmt.Errorf("无效的开始日期: %q", startDate)
+	}
+
+	day, err := strconv.Atoi(dateParts[0])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的开始日期: %q", startDate)
+	}
+	month, err := strconv.Atoi(dateParts[1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的开始日期: %q", startDate)
+	}
+
+	// 2084年以后的文件年份写为"yy"，只能从记录ID中获取
+	year := -1
+	if dateParts[2] != "yy" {
+		yy, err := strconv.Atoi(dateParts[2])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("无效的开始日期: %q", startDate)
+		}
+		if yy >= 85 {
+			year = 1900 + yy
+		} else {
+			year = 2000 + yy
+		}
+	}
+
+	if edfPlus {
+		if d, ok := parseRecordingStartdate(recordingID); ok {
+			year = d.Year()
+		}
+	}
+	if year < 0 {
+		return time.Time{}, fmt.Errorf("开始日期的年份为yy，且记录ID中没有Startdate")
+	}
+
+	timeParts := strings.Split(startTime, ".")
+	if len(timeParts) != 3 {
+		return time.Time{}, fmt.Errorf("无效的开始时间: %q", startTime)
+	}
+	clock := make([]int, 3)
+	for i, part := range timeParts {
+		clock[i], err = strconv.Atoi(part)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("无效的开始时间: %q", startTime)
+		}
+	}
+
+	t := time.Date(year, time.Month(month), day, clock[0], clock[1], clock[2], 0, time.UTC)
+
+	// time.Date会规范化越界的值，需要确认各字段没有被调整
+	if t.Day() != day || int(t.Month()) != month || t.Hour() != clock[0] || t.Minute() != clock[1] || t.Second() != clock[2] {
+		return time.Time{}, fmt.Errorf("无效的开始日期时间: %q %q", startDate, startTime)
+	}
+
+	return t, nil
+}
+
+// 从EDF+记录ID中解析"Startdate dd-MMM-yyyy"，日期未知（X）时返回false
+func parseRecordingStartdate(recordingID string) (time.Time, bool) {
+	if !strings.HasPrefix(recordingID, startdatePrefix) {
+		return time.Time{}, false
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(recordingID, startdatePrefix))
+	if len(fields) == 0 {
+		return time.Time{}, false
+	}
+
+	d, err := time.Parse("02-Jan-2006", fields[0])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return d, true
+}
+
+// 格式化文件头的开始日期字段，超出1985-2084范围时年份写为"yy"
+func formatStartDate(t time.Time) string {
+	if t.Year() < 1985 || t.Year() > 2084 {
+		return t.Format("02.01.") + "yy"
+	}
+	return t.Format("02.01.06")
+}
+
+// 格式化EDF+记录ID的开始日期子字段，如"Startdate 02-MAR-2002"，开始时间未知时为"Startdate X"
+func formatRecordingStartdate(t time.Time) string {
+	if t.IsZero() || t.Equal(unknownStartTime) {
+		return startdatePrefix + "X"
+	}
+	return startdatePrefix + strings.ToUpper(t.Format("02-Jan-2006"))
+}
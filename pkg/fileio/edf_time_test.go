@@ -0,0 +1,100 @@
+package fileio
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+func TestParseStartTime(t *testing.T) {
+	tests := []struct {
+		name        string
+		date, clock string
+		recordingID string
+		edfPlus     bool
+		want        time.Time
+		wantErr     bool
+	}{
+		{"85年属于1900年代", "31.12.85", "23.59.59", "", false, time.Date(1985, 12, 31, 23, 59, 59, 0, time.UTC), false},
+		{"84年属于2000年代", "01.01.84", "00.00.00", "", false, time.Date(2084, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"00年", "29.02.00", "12.30.00", "", false, time.Date(2000, 2, 29, 12, 30, 0, 0, time.UTC), false},
+		{"EDF+使用Startdate的年份", "02.03.02", "10.11.12", "Startdate 02-MAR-2102 X X X", true, time.Date(2102, 3, 2, 10, 11, 12, 0, time.UTC), false},
+		{"yy年份", "02.03.yy", "10.11.12", "Startdate 02-MAR-2190 X X X", true, time.Date(2190, 3, 2, 10, 11, 12, 0, time.UTC), false},
+		{"Startdate未知", "02.03.02", "10.11.12", "Startdate X X X X", true, time.Date(2002, 3, 2, 10, 11, 12, 0, time.UTC), false},
+		{"EDF不读取记录ID", "02.03.02", "10.11.12", "Startdate 02-MAR-2102", false, time.Date(2002, 3, 2, 10, 11, 12, 0, time.UTC), false},
+		{"yy缺少Startdate", "02.03.yy", "10.11.12", "", true, time.Time{}, true},
+		{"日期越界", "30.02.20", "10.11.12", "", false, time.Time{}, true},
+		{"时间越界", "01.01.20", "24.00.00", "", false, time.Time{}, true},
+		{"日期格式错误", "2020-01-01", "10.11.12", "", false, time.Time{}, true},
+		{"月份不是数字", "01.ab.20", "10.11.12", "", false, time.Time{}, true},
+		{"时间格式错误", "01.01.20", "10:11:12", "", false, time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStartTime(tt.date, tt.clock, tt.recordingID, tt.edfPlus)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStartTime error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseStartTime = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatStartDate(t *testing.T) {
+	tests := []struct {
+		t         time.Time
+		date      string
+		startdate string
+	}{
+		{time.Date(1985, 1, 2, 0, 0, 0, 0, time.UTC), "02.01.85", "Startdate 02-JAN-1985"},
+		{time.Date(2084, 12, 31, 0, 0, 0, 0, time.UTC), "31.12.84", "Startdate 31-DEC-2084"},
+		{time.Date(2085, 6, 7, 0, 0, 0, 0, time.UTC), "07.06.yy", "Startdate 07-JUN-2085"},
+		{time.Date(1970, 6, 7, 0, 0, 0, 0, time.UTC), "07.06.yy", "Startdate 07-JUN-1970"},
+		{unknownStartTime, "01.01.85", "Startdate X"},
+		{time.Time{}, "01.01.yy", "Startdate X"},
+	}
+
+	for _, tt := range tests {
+		if got := formatStartDate(tt.t); got != tt.date {
+			t.Errorf("formatStartDate(%v) = %q, want %q", tt.t, got, tt.date)
+		}
+		if got := formatRecordingStartdate(tt.t); got != tt.startdate {
+			t.Errorf("formatRecordingStartdate(%v) = %q, want %q", tt.t, got, tt.startdate)
+		}
+	}
+
+	// 写出的日期能被解析回同一天
+	for _, year := range []int{1985, 2000, 2084, 2085, 2150} {
+		start := time.Date(year, 3, 4, 5, 6, 7, 0, time.UTC)
+		got, err := parseStartTime(formatStartDate(start), start.Format("15.04.05"), formatRecordingStartdate(start)+" X X X", true)
+		if err != nil || !got.Equal(start) {
+			t.Errorf("%d年的往返结果 = %v, %v", year, got, err)
+		}
+	}
+}
+
+func TestWriteStartTime(t *testing.T) {
+	channel := sineChannel("0", 10, 0, 2)
+
+	// 开始时间未知时写入01.01.85和Startdate X
+	r := writeAndOpen(t, []*data.Channel{channel}, EDFWriteOptions{Annotations: []Annotation{}})
+	h := r.GetHeader()
+	if !h.StartTime.Equal(unknownStartTime) {
+		t.Errorf("StartTime = %v, want %v", h.StartTime, unknownStartTime)
+	}
+	if !strings.HasPrefix(h.RecordingID, "Startdate X ") {
+		t.Errorf("RecordingID = %q", h.RecordingID)
+	}
+
+	// 2084年以后的日期通过Startdate保留四位年份
+	start := time.Date(2101, 7, 8, 9, 10, 11, 0, time.UTC)
+	r = writeAndOpen(t, []*data.Channel{channel}, EDFWriteOptions{StartTime: start, Annotations: []Annotation{}})
+	if h := r.GetHeader(); !h.StartTime.Equal(start) || !strings.HasPrefix(h.RecordingID, "Startdate 08-JUL-2101 ") {
+		t.Errorf("StartTime = %v, RecordingID = %q", h.StartTime, h.RecordingID)
+	}
+}
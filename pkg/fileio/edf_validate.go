@@ -0,0 +1,200 @@
+package fileio
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 校验问题的严重程度
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// ValidationIssue 表示文件头校验发现的一个字段级问题
+type ValidationIssue struct {
+	Field    string // 字段名，如"HeaderBytes"、"DigitalMax"
+	Signal   int    // 信号索引，文件级字段为-1
+	Severity string // SeverityError或SeverityWarning
+	Message  string // 问题描述
+	Repaired bool   // 宽松模式下是否已修复
+}
+
+// Error 实现error接口
+func (i ValidationIssue) Error() string {
+	if i.Signal >= 0 {
+		return fmt.Sprintf("信号%d的%s: %s", i.Signal, i.Field, i.Message)
+	}
+	return fmt.Sprintf("%s: %s", i.Field, i.Message)
+}
+
+// ValidationError 表示文件头校验失败，包含所有错误级别的问题
+type ValidationError struct {
+	Issues []ValidationIssue
+}
+
+// Error 实现error接口
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		messages[i] = issue.Error()
+	}
+	return "EDF文件头校验失败: " + strings.Join(messages, "; ")
+}
+
+// 将读取文件头字段时的错误包装为字段级校验错误
+func headerFieldError(field string, signal int, err error) error {
+	return headerIssueError(field, signal, fmt.Sprintf("无法读取: %v", err))
+}
+
+// 返回只包含一个字段级错误的校验错误，用于无法继续解析文件头的情况
+func headerIssueError(field string, signal int, message string) error {
+	return &ValidationError{
+		Issues: []ValidationIssue{{
+			Field:    field,
+			Signal:   signal,
+			Severity: SeverityError,
+			Message:  message,
+		}},
+	}
+}
+
+// Warnings 返回打开文件时产生的校验警告（包括宽松模式下的修复记录）
+func (r *EDFReader) Warnings() []ValidationIssue {
+	return r.warnings
+}
+
+// 校验文件头，宽松模式下修复可修复的问题
+func (r *EDFReader) validate(fileSize int64, lenient bool) error {
+	var issues []ValidationIssue
+	h := &r.header
+
+	// report 记录一个问题，repair非nil时宽松模式下执行修复并降级为警告
+	report := func(field string, signal int, message string, repair func()) {
+		issue := ValidationIssue{
+			Field:    field,
+			Signal:   signal,
+			Severity: SeverityError,
+			Message:  message,
+		}
+		if lenient && repair != nil {
+			repair()
+			issue.Severity = SeverityWarning
+			issue.Repaired = true
+		}
+		issues = append(issues, issue)
+	}
+	warn := func(field string, signal int, message string) {
+		issues = append(issues, ValidationIssue{
+			Field:    field,
+			Signal:   signal,
+			Severity: SeverityWarning,
+			Message:  message,
+		})
+	}
+
+	if h.Version != "0" && !h.IsBDF() {
+		warn("Version", -1, fmt.Sprintf("非标准的版本字段: %q", h.Version))
+	}
+
+	if r.startTimeErr != nil {
+		if lenient {
+			warn("StartTime", -1, "无法解析开始日期和时间，已置为零值")
+		} else {
+			report("StartTime", -1, fmt.Sprintf("无法解析开始日期和时间: %v", r.startTimeErr), nil)
+		}
+	}
+
+	if expected := 256 * (h.NumSignals + 1); h.HeaderBytes != expected {
+		report("HeaderBytes", -1, fmt.Sprintf("头部字节数应为%d，实际为%d", expected, h.HeaderBytes), func() {
+			h.HeaderBytes = expected
+		})
+	}
+
+	// 数字值的合法范围
+	limitMin, limitMax := -32768.0, 32767.0
+	if h.IsBDF() {
+		limitMin, limitMax = -8388608.0, 8388607.0
+	}
+
+	hasDataSignal := false
+	for i := range h.SignalHeaders {
+		sh := &h.SignalHeaders[i]
+
+		if sh.Samples <= 0 {
+			report("Samples", i, fmt.Sprintf("每个记录的样本数必须大于0: %d", sh.Samples), nil)
+		}
+		if sh.IsAnnotation() {
+			continue
+		}
+		hasDataSignal = true
+
+		if sh.DigitalMin < limitMin || sh.DigitalMax > limitMax {
+			report("DigitalMin", i, fmt.Sprintf("数字范围[%g, %g]超出格式允许的[%g, %g]", sh.DigitalMin, sh.DigitalMax, limitMin, limitMax), func() {
+				sh.DigitalMin = max(sh.DigitalMin, limitMin)
+				sh.DigitalMax = min(sh.DigitalMax, limitMax)
+			})
+		}
+
+		if sh.DigitalMax < sh.DigitalMin {
+			report("DigitalMax", i, fmt.Sprintf("数字最大值%g小于最小值%g", sh.DigitalMax, sh.DigitalMin), func() {
+				sh.DigitalMin, sh.DigitalMax = sh.DigitalMax, sh.DigitalMin
+			})
+		} else if sh.DigitalMax == sh.DigitalMin {
+			report("DigitalMax", i, fmt.Sprintf("数字最大值与最小值相等: %g", sh.DigitalMax), nil)
+		}
+
+		if sh.PhysicalMax == sh.PhysicalMin {
+			report("PhysicalMax", i, fmt.Sprintf("物理最大值与最小值相等: %g", sh.PhysicalMax), nil)
+		}
+	}
+
+	if h.Duration < 0 || (h.Duration == 0 && hasDataSignal) {
+		report("Duration", -1, fmt.Sprintf("数据记录时长无效: %g", h.Duration), nil)
+	}
+
+	// 根据文件大小校验数据记录数
+	_, recordBytes := r.recordLayout()
+	if recordBytes > 0 && fileSize >= int64(h.HeaderBytes) {
+		available := int((fileSize - int64(h.HeaderBytes)) / int64(recordBytes))
+		trailing := (fileSize - int64(h.HeaderBytes)) % int64(recordBytes)
+
+		switch {
+		case h.DataRecords == -1:
+			// -1表示记录仍在进行中，按文件大小推算记录数
+			h.DataRecords = available
+			warn("DataRecords", -1, fmt.Sprintf("数据记录数为-1，已按文件大小推算为%d", available))
+		case h.DataRecords < 0:
+			report("DataRecords", -1, fmt.Sprintf("无效的数据记录数: %d", h.DataRecords), func() {
+				h.DataRecords = available
+			})
+		case h.DataRecords > available:
+			report("DataRecords", -1, fmt.Sprintf("文件被截断: 头部声明%d个数据记录，实际只有%d个", h.DataRecords, available), func() {
+				h.DataRecords = available
+			})
+		case h.DataRecords < available || trailing != 0:
+			warn("DataRecords", -1, fmt.Sprintf("文件末尾有%d字节多余数据", fileSize-int64(h.HeaderBytes)-int64(h.DataRecords)*int64(recordBytes)))
+		}
+	} else if fileSize < int64(h.HeaderBytes) {
+		report("HeaderBytes", -1, fmt.Sprintf("文件大小%d字节小于头部字节数%d", fileSize, h.HeaderBytes), nil)
+	}
+
+	return r.finishValidation(issues)
+}
+
+// 区分错误和警告，存在错误时返回*ValidationError
+func (r *EDFReader) finishValidation(issues []ValidationIssue) error {
+	var errs []ValidationIssue
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			errs = append(errs, issue)
+		} else {
+			r.warnings = append(r.warnings, issue)
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Issues: errs}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package fileio
+
+import (
+	"errors"
+	"testing"
+)
+
+// 返回校验错误中指定字段的问题，不存在时返回false
+func findIssue(issues []ValidationIssue, field string, signal int) (ValidationIssue, bool) {
+	for _, issue := range issues {
+		if issue.Field == field && issue.Signal == signal {
+			return issue, true
+		}
+	}
+	return ValidationIssue{}, false
+}
+
+// 打开文件并断言返回包含指定字段错误的*ValidationError
+func expectValidationError(t *testing.T, path string, opts EDFOpenOptions, field string, signal int) {
+	t.Helper()
+	r, err := OpenEDFWithOptions(path, opts)
+	if err == nil {
+		r.Close()
+		t.Fatalf("应返回%s的校验错误", field)
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("错误类型为%T，应为*ValidationError: %v", err, err)
+	}
+	issue, ok := findIssue(verr.Issues, field, signal)
+	if !ok {
+		t.Fatalf("校验错误中缺少%s(信号%d)的问题: %v", field, signal, err)
+	}
+	if issue.Severity != SeverityError || issue.Repaired {
+		t.Errorf("%s的问题 = %+v，应为未修复的错误", field, issue)
+	}
+}
+
+func TestOpenEDFInvalidNumSignals(t *testing.T) {
+	for _, n := range []string{"-1", "0", "-999", "x"} {
+		t.Run(n, func(t *testing.T) {
+			path := testEDF{
+				NumSignals: n,
+				Signals:    []testSignal{int16Signal("ECG", 2)},
+				Records:    [][]byte{int16Samples(1, 2)},
+			}.write(t)
+			expectValidationError(t, path, EDFOpenOptions{}, "NumSignals", -1)
+			expectValidationError(t, path, EDFOpenOptions{Lenient: true}, "NumSignals", -1)
+		})
+	}
+}
+
+func TestOpenEDFTruncatedHeader(t *testing.T) {
+	full := testEDF{
+		Signals: []testSignal{int16Signal("ECG", 2)},
+		Records: [][]byte{int16Samples(1, 2)},
+	}.bytes()
+
+	// 在信号头的PhysicalMin字段中截断
+	path := writeTestFile(t, "truncated.edf", full[:256+16+80+8+4])
+	expectValidationError(t, path, EDFOpenOptions{}, "PhysicalMin", 0)
+}
+
+func TestOpenEDFHeaderBytesMismatch(t *testing.T) {
+	path := testEDF{
+		HeaderBytes: "768",
+		Signals:     []testSignal{int16Signal("ECG", 2)},
+		Records:     [][]byte{int16Samples(1, 2), int16Samples(3, 4)},
+	}.write(t)
+
+	expectValidationError(t, path, EDFOpenOptions{}, "HeaderBytes", -1)
+
+	r, err := OpenEDFWithOptions(path, EDFOpenOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("宽松模式应修复头部字节数: %v", err)
+	}
+	defer r.Close()
+	if h := r.GetHeader(); h.HeaderBytes != 512 || h.DataRecords != 2 {
+		t.Errorf("修复后HeaderBytes = %d, DataRecords = %d", h.HeaderBytes, h.DataRecords)
+	}
+	if issue, ok := findIssue(r.Warnings(), "HeaderBytes", -1); !ok || !issue.Repaired || issue.Severity != SeverityWarning {
+		t.Errorf("Warnings = %+v，应包含已修复的HeaderBytes问题", r.Warnings())
+	}
+	values, err := r.ReadSignalData(0, 0, 2)
+	if err != nil || len(values) != 4 || values[3] != 4 {
+		t.Errorf("ReadSignalData = %v, %v", values, err)
+	}
+}
+
+func TestOpenEDFDataRecords(t *testing.T) {
+	signals := []testSignal{int16Signal("ECG", 2)}
+	records := [][]byte{int16Samples(1, 2), int16Samples(3, 4), int16Samples(5, 6)}
+
+	// -1表示记录未结束，按文件大小推算
+	r := openTestEDF(t, testEDF{DataRecords: "-1", Signals: signals, Records: records}.write(t))
+	if n := r.GetHeader().DataRecords; n != 3 {
+		t.Errorf("DataRecords = %d, want 3", n)
+	}
+	if _, ok := findIssue(r.Warnings(), "DataRecords", -1); !ok {
+		t.Error("推算记录数应产生警告")
+	}
+
+	// 头部声明的记录数多于文件中的记录
+	path := testEDF{DataRecords: "5", Signals: signals, Records: records}.write(t)
+	expectValidationError(t, path, EDFOpenOptions{}, "DataRecords", -1)
+	lenient, err := OpenEDFWithOptions(path, EDFOpenOptions{Lenient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lenient.Close()
+	if n := lenient.GetHeader().DataRecords; n != 3 {
+		t.Errorf("宽松模式下DataRecords = %d, want 3", n)
+	}
+}
+
+func TestOpenEDFSignalRanges(t *testing.T) {
+	reversed := int16Signal("ECG", 1)
+	reversed.DigMin, reversed.DigMax = 32767, -32768
+	path := testEDF{Signals: []testSignal{reversed}, Records: [][]byte{int16Samples(0)}}.write(t)
+
+	expectValidationError(t, path, EDFOpenOptions{}, "DigitalMax", 0)
+	r, err := OpenEDFWithOptions(path, EDFOpenOptions{Lenient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if sh := r.GetHeader().SignalHeaders[0]; sh.DigitalMin != -32768 || sh.DigitalMax != 32767 {
+		t.Errorf("修复后数字范围 = [%g, %g]", sh.DigitalMin, sh.DigitalMax)
+	}
+
+	// 物理范围为零无法修复
+	flat := int16Signal("ECG", 1)
+	flat.PhysMax = flat.PhysMin
+	path = testEDF{Signals: []testSignal{flat}, Records: [][]byte{int16Samples(0)}}.write(t)
+	expectValidationError(t, path, EDFOpenOptions{Lenient: true}, "PhysicalMax", 0)
+
+	empty := int16Signal("ECG", 0)
+	path = testEDF{Signals: []testSignal{empty}, Records: [][]byte{nil}}.write(t)
+	expectValidationError(t, path, EDFOpenOptions{}, "Samples", 0)
+}
+
+func TestOpenEDFInvalidStartDate(t *testing.T) {
+	path := testEDF{
+		StartDate: "31.02.20",
+		Signals:   []testSignal{int16Signal("ECG", 1)},
+		Records:   [][]byte{int16Samples(0)},
+	}.write(t)
+
+	expectValidationError(t, path, EDFOpenOptions{}, "StartTime", -1)
+
+	r, err := OpenEDFWithOptions(path, EDFOpenOptions{Lenient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if !r.GetHeader().StartTime.IsZero() {
+		t.Errorf("StartTime = %v，宽松模式下应为零值", r.GetHeader().StartTime)
+	}
+	if _, ok := findIssue(r.Warnings(), "StartTime", -1); !ok {
+		t.Error("无法解析的开始日期应产生警告")
+	}
+}
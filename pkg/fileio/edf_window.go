@@ -0,0 +1,180 @@
+package fileio
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+// EnableCache 启用已解码数据记录的LRU缓存，records为缓存的记录数，小于等于0时关闭缓存
+// 可与读取并发调用，正在进行的读取继续使用替换前的缓存。
+func (r *EDFReader) EnableCache(records int) {
+	if records <= 0 {
+		r.cache.Store(nil)
+		return
+	}
+	r.cache.Store(newRecordCache(records))
+}
+
+// ReadWindow 读取信号在时间窗口[t0, t1)内的物理值，返回样本及第一个样本的时间
+// 只读取窗口覆盖的数据记录，基于ReadAt实现，可被多个goroutine并发调用。
+// EDF+D文件中窗口可能跨越记录间断，需要每个样本的时间时请使用ReadWindowPoints。
+func (r *EDFReader) ReadWindow(signalIndex int, t0, t1 float64) ([]float64, float64, error) {
+	points, err := r.ReadWindowPoints(signalIndex, t0, t1)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(points) == 0 {
+		return []float64{}, t0, nil
+	}
+
+	values := make([]float64, len(points))
+	for i, p := range points {
+		values[i] = p.Y
+	}
+	return values, points[0].X, nil
+}
+
+// ReadWindowPoints 读取信号在时间窗口[t0, t1)内的数据点，X为样本的实际时间
+func (r *EDFReader) ReadWindowPoints(signalIndex int, t0, t1 float64) ([]data.DataPoint, error) {
+	if signalIndex < 0 || signalIndex >= r.header.NumSignals {
+		return nil, fmt.Errorf("信号索引超出范围: %d", signalIndex)
+	}
+	if r.IsAnnotationSignal(signalIndex) {
+		return nil, fmt.Errorf("信号%d是注释信号，不能按时间窗口读取", signalIndex)
+	}
+	if t1 <= t0 {
+		return nil, fmt.Errorf("无效的时间窗口: [%g, %g)", t0, t1)
+	}
+
+	recordTimes, err := r.RecordStartTimes()
+	if err != nil {
+		return nil, err
+	}
+
+	samplesPerRecord := r.header.SignalHeaders[signalIndex].Samples
+	timeStep := r.header.Duration / float64(samplesPerRecord)
+
+	// 第一个结束时间晚于t0的数据记录
+	first := sort.Search(len(recordTimes), func(i int) bool {
+		return recordTimes[i]+r.header.Duration > t0
+	})
+
+	points := make([]data.DataPoint, 0)
+	for record := first; record < len(recordTimes) && recordTimes[record] < t1; record++ {
+		// 记录内落在窗口中的样本范围[start, end)
+		recordStart := recordTimes[record]
+		start := int(math.Max(0, math.Ceil((t0-recordStart)/timeStep-1e-9)))
+		end := int(math.Min(float64(samplesPerRecord), math.Ceil((t1-recordStart)/timeStep-1e-9)))
+		if start >= end {
+			continue
+		}
+
+		samples, err := r.readRecord(record)
+		if err != nil {
+			return nil, err
+		}
+
+		for j := start; j < end; j++ {
+			points = append(points, data.DataPoint{
+				X: recordStart + float64(j)*timeStep,
+				Y: r.ConvertToPhysical(signalIndex, samples[signalIndex][j]),
+			})
+		}
+	}
+
+	return points, nil
+}
+
+// LoadWindowToChannel 将信号在时间窗口[t0, t1)内的数据加载到通道
+func (r *EDFReader) LoadWindowToChannel(signalIndex int, channel *data.Channel, t0, t1 float64) error {
+	points, err := r.ReadWindowPoints(signalIndex, t0, t1)
+	if err != nil {
+		return err
+	}
+
+	gaps, err := r.Gaps()
+	if err != nil {
+		return err
+	}
+
+	channel.ClearData()
+	channel.Data = points
+	channel.StartTime = r.header.StartTime
+	channel.SampleRate = r.GetSignalSamplingRate(signalIndex)
+
+	// 只保留与窗口相交的间断
+	for _, g := range gaps {
+		if g.End > t0 && g.Start < t1 {
+			channel.Gaps = append(channel.Gaps, g)
+		}
+	}
+
+	return nil
+}
+
+// 读取并解码一个完整的数据记录，返回每个数据信号的数字值（注释信号为nil）
+// 返回的切片可能来自缓存，调用方不能修改。
+func (r *EDFReader) readRecord(index int) ([][]int32, error) {
+	if index < 0 || index >= r.header.DataRecords {
+		return nil, fmt.Errorf("数据记录超出范围: %d", index)
+	}
+
+	cache := r.cache.Load()
+	if cache != nil {
+		if samples, ok := cache.get(index); ok {
+			return samples, nil
+		}
+	}
+
+	offsets, recordBytes := r.recordLayout()
+	buf := make([]byte, recordBytes)
+	recordPos := int64(r.header.HeaderBytes) + int64(index)*int64(recordBytes)
+	if err := r.readFullAt(buf, recordPos); err != nil {
+		return nil, err
+	}
+
+	sampleBytes := r.header.SampleBytes()
+	samples := make([][]int32, r.header.NumSignals)
+	for i, sh := range r.header.SignalHeaders {
+		if sh.IsAnnotation() {
+			continue
+		}
+
+		samples[i] = make([]int32, sh.Samples)
+		decodeSamples(samples[i], buf[offsets[i]:], sampleBytes)
+	}
+
+	if cache != nil {
+		cache.put(index, samples)
+	}
+
+	return samples, nil
+}
+
+// 计算每个信号在数据记录中的字节偏移及数据记录的总字节数
+func (r *EDFReader) recordLayout() ([]int, int) {
+	sampleBytes := r.header.SampleBytes()
+	offsets := make([]int, r.header.NumSignals)
+	recordBytes := 0
+	for i, sh := range r.header.SignalHeaders {
+		offsets[i] = recordBytes
+		recordBytes += sh.Samples * sampleBytes
+	}
+	return offsets, recordBytes
+}
+
+// 从指定位置读满缓冲区
+func (r *EDFReader) readFullAt(buf []byte, offset int64) error {
+	n, err := r.file.ReadAt(buf, offset)
+	if n < len(buf) {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return nil
+}
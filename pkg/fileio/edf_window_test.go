@@ -0,0 +1,141 @@
+package fileio
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+)
+
+// 5个1秒的数据记录，每个记录10个样本，第i个样本的数字值为i
+func windowTestFile(t *testing.T) string {
+	records := make([][]byte, 5)
+	for rec := range records {
+		values := make([]int, 10)
+		for j := range values {
+			values[j] = rec*10 + j
+		}
+		records[rec] = record(int16Samples(values...), talBytes(20, fmt.Sprintf("+%d\x14\x14", rec)))
+	}
+	return testEDF{
+		Reserved: "EDF+C",
+		Signals:  []testSignal{int16Signal("ECG", 10), annotationSignal(10)},
+		Records:  records,
+	}.write(t)
+}
+
+func TestReadWindow(t *testing.T) {
+	r := openTestEDF(t, windowTestFile(t))
+
+	tests := []struct {
+		name       string
+		t0, t1     float64
+		first, end int // 期望的样本范围[first, end)
+	}{
+		{"记录内", 0.2, 0.5, 2, 5},
+		{"跨越记录", 0.95, 2.25, 10, 23},
+		{"记录边界", 1, 2, 10, 20},
+		{"超出末尾", 4.5, 100, 45, 50},
+		{"从负时间开始", -3, 0.25, 0, 3},
+		{"窗口内没有样本", 0.91, 0.99, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, start, err := r.ReadWindow(0, tt.t0, tt.t1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(values) != tt.end-tt.first {
+				t.Fatalf("ReadWindow返回%d个样本, want %d", len(values), tt.end-tt.first)
+			}
+			if len(values) > 0 && math.Abs(start-float64(tt.first)/10) > 1e-9 {
+				t.Errorf("第一个样本的时间 = %g, want %g", start, float64(tt.first)/10)
+			}
+			for i, v := range values {
+				if want := float64(tt.first+i) / 10; math.Abs(v-want) > 1e-9 {
+					t.Errorf("样本%d = %g, want %g", i, v, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadWindowErrors(t *testing.T) {
+	r := openTestEDF(t, windowTestFile(t))
+
+	if _, _, err := r.ReadWindow(2, 0, 1); err == nil {
+		t.Error("信号索引超出范围应返回错误")
+	}
+	if _, _, err := r.ReadWindow(1, 0, 1); err == nil {
+		t.Error("按时间窗口读取注释信号应返回错误")
+	}
+	if _, _, err := r.ReadWindow(0, 2, 2); err == nil {
+		t.Error("空时间窗口应返回错误")
+	}
+}
+
+func TestReadWindowConcurrentWithCache(t *testing.T) {
+	r := openTestEDF(t, windowTestFile(t))
+	r.EnableCache(2)
+
+	var wg sync.WaitGroup
+	for k := 0; k < 16; k++ {
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+			t0 := float64(k%4) + 0.35
+			values, start, err := r.ReadWindow(0, t0, t0+1.3)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if len(values) != 13 || math.Abs(start-(t0+0.05)) > 1e-9 {
+				t.Errorf("窗口[%g, %g)返回%d个样本，起始时间%g", t0, t0+1.3, len(values), start)
+				return
+			}
+			for i, v := range values {
+				if want := start + float64(i)/10; math.Abs(v-want) > 1e-9 {
+					t.Errorf("窗口[%g, %g)的样本%d = %g, want %g", t0, t0+1.3, i, v, want)
+					return
+				}
+			}
+		}(k)
+	}
+
+	// 读取过程中切换缓存
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for k := 0; k < 8; k++ {
+			r.EnableCache(k % 3)
+		}
+	}()
+	wg.Wait()
+}
+
+func TestRecordCacheEviction(t *testing.T) {
+	c := newRecordCache(2)
+	c.put(1, [][]int32{{1}})
+	c.put(2, [][]int32{{2}})
+
+	// 访问1后2成为最久未使用的记录
+	if _, ok := c.get(1); !ok {
+		t.Fatal("记录1应在缓存中")
+	}
+	c.put(3, [][]int32{{3}})
+
+	if _, ok := c.get(2); ok {
+		t.Error("记录2应被淘汰")
+	}
+	for _, index := range []int{1, 3} {
+		if samples, ok := c.get(index); !ok || samples[0][0] != int32(index) {
+			t.Errorf("记录%d = %v, %v", index, samples, ok)
+		}
+	}
+
+	// 更新已有记录不增加条目
+	c.put(3, [][]int32{{30}})
+	if samples, _ := c.get(3); samples[0][0] != 30 || c.order.Len() != 2 {
+		t.Errorf("更新后记录3 = %v，缓存条目数%d", samples, c.order.Len())
+	}
+}
@@ -0,0 +1,674 @@
+package fileio
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+	"github.com/ljx520ljx/chartSystem/internal/model"
+)
+
+// EDFWriter 表示EDF文件写入器
+type EDFWriter struct {
+	file *os.File
+}
+
+// EDFWriteOptions 表示将通道导出为EDF时的选项
+type EDFWriteOptions struct {
+	PatientID      string            // 病人ID，EDF+为空时写入"X X X X"
+	RecordingID    string            // 记录ID，EDF+为空时根据开始时间生成
+	StartTime      time.Time         // 记录开始时间，零值时使用第一个通道的StartTime
+	RecordDuration float64           // 每个数据记录的时长（秒），默认1秒
+	PhysicalDims   map[string]string // 通道ID对应的物理单位
+	UseProcessed   bool              // 是否导出处理后的数据
+	BDF            bool              // 是否以24位BDF格式写出
+	Annotations    []Annotation      // 注释事件，非nil时写出EDF+注释信号
+}
+
+// CreateEDF 创建一个EDF文件
+func CreateEDF(path string) (*EDFWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EDFWriter{
+		file: file,
+	}, nil
+}
+
+// Close 关闭文件
+func (w *EDFWriter) Close() error {
+	return w.file.Close()
+}
+
+// WriteSignals 按文件头写出各信号的物理值
+// header.SignalHeaders只描述数据信号，与signals一一对应；Samples为每个数据记录的样本数。
+// 物理范围或数字范围未设置（最小值等于最大值）时按数据和格式自动选取。
+// annotations非nil时追加EDF+注释信号，并将文件标记为EDF+C。
+// EDF不能表示缺失值，样本中有NaN或无穷大时返回错误。
+func (w *EDFWriter) WriteSignals(header EDFHeader, signals [][]float64, annotations []Annotation) error {
+	return w.writeSignals(header, signals, annotations, nil)
+}
+
+// 写出文件头和数据记录
+// recordTimes非nil时为每个数据记录的开始时间（秒），文件以EDF+D写出，
+// 此时每个信号的样本数必须恰好为数据记录数乘以每个记录的样本数。
+func (w *EDFWriter) writeSignals(header EDFHeader, signals [][]float64, annotations []Annotation, recordTimes []float64) error {
+	if len(header.SignalHeaders) != len(signals) {
+		return fmt.Errorf("信号头数量(%d)与信号数量(%d)不一致", len(header.SignalHeaders), len(signals))
+	}
+	if header.Duration <= 0 {
+		return fmt.Errorf("数据记录时长必须大于0: %g", header.Duration)
+	}
+
+	bdf := header.IsBDF()
+	sampleBytes := header.SampleBytes()
+
+	if header.StartTime.IsZero() {
+		header.StartTime = unknownStartTime
+	}
+
+	// 确定数据记录数并选取每个信号的量化范围
+	header.DataRecords = 0
+	for i := range header.SignalHeaders {
+		sh := &header.SignalHeaders[i]
+		if sh.IsAnnotation() {
+			return fmt.Errorf("信号%d为注释信号，请通过annotations参数写出", i)
+		}
+		if sh.Samples <= 0 {
+			return fmt.Errorf("信号%d每个记录的样本数必须大于0", i)
+		}
+
+		for j, v := range signals[i] {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return fmt.Errorf("信号%d的第%d个样本为%g，EDF不能表示缺失值", i, j, v)
+			}
+		}
+
+		if recordTimes != nil {
+			if len(signals[i]) != len(recordTimes)*sh.Samples {
+				return fmt.Errorf("信号%d的样本数%d与数据记录数%d不一致", i, len(signals[i]), len(recordTimes))
+			}
+			header.DataRecords = len(recordTimes)
+		} else if records := (len(signals[i]) + sh.Samples - 1) / sh.Samples; records > header.DataRecords {
+			header.DataRecords = records
+		}
+
+		if err := fitSignalRange(sh, signals[i], bdf); err != nil {
+			return fmt.Errorf("信号%d: %w", i, err)
+		}
+	}
+
+	// 连续记录的数据记录首尾相接
+	if recordTimes == nil {
+		recordTimes = make([]float64, header.DataRecords)
+		for rec := range recordTimes {
+			recordTimes[rec] = float64(rec) * header.Duration
+		}
+	} else if annotations == nil {
+		// 不连续记录的计时注释需要注释信号
+		annotations = []Annotation{}
+	}
+
+	// 生成每个数据记录的注释字节
+	var talRecords [][]byte
+	if annotations != nil {
+		talRecords = buildTALRecords(annotations, recordTimes)
+
+		maxBytes := 0
+		for _, rec := range talRecords {
+			if len(rec) > maxBytes {
+				maxBytes = len(rec)
+			}
+		}
+
+		annotationLabel := edfAnnotationsLabel
+		digitalMin, digitalMax := -32768.0, 32767.0
+		header.Reserved = "EDF+"
+		if bdf {
+			annotationLabel = bdfAnnotationsLabel
+			digitalMin, digitalMax = -8388608.0, 8388607.0
+			header.Reserved = "BDF+"
+		}
+		if isContinuous(recordTimes, header.Duration) {
+			header.Reserved += "C"
+		} else {
+			header.Reserved += "D"
+		}
+
+		header.SignalHeaders = append(header.SignalHeaders, SignalHeader{
+			Label:       annotationLabel,
+			PhysicalMin: -1,
+			PhysicalMax: 1,
+			DigitalMin:  digitalMin,
+			DigitalMax:  digitalMax,
+			Samples:     (maxBytes + sampleBytes - 1) / sampleBytes,
+		})
+
+		if header.PatientID == "" {
+			header.PatientID = "X X X X"
+		}
+		if header.RecordingID == "" {
+			header.RecordingID = formatRecordingStartdate(header.StartTime) + " X X X"
+		}
+	}
+
+	header.NumSignals = len(header.SignalHeaders)
+	header.HeaderBytes = 256 * (header.NumSignals + 1)
+
+	bw := bufio.NewWriter(w.file)
+
+	if err := writeHeader(bw, header); err != nil {
+		return err
+	}
+
+	// 写出数据记录
+	sampleBuf := make([]byte, sampleBytes)
+	for rec := 0; rec < header.DataRecords; rec++ {
+		for i := range signals {
+			sh := header.SignalHeaders[i]
+			for j := 0; j < sh.Samples; j++ {
+				idx := rec*sh.Samples + j
+
+				// 最后一个记录不足时用最后一个样本补齐
+				var value float64
+				if idx < len(signals[i]) {
+					value = signals[i][idx]
+				} else if len(signals[i]) > 0 {
+					value = signals[i][len(signals[i])-1]
+				}
+
+				encodeSample(sampleBuf, quantize(sh, value))
+				if _, err := bw.Write(sampleBuf); err != nil {
+					return err
+				}
+			}
+		}
+
+		if talRecords != nil {
+			annotationHeader := header.SignalHeaders[header.NumSignals-1]
+			raw := make([]byte, annotationHeader.Samples*sampleBytes)
+			copy(raw, talRecords[rec])
+			if _, err := bw.Write(raw); err != nil {
+				return err
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// WriteChannels 将通道数据写出为EDF/EDF+文件
+// 每个通道的采样率取自通道的SampleRate，时间以第一个通道的第一个点为起点，
+// 文件头的开始时间相应顺延，使截取后的记录仍保持正确的绝对时间。
+// 通道包含数据间断时以EDF+D写出：每段数据从间断结束处开始新的数据记录，最后一个记录不足时用该段最后一个样本补齐。
+func (w *EDFWriter) WriteChannels(channels []*data.Channel, opts EDFWriteOptions) error {
+	if len(channels) == 0 {
+		return fmt.Errorf("没有可导出的通道")
+	}
+
+	recordDuration := opts.RecordDuration
+	if recordDuration <= 0 {
+		recordDuration = 1.0
+	}
+
+	header := EDFHeader{
+		Version:     "0",
+		PatientID:   opts.PatientID,
+		RecordingID: opts.RecordingID,
+		Duration:    recordDuration,
+	}
+	if opts.BDF {
+		header.Version = bdfVersion
+	}
+
+	channelPoints := make([][]data.DataPoint, len(channels))
+	for i, channel := range channels {
+		points := channel.Data
+		if opts.UseProcessed {
+			points = channel.ProcessedData
+		}
+		if len(points) == 0 {
+			return fmt.Errorf("通道%s没有数据点", channel.ID)
+		}
+		if !(channel.SampleRate > 0) {
+			return fmt.Errorf("通道%s的采样率未知", channel.ID)
+		}
+
+		samples := int(math.Round(channel.SampleRate * recordDuration))
+		if samples <= 0 {
+			return fmt.Errorf("通道%s的采样率过低: %g", channel.ID, channel.SampleRate)
+		}
+		channelPoints[i] = points
+
+		header.SignalHeaders = append(header.SignalHeaders, SignalHeader{
+			Label:       channel.Name,
+			PhysicalDim: opts.PhysicalDims[channel.ID],
+			Samples:     samples,
+		})
+	}
+	startX := channelPoints[0][0].X
+
+	// 开始时间顺延到导出数据的第一个点（文件头精度为秒）
+	startTime := opts.StartTime
+	if startTime.IsZero() {
+		startTime = channels[0].StartTime
+	}
+	if !startTime.IsZero() {
+		header.StartTime = startTime.Add(time.Duration(startX * float64(time.Second))).Truncate(time.Second)
+	}
+
+	// 注释时间相对于导出数据的起点
+	var annotations []Annotation
+	if opts.Annotations != nil {
+		annotations = make([]Annotation, 0, len(opts.Annotations))
+		for _, a := range opts.Annotations {
+			a.Onset -= startX
+			annotations = append(annotations, a)
+		}
+	}
+
+	gaps, err := exportGaps(channels, channelPoints)
+	if err != nil {
+		return err
+	}
+	if len(gaps) == 0 {
+		signals := make([][]float64, len(channelPoints))
+		for i, points := range channelPoints {
+			signals[i] = make([]float64, len(points))
+			for j, p := range points {
+				signals[i][j] = p.Y
+			}
+		}
+		return w.WriteSignals(header, signals, annotations)
+	}
+
+	signals, recordTimes, err := segmentRecords(channelPoints, header.SignalHeaders, gaps, recordDuration)
+	if err != nil {
+		return err
+	}
+	for i := range recordTimes {
+		recordTimes[i] -= startX
+	}
+	return w.writeSignals(header, signals, annotations, recordTimes)
+}
+
+// 收集各通道落在导出数据范围内的间断，按开始时间排序并合并重叠的间断
+// 间断内不能有任何通道的数据点。
+func exportGaps(channels []*data.Channel, channelPoints [][]data.DataPoint) ([]data.Gap, error) {
+	var gaps []data.Gap
+	for i, channel := range channels {
+		first, last := channelPoints[i][0].X, channelPoints[i][len(channelPoints[i])-1].X
+		for _, g := range channel.Gaps {
+			if g.End > first && g.Start <= last {
+				gaps = append(gaps, g)
+			}
+		}
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i].Start < gaps[j].Start })
+
+	merged := make([]data.Gap, 0, len(gaps))
+	for _, g := range gaps {
+		if n := len(merged); n > 0 && g.Start <= merged[n-1].End {
+			merged[n-1].End = math.Max(merged[n-1].End, g.End)
+			continue
+		}
+		merged = append(merged, g)
+	}
+
+	for i, points := range channelPoints {
+		for _, g := range merged {
+			k := sort.Search(len(points), func(k int) bool { return points[k].X >= g.Start })
+			if k < len(points) && points[k].X < g.End {
+				return nil, fmt.Errorf("通道%s在间断[%g, %g)内有数据点", channels[i].ID, g.Start, g.End)
+			}
+		}
+	}
+	return merged, nil
+}
+
+// 按间断将数据分段并排成完整的数据记录，返回各信号的样本和每个数据记录的开始时间
+func segmentRecords(channelPoints [][]data.DataPoint, signalHeaders []SignalHeader, gaps []data.Gap, duration float64) ([][]float64, []float64, error) {
+	signals := make([][]float64, len(channelPoints))
+	var recordTimes []float64
+	next := make([]int, len(channelPoints)) // 每个通道下一个未处理的数据点
+
+	for seg := 0; seg <= len(gaps); seg++ {
+		segEnd := math.Inf(1)
+		if seg < len(gaps) {
+			segEnd = gaps[seg].Start
+		}
+
+		// 段内各通道的数据点范围及段的开始时间
+		ends := make([]int, len(channelPoints))
+		onset, records := math.Inf(1), 0
+		for i, points := range channelPoints {
+			ends[i] = next[i] + sort.Search(len(points)-next[i], func(k int) bool { return points[next[i]+k].X >= segEnd })
+			if n := ends[i] - next[i]; n > 0 {
+				onset = math.Min(onset, points[next[i]].X)
+				records = max(records, (n+signalHeaders[i].Samples-1)/signalHeaders[i].Samples)
+			}
+		}
+		if records == 0 {
+			continue
+		}
+
+		if n := len(recordTimes); n > 0 && onset < recordTimes[n-1]+duration-gapTolerance {
+			return nil, nil, fmt.Errorf("%g秒处的间断短于补齐数据记录所需的时长，请减小数据记录时长", onset)
+		}
+		for rec := 0; rec < records; rec++ {
+			recordTimes = append(recordTimes, onset+float64(rec)*duration)
+		}
+
+		for i, points := range channelPoints {
+			fill := 0.0
+			if n := len(signals[i]); n > 0 {
+				fill = signals[i][n-1]
+			}
+			for _, p := range points[next[i]:ends[i]] {
+				signals[i] = append(signals[i], p.Y)
+				fill = p.Y
+			}
+			for len(signals[i]) < len(recordTimes)*signalHeaders[i].Samples {
+				signals[i] = append(signals[i], fill)
+			}
+			next[i] = ends[i]
+		}
+	}
+
+	return signals, recordTimes, nil
+}
+
+// MarkersToAnnotations 将标记点转换为注释事件
+func MarkersToAnnotations(markers []*model.Marker) []Annotation {
+	annotations := make([]Annotation, 0, len(markers))
+
+	for _, m := range markers {
+		text := m.Label
+		if text == "" {
+			text = m.Type
+		}
+
+		annotations = append(annotations, Annotation{
+			Onset: m.Position,
+			Text:  text,
+		})
+	}
+
+	return annotations
+}
+
+// 根据数据和格式为信号选取物理范围和数字范围
+func fitSignalRange(sh *SignalHeader, samples []float64, bdf bool) error {
+	if sh.DigitalMin == sh.DigitalMax {
+		if bdf {
+			sh.DigitalMin, sh.DigitalMax = -8388608, 8388607
+		} else {
+			sh.DigitalMin, sh.DigitalMax = -32768, 32767
+		}
+	}
+
+	if sh.PhysicalMin == sh.PhysicalMax {
+		minValue, maxValue := math.Inf(1), math.Inf(-1)
+		for _, v := range samples {
+			minValue = math.Min(minValue, v)
+			maxValue = math.Max(maxValue, v)
+		}
+
+		if len(samples) == 0 {
+			minValue, maxValue = -1, 1
+		} else if minValue == maxValue {
+			minValue -= 1
+			maxValue += 1
+		}
+
+		sh.PhysicalMin = minValue
+		sh.PhysicalMax = maxValue
+	}
+
+	// 文件头中数值只有8个字符，量化必须使用写入后的数值
+	minField, err := formatHeaderFloat(sh.PhysicalMin, 8, false)
+	if err != nil {
+		return err
+	}
+	maxField, err := formatHeaderFloat(sh.PhysicalMax, 8, true)
+	if err != nil {
+		return err
+	}
+	sh.PhysicalMin, _ = strconv.ParseFloat(strings.TrimSpace(minField), 64)
+	sh.PhysicalMax, _ = strconv.ParseFloat(strings.TrimSpace(maxField), 64)
+	if sh.PhysicalMin >= sh.PhysicalMax {
+		return fmt.Errorf("物理范围[%s, %s]无法在文件头中表示", strings.TrimSpace(minField), strings.TrimSpace(maxField))
+	}
+	return nil
+}
+
+// 将物理值量化为数字值
+func quantize(sh SignalHeader, value float64) int32 {
+	scale := (sh.DigitalMax - sh.DigitalMin) / (sh.PhysicalMax - sh.PhysicalMin)
+	digital := math.Round((value-sh.PhysicalMin)*scale + sh.DigitalMin)
+	digital = math.Max(sh.DigitalMin, math.Min(sh.DigitalMax, digital))
+	return int32(digital)
+}
+
+// 将样本值编码为小端字节，长度由buf决定（2或3字节）
+func encodeSample(buf []byte, v int32) {
+	for i := range buf {
+		buf[i] = byte(v >> (8 * i))
+	}
+}
+
+// 为每个数据记录生成TAL字节，每个记录以计时注释开头，注释写入开始时间不晚于它的最后一个记录
+func buildTALRecords(annotations []Annotation, recordTimes []float64) [][]byte {
+	numRecords := len(recordTimes)
+	records := make([][]byte, numRecords)
+	if numRecords == 0 {
+		return records
+	}
+
+	for rec := range records {
+		records[rec] = append(records[rec], formatTALOnset(recordTimes[rec])...)
+		records[rec] = append(records[rec], talTextSep+talTextSep+"\x00"...)
+	}
+
+	for _, a := range annotations {
+		rec := sort.Search(numRecords, func(i int) bool { return recordTimes[i] > a.Onset }) - 1
+		if rec < 0 {
+			rec = 0
+		}
+
+		t := formatTALOnset(a.Onset)
+		if a.Duration > 0 {
+			t += talDurationSep + strconv.FormatFloat(a.Duration, 'f', -1, 64)
+		}
+		t += talTextSep + sanitizeTALText(a.Text) + talTextSep + "\x00"
+		records[rec] = append(records[rec], t...)
+	}
+
+	return records
+}
+
+// 判断数据记录是否首尾相接
+func isContinuous(recordTimes []float64, duration float64) bool {
+	for i := 1; i < len(recordTimes); i++ {
+		if math.Abs(recordTimes[i]-recordTimes[i-1]-duration) > gapTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// 格式化TAL开始时间，必须带符号且不能使用指数形式
+func formatTALOnset(onset float64) string {
+	if onset < 0 {
+		return strconv.FormatFloat(onset, 'f', -1, 64)
+	}
+	return "+" + strconv.FormatFloat(onset, 'f', -1, 64)
+}
+
+// 去除注释文本中的TAL控制字符
+func sanitizeTALText(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r == 0x00 || r == 0x14 || r == 0x15 {
+			return ' '
+		}
+		return r
+	}, text)
+}
+
+// 写出文件头
+func writeHeader(bw *bufio.Writer, header EDFHeader) error {
+	duration, err := formatHeaderFloat(header.Duration, 8, false)
+	if err != nil {
+		return fmt.Errorf("数据记录时长: %w", err)
+	}
+
+	fields := []string{
+		headerString(header.Version, 8),
+		headerString(header.PatientID, 80),
+		headerString(header.RecordingID, 80),
+		formatStartDate(header.StartTime),
+		header.StartTime.Format("15.04.05"),
+		headerString(strconv.Itoa(header.HeaderBytes), 8),
+		headerString(header.Reserved, 44),
+		headerString(strconv.Itoa(header.DataRecords), 8),
+		duration,
+		headerString(strconv.Itoa(header.NumSignals), 4),
+	}
+
+	// 信号头按字段类型依次写出所有信号
+	text := func(f func(sh SignalHeader) string, length int) func(sh SignalHeader) (string, error) {
+		return func(sh SignalHeader) (string, error) { return headerString(f(sh), length), nil }
+	}
+	number := func(f func(sh SignalHeader) float64, roundUp bool) func(sh SignalHeader) (string, error) {
+		return func(sh SignalHeader) (string, error) { return formatHeaderFloat(f(sh), 8, roundUp) }
+	}
+	signalFields := []func(sh SignalHeader) (string, error){
+		text(func(sh SignalHeader) string { return sh.Label }, 16),
+		text(func(sh SignalHeader) string { return sh.Transducer }, 80),
+		text(func(sh SignalHeader) string { return sh.PhysicalDim }, 8),
+		number(func(sh SignalHeader) float64 { return sh.PhysicalMin }, false),
+		number(func(sh SignalHeader) float64 { return sh.PhysicalMax }, true),
+		number(func(sh SignalHeader) float64 { return sh.DigitalMin }, false),
+		number(func(sh SignalHeader) float64 { return sh.DigitalMax }, true),
+		text(func(sh SignalHeader) string { return sh.Prefiltering }, 80),
+		text(func(sh SignalHeader) string { return strconv.Itoa(sh.Samples) }, 8),
+		text(func(sh SignalHeader) string { return sh.Reserved }, 32),
+	}
+	for _, field := range signalFields {
+		for i, sh := range header.SignalHeaders {
+			f, err := field(sh)
+			if err != nil {
+				return fmt.Errorf("信号%d: %w", i, err)
+			}
+			fields = append(fields, f)
+		}
+	}
+
+	for _, f := range fields {
+		if _, err := bw.WriteString(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 将字符串转换为指定长度的ASCII字段，不足补空格，超出截断
+func headerString(s string, length int) string {
+	if !strings.HasPrefix(s, bdfVersion) {
+		s = strings.Map(func(r rune) rune {
+			if r < 32 || r > 126 {
+				return '_'
+			}
+			return r
+		}, s)
+	}
+
+	if len(s) > length {
+		return s[:length]
+	}
+	return s + strings.Repeat(" ", length-len(s))
+}
+
+// 将浮点数格式化为不超过指定长度的字段，roundUp决定精度不足时的取整方向
+// 依次尝试定点形式和指数形式（如"1e9"、"-2.5e-7"），取与原值最接近的一种，都放不下时返回错误。
+func formatHeaderFloat(v float64, length int, roundUp bool) (string, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return "", fmt.Errorf("无效的数值: %g", v)
+	}
+
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	for decimals := length; len(s) > length && decimals >= 0; decimals-- {
+		p := math.Pow(10, float64(decimals))
+		rounded := math.Floor(v*p) / p
+		if roundUp {
+			rounded = math.Ceil(v*p) / p
+		}
+		s = strconv.FormatFloat(rounded, 'f', decimals, 64)
+		if strings.Contains(s, ".") {
+			s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+		}
+	}
+
+	exact := func(s string) bool {
+		f, err := strconv.ParseFloat(s, 64)
+		return err == nil && f == v
+	}
+	if len(s) > length || !exact(s) {
+		if e := formatHeaderExp(v, length, roundUp); e != "" {
+			if len(s) > length || headerFloatError(e, v) < headerFloatError(s, v) {
+				s = e
+			}
+		}
+	}
+	if len(s) > length {
+		return "", fmt.Errorf("数值%g无法写入%d个字符的文件头字段", v, length)
+	}
+	return headerString(s, length), nil
+}
+
+// 将非零浮点数按指定方向取整为不超过length个字符的指数形式，放不下时返回空字符串
+func formatHeaderExp(v float64, length int, roundUp bool) string {
+	if v == 0 {
+		return ""
+	}
+
+	exp := math.Floor(math.Log10(math.Abs(v)))
+	for digits := length; digits >= 0; digits-- {
+		scale := math.Pow(10, exp-float64(digits))
+		mantissa := math.Floor(v / scale)
+		if roundUp {
+			mantissa = math.Ceil(v / scale)
+		}
+
+		// 尾数去掉多余的零，指数去掉"+"和前导零
+		s := strconv.FormatFloat(mantissa*scale, 'e', digits, 64)
+		m, e, _ := strings.Cut(s, "e")
+		if strings.Contains(m, ".") {
+			m = strings.TrimRight(strings.TrimRight(m, "0"), ".")
+		}
+		n, _ := strconv.Atoi(e)
+		s = m + "e" + strconv.Itoa(n)
+
+		if len(s) <= length {
+			return s
+		}
+	}
+	return ""
+}
+
+// 返回字段文本表示的数值与v之差的绝对值
+func headerFloatError(s string, v float64) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return math.Inf(1)
+	}
+	return math.Abs(f - v)
+}
@@ -0,0 +1,294 @@
+package fileio
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+// 生成采样率为rate、时长为duration秒的正弦通道，第一个点的时间为start
+func sineChannel(id string, rate, start, duration float64) *data.Channel {
+	channel := data.NewChannel(id, "Signal "+id)
+	channel.SampleRate = rate
+	n := int(math.Round(duration * rate))
+	for i := 0; i < n; i++ {
+		t := float64(i) / rate
+		channel.AddDataPoint(start+t, 3.3*math.Sin(2*math.Pi*1.7*t)+0.5)
+	}
+	return channel
+}
+
+// 写出通道并重新打开
+func writeAndOpen(t *testing.T, channels []*data.Channel, opts EDFWriteOptions) *EDFReader {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "out.edf")
+	w, err := CreateEDF(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteChannels(channels, opts); err != nil {
+		w.Close()
+		t.Fatalf("WriteChannels: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return openTestEDF(t, path)
+}
+
+// 比较读回的通道与原通道，误差不超过量化步长的一半
+func assertChannelRoundTrip(t *testing.T, r *EDFReader, signalIndex int, want *data.Channel, offset float64) {
+	t.Helper()
+	got := data.NewChannel("", "")
+	if err := r.LoadSignalToChannel(signalIndex, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.SampleRate != want.SampleRate {
+		t.Errorf("信号%d的采样率 = %g, want %g", signalIndex, got.SampleRate, want.SampleRate)
+	}
+
+	sh := r.GetHeader().SignalHeaders[signalIndex]
+	step := (sh.PhysicalMax - sh.PhysicalMin) / (sh.DigitalMax - sh.DigitalMin)
+	if len(got.Data) < len(want.Data) {
+		t.Fatalf("信号%d读回%d个样本，少于写出的%d个", signalIndex, len(got.Data), len(want.Data))
+	}
+	for i, p := range want.Data {
+		if math.Abs(got.Data[i].Y-p.Y) > step/2+1e-12 {
+			t.Fatalf("信号%d样本%d = %g, want %g（量化步长%g）", signalIndex, i, got.Data[i].Y, p.Y, step)
+		}
+		if math.Abs(got.Data[i].X+offset-p.X) > 1e-9 {
+			t.Fatalf("信号%d样本%d的时间 = %g, want %g", signalIndex, i, got.Data[i].X+offset, p.X)
+		}
+	}
+}
+
+func TestWriteChannelsRoundTrip(t *testing.T) {
+	for _, bdf := range []bool{false, true} {
+		t.Run(map[bool]string{false: "EDF", true: "BDF"}[bdf], func(t *testing.T) {
+			ecg := sineChannel("1", 256, 2, 10.1)
+			resp := sineChannel("2", 10, 2, 6)
+			start := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+
+			r := writeAndOpen(t, []*data.Channel{ecg, resp}, EDFWriteOptions{
+				StartTime:    start,
+				BDF:          bdf,
+				PhysicalDims: map[string]string{"1": "mV"},
+				Annotations: []Annotation{
+					{Onset: 4.5, Duration: 1, Text: "Event"},
+					{Onset: 9, Text: "R\x14peak"},
+				},
+			})
+
+			h := r.GetHeader()
+			if h.IsBDF() != bdf {
+				t.Errorf("IsBDF = %v, want %v", h.IsBDF(), bdf)
+			}
+			wantReserved := map[bool]string{false: "EDF+C", true: "BDF+C"}[bdf]
+			if h.Reserved != wantReserved || h.IsDiscontinuous() {
+				t.Errorf("Reserved = %q, want %q", h.Reserved, wantReserved)
+			}
+			// 开始时间顺延到第一个点
+			if want := start.Add(2 * time.Second); !h.StartTime.Equal(want) {
+				t.Errorf("StartTime = %v, want %v", h.StartTime, want)
+			}
+			if h.DataRecords != 11 || h.NumSignals != 3 {
+				t.Errorf("DataRecords = %d, NumSignals = %d, want 11, 3", h.DataRecords, h.NumSignals)
+			}
+			if h.SignalHeaders[0].PhysicalDim != "mV" || h.SignalHeaders[0].Label != "Signal 1" {
+				t.Errorf("信号头 = %+v", h.SignalHeaders[0])
+			}
+
+			assertChannelRoundTrip(t, r, 0, ecg, 2)
+			assertChannelRoundTrip(t, r, 1, resp, 2)
+
+			a, err := r.ReadAnnotations()
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []Annotation{{Onset: 2.5, Duration: 1, Text: "Event"}, {Onset: 7, Text: "R peak"}}
+			if !reflect.DeepEqual(a, want) {
+				t.Errorf("ReadAnnotations = %+v, want %+v", a, want)
+			}
+		})
+	}
+}
+
+func TestWriteChannelsWithoutAnnotations(t *testing.T) {
+	r := writeAndOpen(t, []*data.Channel{sineChannel("1", 100, 0, 3)}, EDFWriteOptions{RecordDuration: 0.5})
+	h := r.GetHeader()
+	if h.IsEDFPlus() || h.NumSignals != 1 || h.DataRecords != 6 || h.Duration != 0.5 {
+		t.Errorf("header = %+v", h)
+	}
+}
+
+func TestWriteChannelsGaps(t *testing.T) {
+	// 两段数据：[0, 2.5)和[5, 7)，间断为[2.5, 5)
+	ecg := sineChannel("1", 100, 0, 2.5)
+	second := sineChannel("1", 100, 5, 2)
+	ecg.Data = append(ecg.Data, second.Data...)
+	ecg.Gaps = []data.Gap{{Start: 2.5, End: 5}, {Start: 20, End: 30}}
+
+	resp := sineChannel("2", 4, 0, 2.5)
+	resp.Data = append(resp.Data, sineChannel("2", 4, 5, 2).Data...)
+	resp.Gaps = []data.Gap{{Start: 2.5, End: 5}}
+
+	r := writeAndOpen(t, []*data.Channel{ecg, resp}, EDFWriteOptions{
+		Annotations: []Annotation{{Onset: 1, Text: "a"}, {Onset: 5.5, Text: "b"}},
+	})
+
+	h := r.GetHeader()
+	if h.Reserved != "EDF+D" {
+		t.Fatalf("Reserved = %q, want EDF+D", h.Reserved)
+	}
+	times, err := r.RecordStartTimes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 第一段的最后一个记录补齐到3秒
+	if want := []float64{0, 1, 2, 5, 6}; !reflect.DeepEqual(times, want) {
+		t.Errorf("RecordStartTimes = %v, want %v", times, want)
+	}
+	gaps, err := r.Gaps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []data.Gap{{Start: 3, End: 5}}; !reflect.DeepEqual(gaps, want) {
+		t.Errorf("Gaps = %v, want %v", gaps, want)
+	}
+
+	got := data.NewChannel("", "")
+	if err := r.LoadSignalToChannel(0, got); err != nil {
+		t.Fatal(err)
+	}
+	// 间断后的第一个样本位于5秒
+	if p := got.Data[300]; math.Abs(p.X-5) > 1e-9 || math.Abs(p.Y-ecg.Data[250].Y) > 1e-3 {
+		t.Errorf("间断后的第一个样本 = %+v, want X=5 Y=%g", p, ecg.Data[250].Y)
+	}
+
+	a, err := r.ReadAnnotations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Annotation{{Onset: 1, Text: "a"}, {Onset: 5.5, Text: "b"}}; !reflect.DeepEqual(a, want) {
+		t.Errorf("ReadAnnotations = %+v, want %+v", a, want)
+	}
+}
+
+func TestWriteChannelsErrors(t *testing.T) {
+	noRate := sineChannel("1", 100, 0, 1)
+	noRate.SampleRate = 0
+
+	withNaN := sineChannel("1", 100, 0, 1)
+	withNaN.Data[10].Y = math.NaN()
+
+	inGap := sineChannel("1", 100, 0, 3)
+	inGap.Gaps = []data.Gap{{Start: 1, End: 2}}
+
+	shortGap := sineChannel("1", 100, 0, 2.5)
+	shortGap.Data = append(shortGap.Data, sineChannel("1", 100, 2.7, 1).Data...)
+	shortGap.Gaps = []data.Gap{{Start: 2.5, End: 2.7}}
+
+	tests := []struct {
+		name     string
+		channels []*data.Channel
+		want     string
+	}{
+		{"没有通道", nil, "没有可导出的通道"},
+		{"采样率未知", []*data.Channel{noRate}, "采样率未知"},
+		{"样本为NaN", []*data.Channel{withNaN}, "NaN"},
+		{"间断内有数据", []*data.Channel{inGap}, "间断"},
+		{"间断过短", []*data.Channel{shortGap}, "补齐"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := CreateEDF(filepath.Join(t.TempDir(), "out.edf"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+
+			err = w.WriteChannels(tt.channels, EDFWriteOptions{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("WriteChannels error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatHeaderFloat(t *testing.T) {
+	tests := []struct {
+		v       float64
+		roundUp bool
+		want    string
+	}{
+		{0, false, "0"},
+		{-3276.8, false, "-3276.8"},
+		{12345678, true, "12345678"},
+		{-8388608, false, "-8388608"},
+		{1.0 / 3, false, "0.333333"},
+		{1.0 / 3, true, "0.333334"},
+		{1e9, true, "1e9"},
+		{-1e9, false, "-1e9"},
+		{123456789, false, "1.2345e8"},
+		{123456789, true, "1.2346e8"},
+		{2.5e-7, false, "2.5e-7"},
+		{-1.25e300, false, "-1.3e300"},
+	}
+	for _, tt := range tests {
+		got, err := formatHeaderFloat(tt.v, 8, tt.roundUp)
+		if err != nil {
+			t.Errorf("formatHeaderFloat(%g, %v): %v", tt.v, tt.roundUp, err)
+			continue
+		}
+		if len(got) != 8 || strings.TrimSpace(got) != tt.want {
+			t.Errorf("formatHeaderFloat(%g, %v) = %q, want %q", tt.v, tt.roundUp, got, tt.want)
+		}
+
+		// 取整方向必须保证物理范围覆盖原值
+		parsed, _ := strconv.ParseFloat(strings.TrimSpace(got), 64)
+		if (tt.roundUp && parsed < tt.v) || (!tt.roundUp && parsed > tt.v) {
+			t.Errorf("formatHeaderFloat(%g, %v) = %q，取整方向错误", tt.v, tt.roundUp, got)
+		}
+	}
+
+	for _, v := range []float64{math.NaN(), math.Inf(1)} {
+		if _, err := formatHeaderFloat(v, 8, false); err == nil {
+			t.Errorf("formatHeaderFloat(%g)应返回错误", v)
+		}
+	}
+	if _, err := formatHeaderFloat(-1.25e300, 4, false); err == nil {
+		t.Error("放不下的数值应返回错误")
+	}
+}
+
+func TestWriteSignalsLargePhysicalRange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.edf")
+	w, err := CreateEDF(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := EDFHeader{Version: "0", Duration: 1, SignalHeaders: []SignalHeader{{Label: "P", Samples: 2}}}
+	if err := w.WriteSignals(header, [][]float64{{-2e9, 1e9, 3e9, 0}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	r := openTestEDF(t, path)
+	sh := r.GetHeader().SignalHeaders[0]
+	if sh.PhysicalMin != -2e9 || sh.PhysicalMax != 3e9 {
+		t.Errorf("物理范围 = [%g, %g], want [-2e9, 3e9]", sh.PhysicalMin, sh.PhysicalMax)
+	}
+	values, err := r.ReadSignalData(0, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.ConvertToPhysical(0, values[2]); math.Abs(got-3e9) > 1e5 {
+		t.Errorf("最大值读回为%g", got)
+	}
+}
@@ -0,0 +1,176 @@
+package fileio
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testSignal 描述测试文件中的一个信号头
+type testSignal struct {
+	Label            string
+	Dim              string
+	PhysMin, PhysMax float64
+	DigMin, DigMax   float64
+	Samples          int
+}
+
+// testEDF 描述一个逐字节构造的EDF/BDF测试文件，字符串字段为空时使用默认值
+type testEDF struct {
+	Version     string // 默认"0"
+	PatientID   string
+	RecordingID string
+	StartDate   string // 默认"02.03.02"
+	StartTime   string // 默认"10.11.12"
+	HeaderBytes string // 默认256*(信号数+1)
+	Reserved    string
+	DataRecords string // 默认len(Records)
+	Duration    string // 默认"1"
+	NumSignals  string // 默认len(Signals)
+	Signals     []testSignal
+	Records     [][]byte // 每个数据记录的原始字节
+}
+
+// 数字值为物理值10倍的16位信号，物理值与数字值的换算没有舍入误差
+func int16Signal(label string, samples int) testSignal {
+	return testSignal{Label: label, Dim: "uV", PhysMin: -3276.8, PhysMax: 3276.7, DigMin: -32768, DigMax: 32767, Samples: samples}
+}
+
+// 容纳samples个16位样本的EDF+注释信号
+func annotationSignal(samples int) testSignal {
+	return testSignal{Label: edfAnnotationsLabel, PhysMin: -1, PhysMax: 1, DigMin: -32768, DigMax: 32767, Samples: samples}
+}
+
+// 按小端16位编码样本
+func int16Samples(values ...int) []byte {
+	b := make([]byte, 0, 2*len(values))
+	for _, v := range values {
+		b = append(b, byte(v), byte(v>>8))
+	}
+	return b
+}
+
+// 按小端24位编码样本
+func int24Samples(values ...int) []byte {
+	b := make([]byte, 0, 3*len(values))
+	for _, v := range values {
+		b = append(b, byte(v), byte(v>>8), byte(v>>16))
+	}
+	return b
+}
+
+// 将若干TAL依次写入size字节的注释信号，每个TAL后补结束符，剩余部分补零
+func talBytes(size int, tals ...string) []byte {
+	b := make([]byte, 0, size)
+	for _, t := range tals {
+		b = append(b, t...)
+		b = append(b, talEnd)
+	}
+	if len(b) > size {
+		panic(fmt.Sprintf("TAL长度%d超过注释信号的%d字节", len(b), size))
+	}
+	return append(b, make([]byte, size-len(b))...)
+}
+
+// 拼接一个数据记录中各信号的字节
+func record(parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+// 生成文件的全部字节
+func (e testEDF) bytes() []byte {
+	or := func(s, def string) string {
+		if s == "" {
+			return def
+		}
+		return s
+	}
+	field := func(s string, n int) string {
+		return (s + strings.Repeat(" ", n))[:n]
+	}
+	num := func(v float64) string {
+		return field(strconv.FormatFloat(v, 'f', -1, 64), 8)
+	}
+
+	var b strings.Builder
+	b.WriteString(field(or(e.Version, "0"), 8))
+	b.WriteString(field(e.PatientID, 80))
+	b.WriteString(field(e.RecordingID, 80))
+	b.WriteString(field(or(e.StartDate, "02.03.02"), 8))
+	b.WriteString(field(or(e.StartTime, "10.11.12"), 8))
+	b.WriteString(field(or(e.HeaderBytes, fmt.Sprint(256*(len(e.Signals)+1))), 8))
+	b.WriteString(field(e.Reserved, 44))
+	b.WriteString(field(or(e.DataRecords, fmt.Sprint(len(e.Records))), 8))
+	b.WriteString(field(or(e.Duration, "1"), 8))
+	b.WriteString(field(or(e.NumSignals, fmt.Sprint(len(e.Signals))), 4))
+
+	for _, s := range e.Signals {
+		b.WriteString(field(s.Label, 16))
+	}
+	for range e.Signals {
+		b.WriteString(field("", 80))
+	}
+	for _, s := range e.Signals {
+		b.WriteString(field(s.Dim, 8))
+	}
+	for _, s := range e.Signals {
+		b.WriteString(num(s.PhysMin))
+	}
+	for _, s := range e.Signals {
+		b.WriteString(num(s.PhysMax))
+	}
+	for _, s := range e.Signals {
+		b.WriteString(num(s.DigMin))
+	}
+	for _, s := range e.Signals {
+		b.WriteString(num(s.DigMax))
+	}
+	for range e.Signals {
+		b.WriteString(field("", 80))
+	}
+	for _, s := range e.Signals {
+		b.WriteString(field(fmt.Sprint(s.Samples), 8))
+	}
+	for range e.Signals {
+		b.WriteString(field("", 32))
+	}
+
+	for _, r := range e.Records {
+		b.Write(r)
+	}
+	return []byte(b.String())
+}
+
+// 将文件写入测试临时目录并返回路径
+func (e testEDF) write(t testing.TB) string {
+	t.Helper()
+	return writeTestFile(t, "test.edf", e.bytes())
+}
+
+// 将内容写入测试临时目录中的文件并返回路径
+func writeTestFile(t testing.TB, name string, content []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// 打开测试文件，失败时终止测试
+func openTestEDF(t testing.TB, path string) *EDFReader {
+	t.Helper()
+	r, err := OpenEDF(path)
+	if err != nil {
+		t.Fatalf("OpenEDF: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
@@ -0,0 +1,62 @@
+package fileio
+
+import (
+	"container/list"
+	"sync"
+)
+
+// recordCache 是已解码数据记录的LRU缓存，可被多个goroutine并发使用
+type recordCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[int]*list.Element
+	order    *list.List // 最近使用的记录在前
+}
+
+// 缓存中的一个数据记录
+type cachedRecord struct {
+	index   int
+	samples [][]int32
+}
+
+// 创建一个最多保存capacity个数据记录的缓存
+func newRecordCache(capacity int) *recordCache {
+	return &recordCache{
+		capacity: capacity,
+		entries:  make(map[int]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// 获取缓存的数据记录
+func (c *recordCache) get(index int) ([][]int32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[index]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cachedRecord).samples, true
+}
+
+// 添加数据记录，超出容量时淘汰最久未使用的记录
+func (c *recordCache) put(index int, samples [][]int32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[index]; ok {
+		elem.Value.(*cachedRecord).samples = samples
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[index] = c.order.PushFront(&cachedRecord{index: index, samples: samples})
+
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedRecord).index)
+	}
+}
@@ -0,0 +1,173 @@
+package fileio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ljx520ljx/chartSystem/internal/model"
+)
+
+// MIT格式注释文件中的特殊代码
+const (
+	wfdbSkip = 59 // 后跟4字节的时间间隔
+	wfdbNum  = 60 // 设置注释的num字段
+	wfdbSub  = 61 // 设置注释的subtyp字段
+	wfdbChn  = 62 // 设置注释的chan字段
+	wfdbAux  = 63 // 后跟长度为I的附加文本
+)
+
+// WFDB注释代码对应的助记符
+var wfdbAnnotationSymbols = map[int]string{
+	1: "N", 2: "L", 3: "R", 4: "a", 5: "V", 6: "F", 7: "J", 8: "A", 9: "S", 10: "E",
+	11: "j", 12: "/", 13: "Q", 14: "~", 16: "|", 18: "s", 19: "T", 20: "*", 21: "D", 22: "\"",
+	23: "=", 24: "p", 25: "B", 26: "^", 27: "t", 28: "+", 29: "u", 30: "?", 31: "!", 32: "[",
+	33: "]", 34: "e", 35: "n", 36: "@", 37: "x", 38: "f", 39: "(", 40: ")", 41: "r",
+}
+
+// 表示心搏的注释代码
+var wfdbBeatCodes = map[int]bool{
+	1: true, 2: true, 3: true, 4: true, 5: true, 6: true, 7: true, 8: true, 9: true, 10: true,
+	11: true, 12: true, 13: true, 25: true, 30: true, 34: true, 35: true, 38: true, 41: true,
+}
+
+// WFDBAnnotation 表示WFDB注释文件中的一条注释
+type WFDBAnnotation struct {
+	Sample  int64   // 注释位置（帧序号）
+	Time    float64 // 注释时间（秒）
+	Code    int     // 注释代码，如1表示正常心搏
+	Symbol  string  // 注释助记符，如"N"、"V"
+	SubType int
+	Chan    int
+	Num     int
+	Aux     string // 附加文本，节律注释中为"(AFIB"等节律名称
+}
+
+// IsBeat 判断注释是否为心搏注释
+func (a WFDBAnnotation) IsBeat() bool {
+	return wfdbBeatCodes[a.Code]
+}
+
+// ReadAnnotations 读取记录的注释文件，annotator为注释文件扩展名，如"atr"
+func (r *WFDBReader) ReadAnnotations(annotator string) ([]WFDBAnnotation, error) {
+	raw, err := os.ReadFile(filepath.Join(r.dir, r.header.RecordName+"."+annotator))
+	if err != nil {
+		return nil, err
+	}
+
+	annotations, err := parseMITAnnotations(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range annotations {
+		annotations[i].Time = float64(annotations[i].Sample) / r.header.FrameFrequency
+	}
+	return annotations, nil
+}
+
+// 解析MIT格式的注释数据
+// 每条注释为一个小端16位字，高6位为代码A，低10位为I：普通注释的I为距上一条注释的样本数，
+// 特殊代码的I为修饰值。修饰代码（NUM、SUB、CHN、AUX）作用于其前面的注释。
+func parseMITAnnotations(raw []byte) ([]WFDBAnnotation, error) {
+	annotations := make([]WFDBAnnotation, 0)
+	var sample int64
+	chn, num := 0, 0
+
+	for pos := 0; pos+2 <= len(raw); {
+		word := binary.LittleEndian.Uint16(raw[pos:])
+		pos += 2
+		code := int(word >> 10)
+		value := int(word & 0x3ff)
+
+		last := len(annotations) - 1
+		switch code {
+		case 0:
+			// 全0字为文件结束，但文件开头的时间分辨率注释也以0开头并后跟AUX
+			if value == 0 && (pos+2 > len(raw) || binary.LittleEndian.Uint16(raw[pos:])>>10 != wfdbAux) {
+				return annotations, nil
+			}
+			sample += int64(value)
+			annotations = append(annotations, WFDBAnnotation{Sample: sample, Chan: chn, Num: num})
+		case wfdbSkip:
+			if pos+4 > len(raw) {
+				return nil, fmt.Errorf("注释文件在SKIP处截断")
+			}
+			// PDP-11长整型：高16位在前
+			interval := int32(uint32(binary.LittleEndian.Uint16(raw[pos:]))<<16 | uint32(binary.LittleEndian.Uint16(raw[pos+2:])))
+			sample += int64(interval)
+			pos += 4
+		case wfdbNum, wfdbSub, wfdbChn, wfdbAux:
+			if last < 0 {
+				return nil, fmt.Errorf("注释文件的修饰代码%d之前没有注释", code)
+			}
+			a := &annotations[last]
+			switch code {
+			case wfdbNum:
+				a.Num = int(int16(value<<6) >> 6)
+				num = a.Num
+			case wfdbSub:
+				a.SubType = int(int16(value<<6) >> 6)
+			case wfdbChn:
+				a.Chan = value
+				chn = a.Chan
+			case wfdbAux:
+				if pos+value > len(raw) {
+					return nil, fmt.Errorf("注释文件在附加文本处截断")
+				}
+				a.Aux = strings.TrimRight(string(raw[pos:pos+value]), "\x00")
+				// 附加文本按偶数字节对齐
+				pos += value + value%2
+			}
+		default:
+			sample += int64(value)
+			annotations = append(annotations, WFDBAnnotation{
+				Sample: sample,
+				Code:   code,
+				Symbol: wfdbAnnotationSymbols[code],
+				Chan:   chn,
+				Num:    num,
+			})
+		}
+	}
+
+	return annotations, nil
+}
+
+// WFDBAnnotationsToMarkers 将WFDB注释转换为标记点
+// 心搏注释的类型为"beat"，标签为助记符；其他注释的类型为"annotation"，有附加文本时以其为标签。
+func WFDBAnnotationsToMarkers(annotations []WFDBAnnotation, fileID, channelID, createdBy uint) []*model.Marker {
+	markers := make([]*model.Marker, 0, len(annotations))
+
+	for _, a := range annotations {
+		// 代码为0的注释只用于存放文件级信息
+		if a.Code == 0 {
+			continue
+		}
+
+		marker := &model.Marker{
+			FileID:      fileID,
+			ChannelID:   channelID,
+			Position:    a.Time,
+			Type:        "annotation",
+			Label:       a.Symbol,
+			Description: a.Aux,
+			CreatedBy:   createdBy,
+		}
+		if a.IsBeat() {
+			marker.Type = "beat"
+		} else if a.Aux != "" {
+			marker.Label = a.Aux
+			marker.Description = a.Symbol
+		}
+		if len([]rune(marker.Label)) > 200 {
+			marker.Label = string([]rune(marker.Label)[:200])
+		}
+
+		markers = append(markers, marker)
+	}
+
+	return markers
+}
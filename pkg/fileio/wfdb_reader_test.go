@@ -0,0 +1,376 @@
+package fileio
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+// 按格式212打包12位样本，每两个样本占3字节
+func pack212(values ...int) []byte {
+	if len(values)%2 != 0 {
+		values = append(values, 0)
+	}
+	b := make([]byte, 0, len(values)/2*3)
+	for i := 0; i < len(values); i += 2 {
+		v0, v1 := values[i]&0xfff, values[i+1]&0xfff
+		b = append(b, byte(v0), byte(v0>>8)|byte(v1>>8)<<4, byte(v1))
+	}
+	return b
+}
+
+// 将文件写入同一目录，返回目录
+func writeRecordFiles(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// MIT格式注释的一个16位字
+func mitWord(code, value int) []byte {
+	return binary.LittleEndian.AppendUint16(nil, uint16(code<<10|value&0x3ff))
+}
+
+// 两个格式212信号交错存储的记录，信号0有显式基线，信号1的基线等于ADC零点
+var (
+	wfdbSignal0 = []int{995, 1000, 1024, 1224, -2048, 2047}
+	wfdbSignal1 = []int{1011, -5, -2048, 1024, 100, 2047}
+)
+
+const wfdbTestHeader = `# 测试记录
+rec 2 360 6 10:20:30 01/02/2003
+rec.dat 212 200(1024)/mV 11 1024 995 0 0 MLII
+rec.dat 212 100 11 24 1011 0 0 V5
+# 信号行之后的注释
+`
+
+func wfdbTestRecord(t *testing.T) string {
+	var stream []int
+	for i := range wfdbSignal0 {
+		stream = append(stream, wfdbSignal0[i], wfdbSignal1[i])
+	}
+	dir := writeRecordFiles(t, map[string][]byte{
+		"rec.hea": []byte(wfdbTestHeader),
+		"rec.dat": pack212(stream...),
+	})
+	return filepath.Join(dir, "rec.hea")
+}
+
+func TestParseWFDBHeader(t *testing.T) {
+	header, err := ParseWFDBHeader(strings.NewReader(wfdbTestHeader))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.RecordName != "rec" || header.NumSignals != 2 || header.FrameFrequency != 360 || header.NumFrames != 6 {
+		t.Errorf("记录行 = %+v", header)
+	}
+	if want := time.Date(2003, 2, 1, 10, 20, 30, 0, time.UTC); !header.StartTime.Equal(want) {
+		t.Errorf("StartTime = %v, want %v", header.StartTime, want)
+	}
+
+	want := []WFDBSignal{
+		{FileName: "rec.dat", Format: 212, SamplesPerFrame: 1, Gain: 200, Baseline: 1024, Units: "mV", ADCResolution: 11, ADCZero: 1024, InitialValue: 995, Description: "MLII"},
+		{FileName: "rec.dat", Format: 212, SamplesPerFrame: 1, Gain: 100, Baseline: 24, Units: "mV", ADCResolution: 11, ADCZero: 24, InitialValue: 1011, Description: "V5"},
+	}
+	if !reflect.DeepEqual(header.Signals, want) {
+		t.Errorf("Signals = %+v, want %+v", header.Signals, want)
+	}
+}
+
+func TestParseWFDBSignalLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want WFDBSignal
+	}{
+		{"a.dat 16", WFDBSignal{FileName: "a.dat", Format: 16, SamplesPerFrame: 1, Gain: wfdbDefaultGain, Units: "mV", ADCResolution: 16}},
+		{"a.dat 16x4:2+512 0(-3)/uV", WFDBSignal{FileName: "a.dat", Format: 16, SamplesPerFrame: 4, ByteOffset: 512, Gain: wfdbDefaultGain, Baseline: -3, Units: "uV", ADCResolution: 16}},
+		{"a.dat 80 50 8 0 0 0 0 Resp chest", WFDBSignal{FileName: "a.dat", Format: 80, SamplesPerFrame: 1, Gain: 50, Units: "mV", ADCResolution: 8, Description: "Resp chest"}},
+		{"a.dat 61 1000/mmHg", WFDBSignal{FileName: "a.dat", Format: 61, SamplesPerFrame: 1, Gain: 1000, Units: "mmHg", ADCResolution: 16}},
+	}
+	for _, tt := range tests {
+		got, err := parseWFDBSignalLine(tt.line)
+		if err != nil {
+			t.Errorf("parseWFDBSignalLine(%q): %v", tt.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseWFDBSignalLine(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseWFDBHeaderErrors(t *testing.T) {
+	tests := map[string]string{
+		"缺少记录行":   "# 只有注释\n",
+		"信号行不足":   "rec 2 360\nrec.dat 212\n",
+		"多段记录":    "rec/2 1 360\n",
+		"无效的采样频率": "rec 1 abc\nrec.dat 16\n",
+		"不支持的格式":  "rec 1 360\nrec.dat 310\n",
+		"无效的基线":   "rec 1 360\nrec.dat 16 200(x)\n",
+		"每帧样本数为0": "rec 1 360\nrec.dat 16x0\n",
+		"无效的基准日期": "rec 1 360 10 10:00:00 2003-02-01\nrec.dat 16\n",
+		"标准输入":    "rec 1 360\n- 16\n",
+	}
+	for name, content := range tests {
+		if _, err := ParseWFDBHeader(strings.NewReader(content)); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+}
+
+func TestReadWFDB212(t *testing.T) {
+	r, err := OpenWFDB(strings.TrimSuffix(wfdbTestRecord(t), ".hea"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	for k, want := range [][]int{wfdbSignal0, wfdbSignal1} {
+		values, err := r.ReadSignalData(k, 0, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]int, len(values))
+		for i, v := range values {
+			got[i] = int(v)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("信号%d = %v, want %v", k, got, want)
+		}
+	}
+
+	// 从奇数样本开始读取
+	values, err := r.ReadSignalData(0, 3, 2)
+	if err != nil || !reflect.DeepEqual(values, []int32{1224, -2048}) {
+		t.Errorf("ReadSignalData(0, 3, 2) = %v, %v", values, err)
+	}
+
+	name, unit, physMin, physMax := r.GetChannelInfo(1)
+	if name != "V5" || unit != "mV" || physMin != (24-1024-24)/100.0 || physMax != (24+1023-24)/100.0 {
+		t.Errorf("GetChannelInfo = %s, %s, %g, %g", name, unit, physMin, physMax)
+	}
+	if d := r.Duration(); math.Abs(d-6.0/360) > 1e-12 {
+		t.Errorf("Duration = %g", d)
+	}
+
+	// 物理值为(ADC值-基线)/增益，无效样本-2048被跳过
+	channel := data.NewChannel("0", "MLII")
+	if err := r.LoadSignalToChannel(0, channel); err != nil {
+		t.Fatal(err)
+	}
+	if len(channel.Data) != 5 || channel.SampleRate != 360 || !channel.StartTime.Equal(r.GetHeader().StartTime) {
+		t.Fatalf("通道有%d个点，采样率%g", len(channel.Data), channel.SampleRate)
+	}
+	for j, i := range []int{0, 1, 2, 3, 5} {
+		p := channel.Data[j]
+		if want := float64(wfdbSignal0[i]-1024) / 200; math.Abs(p.Y-want) > 1e-12 || math.Abs(p.X-float64(i)/360) > 1e-12 {
+			t.Errorf("样本%d = (%g, %g), want (%g, %g)", i, p.X, p.Y, float64(i)/360, want)
+		}
+	}
+
+	points, err := r.ReadWindowPoints(1, 2.0/360, 4.0/360)
+	if err != nil || len(points) != 1 || points[0].X != 3.0/360 || points[0].Y != (1024-24)/100.0 {
+		t.Errorf("ReadWindowPoints = %v, %v", points, err)
+	}
+}
+
+func TestReadWFDB212OddSamples(t *testing.T) {
+	// 样本数为奇数时最后一组只写出2字节
+	dat := pack212(1, -2, 3)
+	dat = dat[:len(dat)-1]
+
+	for _, header := range []string{"odd 1 100 3\n", "odd 1 100\n"} {
+		dir := writeRecordFiles(t, map[string][]byte{
+			"odd.hea": []byte(header + "odd.dat 212 1 12 0 0 0 0 ECG\n"),
+			"odd.dat": dat,
+		})
+		r, err := OpenWFDB(filepath.Join(dir, "odd.hea"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n := r.GetHeader().NumFrames; n != 3 {
+			t.Errorf("%q: NumFrames = %d, want 3", header, n)
+		}
+		values, err := r.ReadSignalData(0, 0, 3)
+		if err != nil || !reflect.DeepEqual(values, []int32{1, -2, 3}) {
+			t.Errorf("%q: ReadSignalData = %v, %v", header, values, err)
+		}
+		values, err = r.ReadSignalData(0, 2, 1)
+		if err != nil || !reflect.DeepEqual(values, []int32{3}) {
+			t.Errorf("%q: 读取最后一个样本 = %v, %v", header, values, err)
+		}
+		r.Close()
+	}
+}
+
+func TestReadWFDBInvalidSamples(t *testing.T) {
+	// 格式16和61中-32768表示无效样本
+	le := binary.LittleEndian.AppendUint16(nil, 5)
+	le = binary.LittleEndian.AppendUint16(le, 0x8000)
+	le = binary.LittleEndian.AppendUint16(le, 7)
+	be := binary.BigEndian.AppendUint16(nil, 0x8000)
+	be = binary.BigEndian.AppendUint16(be, 6)
+	be = binary.BigEndian.AppendUint16(be, 0x8000)
+
+	dir := writeRecordFiles(t, map[string][]byte{
+		"inv.hea":    []byte("inv 2 10 3\ninv_le.dat 16 1 16 0 0 0 0 A\ninv_be.dat 61 1 16 0 0 0 0 B\n"),
+		"inv_le.dat": le,
+		"inv_be.dat": be,
+	})
+	r, err := OpenWFDB(filepath.Join(dir, "inv.hea"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	for k, want := range [][]data.DataPoint{{{X: 0, Y: 5}, {X: 0.2, Y: 7}}, {{X: 0.1, Y: 6}}} {
+		points, err := r.ReadWindowPoints(k, 0, 1)
+		if err != nil || !reflect.DeepEqual(points, want) {
+			t.Errorf("信号%d = %v, %v, want %v", k, points, err, want)
+		}
+	}
+
+	// ReadSignalData返回包括无效样本在内的原始ADC值
+	values, err := r.ReadSignalData(0, 0, 3)
+	if err != nil || !reflect.DeepEqual(values, []int32{5, -32768, 7}) {
+		t.Errorf("ReadSignalData = %v, %v", values, err)
+	}
+}
+
+func TestReadWFDBMultiFrequency(t *testing.T) {
+	// 同一数据文件中信号0每帧2个样本，信号1每帧1个样本，数据前有4字节前缀，帧数按文件大小推算
+	raw := []byte{0xde, 0xad, 0xbe, 0xef}
+	for frame := 0; frame < 5; frame++ {
+		for _, v := range []int{10 * frame, 10*frame + 5, -frame} {
+			raw = binary.LittleEndian.AppendUint16(raw, uint16(int16(v)))
+		}
+	}
+	dir := writeRecordFiles(t, map[string][]byte{
+		"mf.hea": []byte("mf 2 100\nmf.dat 16x2+4 10 16 0 0 0 0 ECG\nmf.dat 16+4 1/uV 16 0 0 0 0 Temp\n"),
+		"mf.dat": raw,
+	})
+
+	r, err := OpenWFDB(filepath.Join(dir, "mf.hea"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if n := r.GetHeader().NumFrames; n != 5 {
+		t.Errorf("NumFrames = %d, want 5", n)
+	}
+	if r.GetSignalSamplingRate(0) != 200 || r.GetSignalSamplingRate(1) != 100 || r.GetSignalSamplingRate(2) != 0 {
+		t.Error("采样率应为帧频率乘以每帧样本数")
+	}
+
+	values, err := r.ReadSignalData(0, 3, 4)
+	if err != nil || !reflect.DeepEqual(values, []int32{15, 20, 25, 30}) {
+		t.Errorf("信号0 = %v, %v", values, err)
+	}
+	values, err = r.ReadSignalData(1, 0, 10)
+	if err != nil || !reflect.DeepEqual(values, []int32{0, -1, -2, -3, -4}) {
+		t.Errorf("信号1 = %v, %v", values, err)
+	}
+
+	if _, err := r.ReadSignalData(0, 11, 1); err == nil {
+		t.Error("起始样本超出记录应返回错误")
+	}
+	if _, err := r.ReadWindowPoints(2, 0, 1); err == nil {
+		t.Error("信号索引超出范围应返回错误")
+	}
+}
+
+func TestOpenWFDBMissingDataFile(t *testing.T) {
+	dir := writeRecordFiles(t, map[string][]byte{"rec.hea": []byte(wfdbTestHeader)})
+	if _, err := OpenWFDB(filepath.Join(dir, "rec.hea")); err == nil {
+		t.Error("数据文件不存在时应返回错误")
+	}
+}
+
+func TestReadWFDBAnnotations(t *testing.T) {
+	var raw []byte
+	raw = append(raw, mitWord(1, 1)...)  // N @1
+	raw = append(raw, mitWord(5, 2)...)  // V @3
+	raw = append(raw, mitWord(28, 0)...) // + @3，节律变化
+	raw = append(raw, mitWord(wfdbAux, 5)...)
+	raw = append(raw, "(AFIB\x00"...)
+	raw = append(raw, mitWord(wfdbChn, 1)...)
+	raw = append(raw, mitWord(wfdbSkip, 0)...)
+	raw = append(raw, 0x01, 0x00, 0xa0, 0x86) // 高16位在前: 0x000186a0 = 100000
+	raw = append(raw, mitWord(1, 7)...)       // N @100010
+	raw = append(raw, mitWord(0, 0)...)
+
+	path := wfdbTestRecord(t)
+	if err := os.WriteFile(filepath.Join(filepath.Dir(path), "rec.atr"), raw, 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := OpenWFDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	got, err := r.ReadAnnotations("atr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []WFDBAnnotation{
+		{Sample: 1, Time: 1.0 / 360, Code: 1, Symbol: "N"},
+		{Sample: 3, Time: 3.0 / 360, Code: 5, Symbol: "V"},
+		{Sample: 3, Time: 3.0 / 360, Code: 28, Symbol: "+", Aux: "(AFIB", Chan: 1},
+		{Sample: 100010, Time: 100010.0 / 360, Code: 1, Symbol: "N", Chan: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadAnnotations = %+v, want %+v", got, want)
+	}
+
+	markers := WFDBAnnotationsToMarkers(got, 1, 2, 3)
+	if len(markers) != 4 {
+		t.Fatalf("len(markers) = %d, want 4", len(markers))
+	}
+	if m := markers[1]; m.Type != "beat" || m.Label != "V" || m.Position != 3.0/360 {
+		t.Errorf("markers[1] = %+v", *m)
+	}
+	if m := markers[2]; m.Type != "annotation" || m.Label != "(AFIB" || m.Description != "+" {
+		t.Errorf("markers[2] = %+v", *m)
+	}
+
+	if _, err := r.ReadAnnotations("qrs"); !os.IsNotExist(err) {
+		t.Errorf("注释文件不存在时 err = %v", err)
+	}
+}
+
+func TestParseMITAnnotationsErrors(t *testing.T) {
+	tests := map[string][]byte{
+		"修饰代码之前没有注释": mitWord(wfdbAux, 2),
+		"SKIP截断":     append(mitWord(1, 1), mitWord(wfdbSkip, 0)...),
+		"附加文本截断":     append(mitWord(1, 1), mitWord(wfdbAux, 10)...),
+	}
+	for name, raw := range tests {
+		if _, err := parseMITAnnotations(raw); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+
+	// 文件开头代码为0的注释带有附加文本，不是文件结束
+	raw := append(mitWord(0, 0), mitWord(wfdbAux, 2)...)
+	raw = append(raw, "##"...)
+	raw = append(raw, mitWord(1, 5)...)
+	got, err := parseMITAnnotations(raw)
+	if err != nil || len(got) != 2 || got[0].Aux != "##" || got[1].Sample != 5 || got[1].Symbol != "N" {
+		t.Errorf("parseMITAnnotations = %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,151 @@
+package fileproc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+	"github.com/ljx520ljx/chartSystem/pkg/fileio"
+)
+
+func init() {
+	Register(aecgFormat{})
+}
+
+// aecgFormat 是HL7 aECG（Annotated ECG XML）格式的实现
+type aecgFormat struct{}
+
+// Info 返回格式的描述信息
+func (aecgFormat) Info() FormatInfo {
+	return FormatInfo{
+		ID:          "aecg",
+		Name:        "HL7 aECG",
+		Extension:   ".xml",
+		MimeType:    "application/xml",
+		Description: "HL7 Annotated ECG XML格式，静息心电图导联波形及注释",
+	}
+}
+
+// Sniff 根据根元素判断文件格式
+func (aecgFormat) Sniff(name string, head []byte) bool {
+	return bytes.Contains(head, []byte("<AnnotatedECG"))
+}
+
+// Open 解析文件，读取节律序列组（没有时使用第一个包含导联的序列组）
+func (aecgFormat) Open(path string) (Reader, error) {
+	record, err := fileio.OpenAECG(path)
+	if err != nil {
+		return nil, err
+	}
+	return newAECGReader(record)
+}
+
+// OpenStream 从数据流解析aECG文档
+func (aecgFormat) OpenStream(name string, r io.Reader) (Reader, error) {
+	record, err := fileio.ReadAECG(r)
+	if err != nil {
+		return nil, err
+	}
+	return newAECGReader(record)
+}
+
+// 选取节律序列（没有时取第一个有导联的序列）并提取元数据
+func newAECGReader(record *fileio.AECGRecord) (Reader, error) {
+	var series *fileio.AECGSeries
+	for i := range record.Series {
+		s := &record.Series[i]
+		if len(s.Leads) == 0 {
+			continue
+		}
+		if series == nil || (s.Code == "RHYTHM" && series.Code != "RHYTHM") {
+			series = s
+		}
+	}
+	if series == nil {
+		return nil, fmt.Errorf("aECG文件中没有导联波形")
+	}
+
+	meta := &Metadata{
+		Format:    "aecg",
+		StartTime: series.StartTime,
+		Duration:  series.Duration(),
+	}
+	if meta.StartTime.IsZero() {
+		meta.StartTime = record.StartTime
+	}
+
+	for i, lead := range series.Leads {
+		physMin, physMax := math.Inf(1), math.Inf(-1)
+		for _, v := range lead.Values {
+			physMin = math.Min(physMin, v)
+			physMax = math.Max(physMax, v)
+		}
+		if len(lead.Values) == 0 {
+			physMin, physMax = 0, 0
+		}
+
+		meta.Signals = append(meta.Signals, SignalInfo{
+			Index:       i,
+			Name:        lead.Name,
+			Unit:        lead.Unit,
+			SampleRate:  series.SampleRate,
+			PhysicalMin: physMin,
+			PhysicalMax: physMax,
+			NumSamples:  int64(len(lead.Values)),
+		})
+	}
+
+	for _, a := range series.Annotations {
+		ann := Annotation{Onset: a.Onset, Duration: a.Duration, Text: a.Label(), Type: "annotation"}
+		if strings.HasPrefix(a.Code, "MDC_ECG_BEAT") {
+			ann.Type = "beat"
+		}
+		meta.Annotations = append(meta.Annotations, ann)
+	}
+	sort.SliceStable(meta.Annotations, func(i, j int) bool {
+		return meta.Annotations[i].Onset < meta.Annotations[j].Onset
+	})
+
+	return &aecgReader{series: series, meta: meta}, nil
+}
+
+// aecgReader 在内存中保存解析后的aECG导联波形
+type aecgReader struct {
+	series *fileio.AECGSeries
+	meta   *Metadata
+}
+
+// Metadata 返回文件的元数据
+func (r *aecgReader) Metadata() *Metadata {
+	return r.meta
+}
+
+// ReadWindow 读取导联在时间窗口内的数据点
+func (r *aecgReader) ReadWindow(signalIndex int, t0, t1 float64) ([]data.DataPoint, error) {
+	if signalIndex < 0 || signalIndex >= len(r.series.Leads) {
+		return nil, fmt.Errorf("信号索引超出范围: %d", signalIndex)
+	}
+	if t1 <= t0 {
+		return nil, fmt.Errorf("无效的时间窗口: [%g, %g)", t0, t1)
+	}
+
+	values := r.series.Leads[signalIndex].Values
+	rate := r.series.SampleRate
+	start := int(math.Max(0, math.Ceil(t0*rate-1e-9)))
+	end := int(math.Min(float64(len(values)), math.Ceil(t1*rate-1e-9)))
+
+	points := make([]data.DataPoint, 0, max(end-start, 0))
+	for i := start; i < end; i++ {
+		points = append(points, data.DataPoint{X: float64(i) / rate, Y: values[i]})
+	}
+	return points, nil
+}
+
+// Close 释放资源，aECG在打开时已全部读入内存
+func (r *aecgReader) Close() error {
+	return nil
+}
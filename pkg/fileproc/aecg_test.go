@@ -0,0 +1,108 @@
+package fileproc
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// aECG文档中第一个序列组没有导联，应选取节律序列
+const testAECG = `<?xml version="1.0"?>
+<AnnotatedECG xmlns="urn:hl7-org:v3">
+  <id root="1.2.3"/>
+  <effectiveTime><low value="20240102030400"/></effectiveTime>
+  <component><series>
+    <code code="REPRESENTATIVE_BEAT"/>
+    <component><sequenceSet><component><sequence>
+      <code code="TIME_RELATIVE"/>
+      <value><head value="0"/><increment value="1" unit="ms"/></value>
+    </sequence></component></sequenceSet></component>
+  </series></component>
+  <component><series>
+    <code code="RHYTHM"/>
+    <component><sequenceSet>
+      <component><sequence>
+        <code code="TIME_ABSOLUTE"/>
+        <value><head value="20240102030405"/><increment value="0.1" unit="s"/></value>
+      </sequence></component>
+      <component><sequence>
+        <code code="MDC_ECG_LEAD_II"/>
+        <value><origin value="0" unit="uV"/><scale value="2" unit="uV"/><digits>0 1 2 3 4 5 6 7 8 9</digits></value>
+      </sequence></component>
+    </sequenceSet></component>
+    <subjectOf><annotationSet>
+      <component><annotation>
+        <code code="MDC_ECG_WAVC_TWAVE"/>
+        <support><supportingROI><component><boundary>
+          <code code="TIME_ABSOLUTE"/>
+          <value><low value="20240102030405.7"/></value>
+        </boundary></component></supportingROI></support>
+      </annotation></component>
+      <component><annotation>
+        <code code="MDC_ECG_BEAT"/>
+        <value code="MDC_ECG_BEAT_NORMAL"/>
+        <support><supportingROI><component><boundary>
+          <code code="TIME_ABSOLUTE"/>
+          <value><low value="20240102030405.2"/><high value="20240102030405.5"/></value>
+        </boundary></component></supportingROI></support>
+      </annotation></component>
+    </annotationSet></subjectOf>
+  </series></component>
+</AnnotatedECG>
+`
+
+func TestOpenAECG(t *testing.T) {
+	path := writeTestFile(t, "ecg.xml", []byte(testAECG))
+
+	format, err := Detect(path)
+	if err != nil || format.Info().ID != "aecg" {
+		t.Fatalf("Detect = %v, %v", format, err)
+	}
+
+	reader, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	meta := reader.Metadata()
+	if want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC); meta.Format != "aecg" || !meta.StartTime.Equal(want) || math.Abs(meta.Duration-1) > 1e-9 {
+		t.Errorf("Metadata = %+v", meta)
+	}
+	if len(meta.Signals) != 1 {
+		t.Fatalf("len(Signals) = %d, want 1", len(meta.Signals))
+	}
+	if s := meta.Signals[0]; s.Name != "II" || s.Unit != "uV" || math.Abs(s.SampleRate-10) > 1e-9 || s.PhysicalMin != 0 || s.PhysicalMax != 18 || s.NumSamples != 10 {
+		t.Errorf("Signals[0] = %+v", s)
+	}
+
+	// 注释按开始时间排序
+	if a := meta.Annotations; len(a) != 2 || a[0].Type != "beat" || a[0].Text != "BEAT_NORMAL" || math.Abs(a[0].Onset-0.2) > 1e-9 ||
+		math.Abs(a[0].Duration-0.3) > 1e-9 || a[1].Type != "annotation" || a[1].Text != "WAVC_TWAVE" {
+		t.Errorf("Annotations = %+v", a)
+	}
+
+	points, err := reader.ReadWindow(0, 0.25, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 2 || math.Abs(points[0].X-0.3) > 1e-9 || points[0].Y != 6 || points[1].Y != 8 {
+		t.Errorf("ReadWindow = %v", points)
+	}
+	if _, err := reader.ReadWindow(1, 0, 1); err == nil {
+		t.Error("信号索引超出范围应返回错误")
+	}
+	if _, err := reader.ReadWindow(0, 1, 0); err == nil {
+		t.Error("无效的时间窗口应返回错误")
+	}
+}
+
+func TestOpenAECGWithoutLeads(t *testing.T) {
+	// 只保留没有导联的序列组
+	doc := testAECG[:strings.Index(testAECG, "  <component><series>\n    <code code=\"RHYTHM\"/>")] + "</AnnotatedECG>\n"
+	path := writeTestFile(t, "empty.xml", []byte(doc))
+	if _, err := Open(path); err == nil {
+		t.Error("没有导联波形时应返回错误")
+	}
+}
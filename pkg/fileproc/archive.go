@@ -0,0 +1,459 @@
+package fileproc
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ljx520ljx/chartSystem/internal/model"
+	"github.com/ljx520ljx/chartSystem/pkg/fileio"
+)
+
+// 压缩包的文件头标识
+var (
+	zipMagic  = []byte("PK\x03\x04")
+	gzipMagic = []byte{0x1f, 0x8b}
+)
+
+// ErrArchiveFileTooLarge 表示压缩包中的文件解压后超过声明的大小或全局上限
+var ErrArchiveFileTooLarge = errors.New("压缩包中的文件解压后过大")
+
+// maxArchiveFileSize 是压缩包中单个文件解压后的最大字节数
+// 上限小于2^32，因此gzip文件尾中对2^32取模的ISIZE在上限内总是准确的。
+var maxArchiveFileSize int64 = 1 << 31
+
+// StreamFormat 表示可以直接从数据流读取的文件格式
+// 读取压缩包中此类格式的文件时不需要解压到临时文件。
+type StreamFormat interface {
+	Format
+	// OpenStream 从r中读取文件并提取元数据，name为文件名
+	OpenStream(name string, r io.Reader) (Reader, error)
+}
+
+// ArchiveEntry 表示压缩包中识别出的一个记录
+type ArchiveEntry struct {
+	Name    string     `json:"name"` // 记录主文件在压缩包中的路径
+	Format  FormatInfo `json:"format"`
+	Size    int64      `json:"size"`              // 主文件和附属文件解压后的总字节数
+	Members []string   `json:"members,omitempty"` // 附属文件在压缩包中的路径，如WFDB记录的数据文件和注释文件
+}
+
+// archiveFile 表示压缩包中的一个文件
+type archiveFile struct {
+	name string
+	size int64
+	open func() (io.ReadCloser, error)
+}
+
+// gzipFile 关闭时同时关闭gzip读取器和底层文件
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+// Close 关闭gzip读取器和底层文件
+func (g *gzipFile) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
+
+// tempFileReader 读取解压到临时目录的记录，关闭时删除临时目录
+type tempFileReader struct {
+	Reader
+	dir string
+}
+
+// Close 关闭文件并删除临时目录
+func (r *tempFileReader) Close() error {
+	err := r.Reader.Close()
+	os.RemoveAll(r.dir)
+	return err
+}
+
+// IsArchive 根据文件开头的字节判断是否为zip或gzip压缩包
+func IsArchive(head []byte) bool {
+	return bytes.HasPrefix(head, zipMagic) || bytes.HasPrefix(head, gzipMagic)
+}
+
+// ScanArchive 列出压缩包中所有可识别的记录
+// WFDB头文件引用的数据文件和同名的注释文件作为附属文件归入该记录，不单独列出。
+func ScanArchive(archivePath string) ([]ArchiveEntry, error) {
+	files, closeArchive, err := listArchive(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer closeArchive()
+
+	entries := make([]ArchiveEntry, 0)
+	grouped := make(map[string]bool)
+	for i := range files {
+		f := &files[i]
+		head, err := readArchiveHead(f)
+		if err != nil {
+			return nil, fmt.Errorf("读取%s失败: %w", f.name, err)
+		}
+
+		format, err := DetectBytes(f.name, head)
+		if err != nil {
+			if errors.Is(err, ErrUnknownFormat) {
+				continue
+			}
+			return nil, err
+		}
+
+		entry := ArchiveEntry{Name: f.name, Format: format.Info(), Size: f.size}
+		if entry.Format.ID == "wfdb" {
+			members, err := wfdbArchiveMembers(f, files)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.name, err)
+			}
+			for _, m := range members {
+				entry.Members = append(entry.Members, m.name)
+				entry.Size += m.size
+				grouped[m.name] = true
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	// 已归入其他记录的附属文件不单独列出
+	result := entries[:0]
+	for _, e := range entries {
+		if !grouped[e.Name] {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// OpenArchiveEntry 打开压缩包中的一个记录，name为ScanArchive返回的记录路径
+// 可流式读取的格式直接从压缩包中读取，其他格式先解压到临时目录，关闭时删除。
+func OpenArchiveEntry(archivePath, name string) (Reader, error) {
+	entries, err := ScanArchive(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if e.Name == name {
+			return openArchiveEntry(archivePath, e)
+		}
+	}
+	return nil, fmt.Errorf("压缩包%s中没有可识别的记录%s", filepath.Base(archivePath), name)
+}
+
+// OpenFile 打开文件模型对应的记录，来自压缩包的记录从压缩包中读取
+func OpenFile(file *model.File) (Reader, error) {
+	if file.ArchiveEntry != "" {
+		return OpenArchiveEntry(file.FilePath, file.ArchiveEntry)
+	}
+	return Open(file.FilePath)
+}
+
+// ArchiveFiles 为压缩包中的每个记录创建一个文件模型
+// 这些文件的FilePath都指向压缩包，ArchiveEntry为记录在压缩包中的路径。
+func ArchiveFiles(archive *model.File) ([]*model.File, error) {
+	entries, err := ScanArchive(archive.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]*model.File, 0, len(entries))
+	for _, e := range entries {
+		files = append(files, &model.File{
+			Name:         path.Base(e.Name),
+			Description:  archive.Description,
+			FilePath:     archive.FilePath,
+			FileSize:     e.Size,
+			ContentType:  e.Format.MimeType,
+			Format:       e.Format.ID,
+			ArchiveName:  archive.Name,
+			ArchiveEntry: e.Name,
+			UserID:       archive.UserID,
+		})
+	}
+	return files, nil
+}
+
+// 打开只包含一个记录的压缩包
+func openSingleArchiveEntry(archivePath string) (Reader, error) {
+	entries, err := ScanArchive(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(entries) {
+	case 0:
+		return nil, fmt.Errorf("%w: 压缩包%s中没有可识别的记录", ErrUnknownFormat, filepath.Base(archivePath))
+	case 1:
+		return openArchiveEntry(archivePath, entries[0])
+	default:
+		return nil, fmt.Errorf("压缩包%s中有%d个记录，需要指定要打开的记录", filepath.Base(archivePath), len(entries))
+	}
+}
+
+// 打开压缩包中的记录
+func openArchiveEntry(archivePath string, entry ArchiveEntry) (Reader, error) {
+	format, ok := Lookup(entry.Format.ID)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, entry.Format.ID)
+	}
+
+	files, closeArchive, err := listArchive(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer closeArchive()
+
+	byName := make(map[string]*archiveFile, len(files))
+	for i := range files {
+		byName[files[i].name] = &files[i]
+	}
+	names := append([]string{entry.Name}, entry.Members...)
+	for _, name := range names {
+		if byName[name] == nil {
+			return nil, fmt.Errorf("压缩包中缺少文件%s", name)
+		}
+	}
+
+	if stream, ok := format.(StreamFormat); ok && len(entry.Members) == 0 {
+		f := byName[entry.Name]
+		rc, err := f.open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+
+		return stream.OpenStream(path.Base(entry.Name), newArchiveLimitReader(f, rc))
+	}
+
+	// 需要随机访问的格式解压到临时目录，附属文件保持相对主文件的位置
+	dir, err := os.MkdirTemp("", "chartsystem-archive-")
+	if err != nil {
+		return nil, err
+	}
+
+	base := path.Dir(entry.Name)
+	for _, name := range names {
+		rel := name
+		if base != "." {
+			rel = strings.TrimPrefix(name, base+"/")
+		}
+		if !filepath.IsLocal(filepath.FromSlash(rel)) {
+			os.RemoveAll(dir)
+			return nil, fmt.Errorf("压缩包中的文件路径不安全: %s", name)
+		}
+
+		if err := extractArchiveFile(byName[name], filepath.Join(dir, filepath.FromSlash(rel))); err != nil {
+			os.RemoveAll(dir)
+			return nil, fmt.Errorf("解压%s失败: %w", name, err)
+		}
+	}
+
+	reader, err := format.Open(filepath.Join(dir, path.Base(entry.Name)))
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return &tempFileReader{Reader: reader, dir: dir}, nil
+}
+
+// 列出压缩包中的文件，返回的关闭函数用于释放压缩包
+func listArchive(archivePath string) ([]archiveFile, func() error, error) {
+	head, err := readHead(archivePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(head, zipMagic):
+		zr, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("打开zip压缩包失败: %w", err)
+		}
+
+		files := make([]archiveFile, 0, len(zr.File))
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() || isArchiveJunk(f.Name) {
+				continue
+			}
+			files = append(files, archiveFile{name: f.Name, size: int64(f.UncompressedSize64), open: f.Open})
+		}
+		return files, zr.Close, nil
+
+	case bytes.HasPrefix(head, gzipMagic):
+		file, err := listGzip(archivePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []archiveFile{file}, func() error { return nil }, nil
+	}
+
+	return nil, nil, fmt.Errorf("%w: %s不是zip或gzip压缩包", ErrUnknownFormat, filepath.Base(archivePath))
+}
+
+// 读取gzip压缩包中的单个文件
+// 文件名取gzip头中记录的原文件名，没有时去掉压缩包的扩展名。
+func listGzip(archivePath string) (archiveFile, error) {
+	open := func() (io.ReadCloser, error) {
+		file, err := os.Open(archivePath)
+		if err != nil {
+			return nil, err
+		}
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("打开gzip压缩包失败: %w", err)
+		}
+		return &gzipFile{Reader: gz, file: file}, nil
+	}
+
+	rc, err := open()
+	if err != nil {
+		return archiveFile{}, err
+	}
+	defer rc.Close()
+
+	gf := rc.(*gzipFile)
+	name := filepath.Base(gf.Name)
+	if gf.Name == "" {
+		name = strings.TrimSuffix(filepath.Base(archivePath), filepath.Ext(archivePath))
+	}
+
+	// 文件尾的ISIZE字段为解压后大小对2^32取模
+	size := int64(-1)
+	if info, err := gf.file.Stat(); err == nil && info.Size() >= 4 {
+		tail := make([]byte, 4)
+		if _, err := gf.file.ReadAt(tail, info.Size()-4); err == nil {
+			size = int64(binary.LittleEndian.Uint32(tail))
+		}
+	}
+
+	return archiveFile{name: name, size: size, open: open}, nil
+}
+
+// 找出WFDB头文件引用的数据文件，以及同一目录下与记录同名的其他文件（如.atr注释）
+func wfdbArchiveMembers(hea *archiveFile, files []archiveFile) ([]*archiveFile, error) {
+	rc, err := hea.open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	header, err := fileio.ParseWFDBHeader(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := path.Dir(hea.name)
+	wanted := make(map[string]bool)
+	for _, s := range header.Signals {
+		wanted[path.Join(dir, s.FileName)] = true
+	}
+	prefix := path.Join(dir, header.RecordName) + "."
+
+	members := make([]*archiveFile, 0)
+	for i := range files {
+		f := &files[i]
+		if f.name == hea.name {
+			continue
+		}
+		sameRecord := strings.HasPrefix(f.name, prefix) && !strings.Contains(f.name[len(prefix):], "/")
+		if wanted[f.name] || sameRecord {
+			members = append(members, f)
+		}
+	}
+	return members, nil
+}
+
+// 读取压缩包中文件开头用于格式检测的字节
+func readArchiveHead(f *archiveFile) ([]byte, error) {
+	rc, err := f.open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	head := make([]byte, sniffBytes)
+	n, err := io.ReadFull(rc, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return head[:n], nil
+}
+
+// 将压缩包中的文件解压到dst
+func extractArchiveFile(f *archiveFile, dst string) error {
+	rc, err := f.open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, newArchiveLimitReader(f, rc)); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// archiveLimitReader 限制从压缩包中读取的字节数，超出时返回ErrArchiveFileTooLarge
+type archiveLimitReader struct {
+	r         io.Reader
+	name      string
+	limit     int64
+	remaining int64
+}
+
+// 按文件声明的解压后大小和maxArchiveFileSize中较小的一个限制读取
+// 压缩包中声明的大小不可信（如压缩炸弹），实际数据超出时解压失败而不是写满磁盘或内存。
+func newArchiveLimitReader(f *archiveFile, r io.Reader) io.Reader {
+	limit := maxArchiveFileSize
+	if f.size >= 0 && f.size < limit {
+		limit = f.size
+	}
+	return &archiveLimitReader{r: r, name: f.name, limit: limit, remaining: limit}
+}
+
+// Read 读取数据，多读一个字节用于判断是否超出限制
+func (l *archiveLimitReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, l.tooLarge()
+	}
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n - 1, l.tooLarge()
+	}
+	return n, err
+}
+
+// 超出限制时返回的错误
+func (l *archiveLimitReader) tooLarge() error {
+	return fmt.Errorf("%w: %s超过%d字节", ErrArchiveFileTooLarge, l.name, l.limit)
+}
+
+// 判断是否为压缩工具附带的元数据文件，如macOS的__MACOSX目录
+func isArchiveJunk(name string) bool {
+	base := path.Base(name)
+	return strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(base, "._") || base == ".DS_Store"
+}
@@ -0,0 +1,284 @@
+package fileproc
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ljx520ljx/chartSystem/internal/model"
+	"github.com/ljx520ljx/chartSystem/pkg/fileio"
+)
+
+// 压缩包中的一个文件
+type zipMember struct {
+	name    string
+	content []byte
+}
+
+// 按顺序写出zip压缩包，名称以"/"结尾的成员为目录
+func writeTestZip(t *testing.T, name string, members ...zipMember) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, m := range members {
+		w, err := zw.Create(m.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(m.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return writeTestFile(t, name, buf.Bytes())
+}
+
+// 写出gzip压缩包，origName为gzip头中记录的原文件名
+func writeTestGzip(t *testing.T, name, origName string, content []byte) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Name = origName
+	if _, err := zw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return writeTestFile(t, name, buf.Bytes())
+}
+
+// 读取测试文件的内容
+func readTestFile(t *testing.T, path string) []byte {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+// 包含EDF记录、WFDB记录（头文件、数据文件和注释）、aECG文档和无关文件的zip压缩包
+func writeRecordsZip(t *testing.T) string {
+	edf := readTestFile(t, writeTestEDF(t, fileio.EDFWriteOptions{}))
+	hea := writeTestWFDB(t, append(mitWord(1, 2), 0, 0))
+	dir := filepath.Dir(hea)
+
+	return writeTestZip(t, "records.zip",
+		zipMember{"readme.txt", []byte("\x00\x01 说明")},
+		zipMember{"data/", nil},
+		zipMember{"data/night.edf", edf},
+		zipMember{"data/mit/rec.dat", readTestFile(t, filepath.Join(dir, "rec.dat"))},
+		zipMember{"data/mit/rec.hea", readTestFile(t, hea)},
+		zipMember{"data/mit/rec.atr", readTestFile(t, filepath.Join(dir, "rec.atr"))},
+		zipMember{"__MACOSX/data/._night.edf", edf},
+		zipMember{"ecg.xml", []byte(testAECG)},
+	)
+}
+
+func TestScanArchive(t *testing.T) {
+	entries, err := ScanArchive(writeRecordsZip(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type entry struct {
+		name, format string
+		members      []string
+	}
+	got := make([]entry, len(entries))
+	for i, e := range entries {
+		got[i] = entry{e.Name, e.Format.ID, e.Members}
+	}
+	want := []entry{
+		{"data/night.edf", "edf", nil},
+		{"data/mit/rec.hea", "wfdb", []string{"data/mit/rec.dat", "data/mit/rec.atr"}},
+		{"ecg.xml", "aecg", nil},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ScanArchive = %+v, want %+v", got, want)
+	}
+	// WFDB记录的大小包含附属文件
+	if entries[1].Size <= 20 {
+		t.Errorf("WFDB记录大小 = %d", entries[1].Size)
+	}
+}
+
+func TestOpenArchiveEntry(t *testing.T) {
+	path := writeRecordsZip(t)
+
+	// 需要随机访问的格式解压到临时目录，关闭后删除
+	reader, err := OpenArchiveEntry(path, "data/night.edf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta := reader.Metadata(); meta.Format != "edf" || meta.Duration != 2 {
+		t.Errorf("Metadata = %+v", meta)
+	}
+	dir := reader.(*tempFileReader).dir
+	if err := reader.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("关闭后临时目录%s未删除", dir)
+	}
+
+	// WFDB记录的附属文件一起解压
+	reader, err = OpenArchiveEntry(path, "data/mit/rec.hea")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta := reader.Metadata(); meta.Format != "wfdb" || len(meta.Signals) != 1 || len(meta.Annotations) != 1 {
+		t.Errorf("Metadata = %+v", meta)
+	}
+	reader.Close()
+
+	// 可流式读取的格式直接从压缩包读取
+	reader, err = OpenArchiveEntry(path, "ecg.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reader.(*tempFileReader); ok || reader.Metadata().Format != "aecg" {
+		t.Errorf("aECG应直接从压缩包读取: %T, %+v", reader, reader.Metadata())
+	}
+	reader.Close()
+
+	if _, err := OpenArchiveEntry(path, "readme.txt"); err == nil {
+		t.Error("不可识别的文件应返回错误")
+	}
+
+	// 包含多个记录的压缩包不能直接打开
+	if _, err := Open(path); err == nil {
+		t.Error("打开包含多个记录的压缩包应返回错误")
+	}
+}
+
+func TestArchiveFiles(t *testing.T) {
+	path := writeRecordsZip(t)
+	archive := &model.File{Name: "records.zip", Description: "夜间记录", FilePath: path, UserID: 7}
+
+	files, err := ArchiveFiles(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("len(files) = %d, want 3", len(files))
+	}
+	f := files[1]
+	if f.Name != "rec.hea" || f.FilePath != path || f.Format != "wfdb" || f.ArchiveName != "records.zip" || f.ArchiveEntry != "data/mit/rec.hea" || f.UserID != 7 || f.Description != "夜间记录" {
+		t.Errorf("files[1] = %+v", f)
+	}
+
+	reader, err := OpenFile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if reader.Metadata().Format != "wfdb" {
+		t.Errorf("Format = %s", reader.Metadata().Format)
+	}
+
+	// 用户的格式描述不用于压缩包中的记录
+	userReader, err := OpenUserFile(f, []*model.FormatDescriptor{{FormatID: "bad", Content: "<format"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer userReader.Close()
+	if userReader.Metadata().Format != "wfdb" {
+		t.Errorf("OpenUserFile: Format = %s", userReader.Metadata().Format)
+	}
+}
+
+func TestOpenGzip(t *testing.T) {
+	edf := readTestFile(t, writeTestEDF(t, fileio.EDFWriteOptions{}))
+
+	// 文件名取gzip头中的原文件名，没有时去掉压缩包扩展名
+	for name, origName := range map[string]string{"upload.gz": "dir/night.edf", "night.edf.gz": ""} {
+		path := writeTestGzip(t, name, origName, edf)
+
+		entries, err := ScanArchive(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].Name != "night.edf" || entries[0].Size != int64(len(edf)) {
+			t.Errorf("%s: ScanArchive = %+v", name, entries)
+		}
+
+		// 只有一个记录的压缩包可以直接打开
+		reader, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reader.Metadata().Format != "edf" {
+			t.Errorf("%s: Format = %s", name, reader.Metadata().Format)
+		}
+		reader.Close()
+	}
+
+	path := writeTestGzip(t, "empty.gz", "a.bin", []byte{1, 2, 3})
+	if _, err := Open(path); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Open = %v，应返回ErrUnknownFormat", err)
+	}
+}
+
+func TestExtractArchiveFileLimit(t *testing.T) {
+	edf := readTestFile(t, writeTestEDF(t, fileio.EDFWriteOptions{}))
+	path := writeTestGzip(t, "night.edf.gz", "", edf)
+
+	// 超过全局上限时解压失败
+	saved := maxArchiveFileSize
+	maxArchiveFileSize = int64(len(edf)) - 1
+	defer func() { maxArchiveFileSize = saved }()
+	if _, err := Open(path); !errors.Is(err, ErrArchiveFileTooLarge) {
+		t.Errorf("Open = %v，应返回ErrArchiveFileTooLarge", err)
+	}
+	maxArchiveFileSize = saved
+
+	// 实际数据超过声明的大小
+	open := func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader("0123456789")), nil }
+	dst := filepath.Join(t.TempDir(), "out", "a.bin")
+	if err := extractArchiveFile(&archiveFile{name: "a.bin", size: 9, open: open}, dst); !errors.Is(err, ErrArchiveFileTooLarge) {
+		t.Errorf("extractArchiveFile = %v，应返回ErrArchiveFileTooLarge", err)
+	}
+	if err := extractArchiveFile(&archiveFile{name: "a.bin", size: 10, open: open}, dst); err != nil {
+		t.Fatal(err)
+	}
+	if content := readTestFile(t, dst); string(content) != "0123456789" {
+		t.Errorf("解压内容 = %q", content)
+	}
+
+	// 流式读取的格式同样受限制
+	r := newArchiveLimitReader(&archiveFile{name: "ecg.xml", size: 4}, strings.NewReader("<xml>"))
+	if content, err := io.ReadAll(r); !errors.Is(err, ErrArchiveFileTooLarge) || string(content) != "<xml" {
+		t.Errorf("ReadAll = %q, %v", content, err)
+	}
+	if _, err := r.Read(make([]byte, 8)); !errors.Is(err, ErrArchiveFileTooLarge) {
+		t.Errorf("超出限制后继续读取应返回错误: %v", err)
+	}
+}
+
+func TestIsArchiveJunk(t *testing.T) {
+	for name, want := range map[string]bool{
+		"__MACOSX/a.edf": true,
+		"data/._a.edf":   true,
+		"data/.DS_Store": true,
+		"data/a.edf":     false,
+		"data/__MACOSX":  false,
+	} {
+		if got := isArchiveJunk(name); got != want {
+			t.Errorf("isArchiveJunk(%s) = %v, want %v", name, got, want)
+		}
+	}
+	if !IsArchive([]byte("PK\x03\x04")) || !IsArchive([]byte{0x1f, 0x8b, 8}) || IsArchive([]byte("PK")) {
+		t.Error("IsArchive判断错误")
+	}
+}
@@ -0,0 +1,97 @@
+package fileproc
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+	"github.com/ljx520ljx/chartSystem/pkg/fileio"
+)
+
+// 可按CSV/TSV读取的文件扩展名
+var csvExtensions = map[string]bool{".csv": true, ".tsv": true, ".txt": true}
+
+func init() {
+	Register(csvFormat{})
+}
+
+// csvFormat 是CSV/TSV文本波形格式的实现
+type csvFormat struct{}
+
+// Info 返回格式的描述信息
+func (csvFormat) Info() FormatInfo {
+	return FormatInfo{
+		ID:          "csv",
+		Name:        "CSV/TSV",
+		Extension:   ".csv",
+		MimeType:    "text/csv",
+		Description: "逗号、分号或制表符分隔的文本波形数据，支持时间列或固定采样率，表头可标注单位",
+	}
+}
+
+// Sniff 根据扩展名判断，并要求文件开头为不含NUL字节的UTF-8文本
+func (csvFormat) Sniff(name string, head []byte) bool {
+	if !csvExtensions[strings.ToLower(filepath.Ext(name))] {
+		return false
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return false
+	}
+
+	// 文件头可能截断在多字节字符中间
+	for i := 0; i < utf8.UTFMax && len(head) > 0 && !utf8.Valid(head); i++ {
+		head = head[:len(head)-1]
+	}
+	return utf8.Valid(head)
+}
+
+// Open 自动检测分隔符、表头和时间列后打开文件
+func (csvFormat) Open(path string) (Reader, error) {
+	reader, err := fileio.OpenCSV(path, fileio.CSVOptions{TimeColumn: fileio.CSVAutoTimeColumn})
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &Metadata{
+		Format:    "csv",
+		StartTime: reader.StartTime(),
+		Duration:  reader.Duration(),
+		Warnings:  reader.Warnings(),
+	}
+	for i, c := range reader.Columns() {
+		meta.Signals = append(meta.Signals, SignalInfo{
+			Index:       i,
+			Name:        c.Name,
+			Unit:        c.Unit,
+			SampleRate:  reader.GetSignalSamplingRate(i),
+			PhysicalMin: c.Min,
+			PhysicalMax: c.Max,
+			NumSamples:  c.Count,
+		})
+	}
+
+	return &csvReader{reader: reader, meta: meta}, nil
+}
+
+// csvReader 将fileio.CSVReader适配为Reader
+type csvReader struct {
+	reader *fileio.CSVReader
+	meta   *Metadata
+}
+
+// Metadata 返回文件的元数据
+func (r *csvReader) Metadata() *Metadata {
+	return r.meta
+}
+
+// ReadWindow 读取数值列在时间窗口内的数据点
+func (r *csvReader) ReadWindow(signalIndex int, t0, t1 float64) ([]data.DataPoint, error) {
+	return r.reader.ReadWindowPoints(signalIndex, t0, t1)
+}
+
+// Close 关闭文件
+func (r *csvReader) Close() error {
+	return r.reader.Close()
+}
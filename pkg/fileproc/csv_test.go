@@ -0,0 +1,36 @@
+package fileproc
+
+import "testing"
+
+func TestOpenCSV(t *testing.T) {
+	path := writeTestFile(t, "export.csv", []byte("time [ms];ECG [mV];SpO2 (%)\n0;0,5;97\n4;0,25;\n8;-0,5;98\n"))
+
+	reader, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	meta := reader.Metadata()
+	if meta.Format != "csv" || len(meta.Signals) != 2 {
+		t.Fatalf("Metadata = %+v", meta)
+	}
+	ecg, spo2 := meta.Signals[0], meta.Signals[1]
+	if ecg.Name != "ECG" || ecg.Unit != "mV" || ecg.SampleRate != 250 || ecg.PhysicalMin != -0.5 || ecg.PhysicalMax != 0.5 || ecg.NumSamples != 3 {
+		t.Errorf("Signals[0] = %+v", ecg)
+	}
+	if spo2.Name != "SpO2" || spo2.Unit != "%" || spo2.NumSamples != 2 {
+		t.Errorf("Signals[1] = %+v", spo2)
+	}
+
+	// 缺失值不返回数据点
+	points, err := reader.ReadWindow(1, 0, meta.Duration)
+	if err != nil || len(points) != 2 || points[1].X != 0.008 || points[1].Y != 98 {
+		t.Errorf("ReadWindow = %v, %v", points, err)
+	}
+
+	channels := meta.DataChannels(1)
+	if len(channels) != 2 || channels[1].DataOffset != 1 || channels[1].Unit != "%" || channels[1].DataFormat != "csv" {
+		t.Errorf("DataChannels = %+v", channels)
+	}
+}
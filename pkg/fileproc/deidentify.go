@@ -0,0 +1,70 @@
+package fileproc
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/ljx520ljx/chartSystem/internal/model"
+	"github.com/ljx520ljx/chartSystem/pkg/fileio"
+)
+
+// ErrDeidentifyUnsupported 表示文件格式不支持去标识化
+var ErrDeidentifyUnsupported = errors.New("文件格式不支持去标识化")
+
+// Deidentifier 是支持去标识化的格式可选实现的接口
+type Deidentifier interface {
+	// Deidentify 原地改写文件中可识别病人身份的信息
+	Deidentify(path string, opts fileio.DeidentifyOptions) error
+}
+
+// Deidentify 自动检测格式并原地去标识化文件
+func Deidentify(path string, opts fileio.DeidentifyOptions) error {
+	format, err := Detect(path)
+	if err != nil {
+		return err
+	}
+	return deidentify(format, path, opts)
+}
+
+// DeidentifyFile 在上传的文件入库前原地去标识化，opts为nil时不做处理
+// 上传流程应在保存文件之后、提取元数据和通道之前调用，使入库的信息都来自去标识化后的文件。
+// 成功后设置file.Deidentified；压缩包中的记录不能原地改写，返回ErrDeidentifyUnsupported。
+func DeidentifyFile(file *model.File, opts *fileio.DeidentifyOptions) error {
+	if opts == nil {
+		return nil
+	}
+	if file.ArchiveEntry != "" {
+		return fmt.Errorf("%w: 压缩包%s中的记录%s", ErrDeidentifyUnsupported, file.ArchiveName, file.ArchiveEntry)
+	}
+
+	var format Format
+	if file.Format != "" {
+		f, ok := Lookup(file.Format)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownFormat, file.Format)
+		}
+		format = f
+	} else {
+		f, err := Detect(file.FilePath)
+		if err != nil {
+			return err
+		}
+		format = f
+	}
+
+	if err := deidentify(format, file.FilePath, *opts); err != nil {
+		return err
+	}
+	file.Deidentified = true
+	return nil
+}
+
+// 使用格式的Deidentifier实现去标识化
+func deidentify(format Format, path string, opts fileio.DeidentifyOptions) error {
+	d, ok := format.(Deidentifier)
+	if !ok {
+		return fmt.Errorf("%w: %s (%s)", ErrDeidentifyUnsupported, filepath.Base(path), format.Info().ID)
+	}
+	return d.Deidentify(path, opts)
+}
@@ -0,0 +1,73 @@
+package fileproc
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ljx520ljx/chartSystem/internal/model"
+	"github.com/ljx520ljx/chartSystem/pkg/fileio"
+)
+
+func TestDeidentifyFile(t *testing.T) {
+	path := writeTestEDF(t, fileio.EDFWriteOptions{
+		PatientID:   "MCH-0234567 F 02-MAY-1951 Haagse_Harry",
+		RecordingID: "Startdate 02-MAR-2002 EMG561 BK/JOP Sony",
+		StartTime:   time.Date(2002, 3, 2, 10, 0, 0, 0, time.UTC),
+		Annotations: []fileio.Annotation{{Onset: 1, Text: "Harry asleep"}},
+	})
+	file := &model.File{FilePath: path, Format: "edf"}
+
+	// 未启用去标识化时不做处理
+	if err := DeidentifyFile(file, nil); err != nil || file.Deidentified {
+		t.Fatalf("DeidentifyFile(nil) = %v, Deidentified = %v", err, file.Deidentified)
+	}
+
+	if err := DeidentifyFile(file, &fileio.DeidentifyOptions{PatientCode: "P1", DateShift: 24 * time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	if !file.Deidentified {
+		t.Error("Deidentified应为true")
+	}
+
+	reader, err := fileio.OpenEDF(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	h := reader.GetHeader()
+	if h.PatientID != "P1 X X X" || h.RecordingID != "Startdate 03-MAR-2002 X X Sony" {
+		t.Errorf("PatientID = %q, RecordingID = %q", h.PatientID, h.RecordingID)
+	}
+
+	meta, err := ReadMetadata(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(meta.Annotations) != 1 || meta.Annotations[0].Text != "XXXXX asleep" {
+		t.Errorf("Annotations = %+v", meta.Annotations)
+	}
+	if want := time.Date(2002, 3, 3, 10, 0, 0, 0, time.UTC); !meta.StartTime.Equal(want) {
+		t.Errorf("StartTime = %v, want %v", meta.StartTime, want)
+	}
+}
+
+func TestDeidentifyFileUnsupported(t *testing.T) {
+	opts := &fileio.DeidentifyOptions{}
+
+	csv := writeTestFile(t, "test.csv", []byte("time,ecg\n0,1\n0.1,2\n"))
+	file := &model.File{FilePath: csv}
+	if err := DeidentifyFile(file, opts); !errors.Is(err, ErrDeidentifyUnsupported) || file.Deidentified {
+		t.Errorf("CSV文件: err = %v, Deidentified = %v", err, file.Deidentified)
+	}
+
+	entry := &model.File{FilePath: csv, Format: "edf", ArchiveName: "a.zip", ArchiveEntry: "a.edf"}
+	if err := DeidentifyFile(entry, opts); !errors.Is(err, ErrDeidentifyUnsupported) {
+		t.Errorf("压缩包中的记录: err = %v", err)
+	}
+
+	unknown := &model.File{FilePath: csv, Format: "no-such-format"}
+	if err := DeidentifyFile(unknown, opts); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("未注册的格式: err = %v", err)
+	}
+}
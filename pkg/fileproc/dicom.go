@@ -0,0 +1,144 @@
+package fileproc
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+	"github.com/ljx520ljx/chartSystem/pkg/fileio"
+)
+
+func init() {
+	Register(dicomFormat{})
+}
+
+// dicomFormat 是DICOM波形对象（如12导联心电图IOD）的实现
+type dicomFormat struct{}
+
+// Info 返回格式的描述信息
+func (dicomFormat) Info() FormatInfo {
+	return FormatInfo{
+		ID:          "dicom",
+		Name:        "DICOM Waveform",
+		Extension:   ".dcm",
+		MimeType:    "application/dicom",
+		Description: "DICOM波形对象（12导联心电图等），支持显式/隐式VR小端传输语法",
+	}
+}
+
+// Sniff 根据前导区后的"DICM"标识判断文件格式
+func (dicomFormat) Sniff(name string, head []byte) bool {
+	return fileio.IsDICOM(head)
+}
+
+// Open 解析文件，所有多路复用组的通道依次作为信号
+// 有多个组时信号名带上组标签，如"Lead II (MEDIAN BEAT)"。
+func (dicomFormat) Open(path string) (Reader, error) {
+	waveform, err := fileio.OpenDICOMWaveform(path)
+	if err != nil {
+		return nil, err
+	}
+	return newDICOMReader(waveform), nil
+}
+
+// OpenStream 从数据流解析DICOM波形对象
+func (dicomFormat) OpenStream(name string, r io.Reader) (Reader, error) {
+	waveform, err := fileio.ReadDICOMWaveform(r)
+	if err != nil {
+		return nil, err
+	}
+	return newDICOMReader(waveform), nil
+}
+
+// 将各复用组的通道展开为连续的信号索引
+func newDICOMReader(waveform *fileio.DICOMWaveform) Reader {
+	reader := &dicomReader{
+		waveform: waveform,
+		meta: &Metadata{
+			Format:    "dicom",
+			StartTime: waveform.StartTime,
+		},
+	}
+
+	for g := range waveform.Groups {
+		group := &waveform.Groups[g]
+		for c, channel := range group.Channels {
+			name := channel.Label
+			if len(waveform.Groups) > 1 && group.Label != "" {
+				name = fmt.Sprintf("%s (%s)", name, group.Label)
+			}
+
+			// 填充样本（NaN）不参与范围计算
+			physMin, physMax := math.Inf(1), math.Inf(-1)
+			for _, v := range channel.Values {
+				if !math.IsNaN(v) {
+					physMin = math.Min(physMin, v)
+					physMax = math.Max(physMax, v)
+				}
+			}
+			if physMin > physMax {
+				physMin, physMax = 0, 0
+			}
+
+			reader.meta.Signals = append(reader.meta.Signals, SignalInfo{
+				Index:       len(reader.channels),
+				Name:        name,
+				Unit:        channel.Unit,
+				SampleRate:  group.SampleRate,
+				PhysicalMin: physMin,
+				PhysicalMax: physMax,
+				NumSamples:  int64(len(channel.Values)),
+			})
+			reader.channels = append(reader.channels, [2]int{g, c})
+
+			// 通道偏斜使通道的最后一个样本晚于组的结束时间
+			end := group.TimeOffset + channel.TimeSkew + float64(group.NumSamples)/group.SampleRate
+			reader.meta.Duration = math.Max(reader.meta.Duration, end)
+		}
+	}
+
+	return reader
+}
+
+// dicomReader 在内存中保存解析后的DICOM波形
+type dicomReader struct {
+	waveform *fileio.DICOMWaveform
+	meta     *Metadata
+	channels [][2]int // 每个信号对应的组索引和组内通道索引
+}
+
+// Metadata 返回文件的元数据
+func (r *dicomReader) Metadata() *Metadata {
+	return r.meta
+}
+
+// ReadWindow 读取通道在时间窗口内的数据点，填充样本不生成数据点
+func (r *dicomReader) ReadWindow(signalIndex int, t0, t1 float64) ([]data.DataPoint, error) {
+	if signalIndex < 0 || signalIndex >= len(r.channels) {
+		return nil, fmt.Errorf("信号索引超出范围: %d", signalIndex)
+	}
+	if t1 <= t0 {
+		return nil, fmt.Errorf("无效的时间窗口: [%g, %g)", t0, t1)
+	}
+
+	group := &r.waveform.Groups[r.channels[signalIndex][0]]
+	channel := group.Channels[r.channels[signalIndex][1]]
+	offset := group.TimeOffset + channel.TimeSkew
+
+	start := int(math.Max(0, math.Ceil((t0-offset)*group.SampleRate-1e-9)))
+	end := int(math.Min(float64(len(channel.Values)), math.Ceil((t1-offset)*group.SampleRate-1e-9)))
+
+	points := make([]data.DataPoint, 0, max(end-start, 0))
+	for i := start; i < end; i++ {
+		if v := channel.Values[i]; !math.IsNaN(v) {
+			points = append(points, data.DataPoint{X: offset + float64(i)/group.SampleRate, Y: v})
+		}
+	}
+	return points, nil
+}
+
+// Close 释放资源，DICOM波形在打开时已全部读入内存
+func (r *dicomReader) Close() error {
+	return nil
+}
@@ -0,0 +1,115 @@
+package fileproc
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/ljx520ljx/chartSystem/pkg/fileio/dicomtest"
+)
+
+// dicomChannel 描述测试组中一个通道的标签和时间偏斜
+type dicomChannel struct {
+	label, skew string
+}
+
+// 一个多路复用组，样本为交错的int16，-32768为填充值
+func dicomGroup(label, rate, offset string, channels []dicomChannel, samples ...int16) []byte {
+	e := dicomtest.Encoder{Explicit: true}
+	var defs [][]byte
+	for _, c := range channels {
+		def := e.Str(0x003A0203, "SH", c.label)
+		if c.skew != "" {
+			def = append(def, e.Str(0x003A0214, "DS", c.skew)...)
+		}
+		defs = append(defs, def)
+	}
+	return bytes.Join([][]byte{
+		e.Str(0x00181068, "DS", offset),
+		e.US(0x003A0005, uint16(len(channels))),
+		e.UL(0x003A0010, uint32(len(samples)/len(channels))),
+		e.Str(0x003A001A, "DS", rate),
+		e.Str(0x003A0020, "CS", label),
+		e.Seq(0x003A0200, defs...),
+		e.US(0x54001004, 16),
+		e.Str(0x54001006, "CS", "SS"),
+		e.Elem(0x5400100A, "OW", dicomtest.Int16Data(math.MinInt16)),
+		e.Elem(0x54001010, "OW", dicomtest.Int16Data(samples...)),
+	}, nil)
+}
+
+func TestOpenDICOM(t *testing.T) {
+	content := dicomtest.File(dicomtest.ExplicitVRLittleEndian, dicomtest.Encoder{Explicit: true}.Seq(0x54000100,
+		dicomGroup("RHYTHM", "100", "0", []dicomChannel{{label: "I"}, {label: "II"}}, 1, -1, 2, math.MinInt16, 3, -3, 4, -4),
+		dicomGroup("MEDIAN BEAT", "200", "500", []dicomChannel{{label: "I"}}, 5, 6),
+	))
+	path := writeTestFile(t, "ecg.dcm", content)
+
+	reader, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	// 多个组时信号名带上组标签，时长包含组偏移
+	meta := reader.Metadata()
+	if meta.Format != "dicom" || math.Abs(meta.Duration-0.51) > 1e-9 || len(meta.Signals) != 3 {
+		t.Fatalf("Metadata = %+v", meta)
+	}
+	names := []string{"I (RHYTHM)", "II (RHYTHM)", "I (MEDIAN BEAT)"}
+	for i, s := range meta.Signals {
+		if s.Index != i || s.Name != names[i] {
+			t.Errorf("Signals[%d] = %+v", i, s)
+		}
+	}
+
+	// 填充样本不参与范围计算，也不生成数据点
+	if s := meta.Signals[1]; s.PhysicalMin != -4 || s.PhysicalMax != -1 || s.NumSamples != 4 {
+		t.Errorf("Signals[1] = %+v", s)
+	}
+	points, err := reader.ReadWindow(1, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 3 || points[1].X != 0.02 || points[1].Y != -3 {
+		t.Errorf("ReadWindow = %v", points)
+	}
+
+	points, err = reader.ReadWindow(2, 0.5, 0.505)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 1 || points[0].X != 0.5 || points[0].Y != 5 {
+		t.Errorf("ReadWindow = %v", points)
+	}
+
+	if _, err := reader.ReadWindow(3, 0, 1); err == nil {
+		t.Error("信号索引超出范围应返回错误")
+	}
+}
+
+func TestOpenDICOMTimeSkew(t *testing.T) {
+	// 第2个通道偏斜30ms，最后一个样本在0.06秒
+	content := dicomtest.File(dicomtest.ExplicitVRLittleEndian, dicomtest.Encoder{Explicit: true}.Seq(0x54000100,
+		dicomGroup("RHYTHM", "100", "0", []dicomChannel{{label: "I"}, {label: "II", skew: "0.03"}}, 1, -1, 2, -2, 3, -3, 4, -4),
+	))
+	reader, err := Open(writeTestFile(t, "skew.dcm", content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	meta := reader.Metadata()
+	if math.Abs(meta.Duration-0.07) > 1e-9 {
+		t.Errorf("Duration = %g, want 0.07", meta.Duration)
+	}
+
+	// 按时长读取完整记录时包含偏斜通道的全部样本
+	points, err := reader.ReadWindow(1, 0, meta.Duration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 4 || math.Abs(points[3].X-0.06) > 1e-9 || points[3].Y != -4 {
+		t.Errorf("ReadWindow = %v", points)
+	}
+}
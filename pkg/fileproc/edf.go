@@ -0,0 +1,155 @@
+package fileproc
+
+import (
+	"bytes"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+	"github.com/ljx520ljx/chartSystem/pkg/fileio"
+)
+
+// EDF/BDF文件头前8字节的版本字段
+var (
+	edfMagic = []byte("0       ")
+	bdfMagic = []byte("\xffBIOSEMI")
+)
+
+func init() {
+	Register(edfFormat{})
+	Register(edfFormat{bdf: true})
+}
+
+// edfFormat 是EDF/EDF+和BDF/BDF+格式的实现，两者共用fileio.EDFReader
+type edfFormat struct {
+	bdf bool
+}
+
+// Info 返回格式的描述信息
+func (f edfFormat) Info() FormatInfo {
+	if f.bdf {
+		return FormatInfo{
+			ID:          "bdf",
+			Name:        "BioSemi Data Format",
+			Extension:   ".bdf",
+			MimeType:    "application/x-bdf",
+			Description: "BioSemi 24位数据格式，支持BDF+注释",
+		}
+	}
+	return FormatInfo{
+		ID:          "edf",
+		Name:        "European Data Format",
+		Extension:   ".edf",
+		MimeType:    "application/x-edf",
+		Description: "欧洲数据格式，多通道生理信号记录，支持EDF+注释和不连续记录",
+	}
+}
+
+// Sniff 根据版本字段判断文件格式
+func (f edfFormat) Sniff(name string, head []byte) bool {
+	if f.bdf {
+		return bytes.HasPrefix(head, bdfMagic)
+	}
+	return bytes.HasPrefix(head, edfMagic)
+}
+
+// Open 以宽松模式打开文件，可修复的文件头问题记录为警告
+func (f edfFormat) Open(path string) (Reader, error) {
+	reader, err := fileio.OpenEDFWithOptions(path, fileio.EDFOpenOptions{Lenient: true})
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := edfMetadata(reader, f.Info().ID)
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+
+	return &edfReader{reader: reader, meta: meta}, nil
+}
+
+// Deidentify 原地改写文件头中的病人信息和开始日期，并清除注释中的敏感文本
+func (f edfFormat) Deidentify(path string, opts fileio.DeidentifyOptions) error {
+	return fileio.DeidentifyEDF(path, "", opts)
+}
+
+// edfReader 将fileio.EDFReader适配为Reader
+type edfReader struct {
+	reader *fileio.EDFReader
+	meta   *Metadata
+}
+
+// Metadata 返回文件的元数据
+func (r *edfReader) Metadata() *Metadata {
+	return r.meta
+}
+
+// ReadWindow 读取信号在时间窗口内的数据点
+func (r *edfReader) ReadWindow(signalIndex int, t0, t1 float64) ([]data.DataPoint, error) {
+	return r.reader.ReadWindowPoints(signalIndex, t0, t1)
+}
+
+// ReadAll 一次遍历数据记录读取多个信号的全部数据点
+func (r *edfReader) ReadAll(signalIndices []int) ([][]data.DataPoint, error) {
+	return r.reader.ReadAllPoints(signalIndices)
+}
+
+// Close 关闭文件
+func (r *edfReader) Close() error {
+	return r.reader.Close()
+}
+
+// 从EDF文件头、注释信号和记录时间中提取元数据
+func edfMetadata(reader *fileio.EDFReader, formatID string) (*Metadata, error) {
+	header := reader.GetHeader()
+
+	recordTimes, err := reader.RecordStartTimes()
+	if err != nil {
+		return nil, err
+	}
+	gaps, err := reader.Gaps()
+	if err != nil {
+		return nil, err
+	}
+	annotations, err := reader.ReadAnnotations()
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &Metadata{
+		Format:    formatID,
+		StartTime: header.StartTime,
+		Gaps:      gaps,
+	}
+	if n := len(recordTimes); n > 0 {
+		meta.Duration = recordTimes[n-1] + header.Duration
+	}
+
+	for i, sh := range header.SignalHeaders {
+		if sh.IsAnnotation() {
+			continue
+		}
+		meta.Signals = append(meta.Signals, SignalInfo{
+			Index:       i,
+			Name:        sh.Label,
+			Unit:        sh.PhysicalDim,
+			SampleRate:  reader.GetSignalSamplingRate(i),
+			PhysicalMin: sh.PhysicalMin,
+			PhysicalMax: sh.PhysicalMax,
+			NumSamples:  int64(sh.Samples) * int64(header.DataRecords),
+		})
+	}
+
+	for _, a := range annotations {
+		meta.Annotations = append(meta.Annotations, Annotation{
+			Onset:    a.Onset,
+			Duration: a.Duration,
+			Text:     a.Text,
+		})
+	}
+
+	for _, w := range reader.Warnings() {
+		meta.Warnings = append(meta.Warnings, w.Error())
+	}
+
+	return meta, nil
+}
@@ -0,0 +1,44 @@
+package fileproc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+	"github.com/ljx520ljx/chartSystem/pkg/fileio"
+)
+
+// 将内容写入测试临时目录中的文件并返回路径
+func writeTestFile(t *testing.T, name string, content []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// 写出一个2秒、10Hz的单通道EDF文件，第i个样本的值为i
+func writeTestEDF(t *testing.T, opts fileio.EDFWriteOptions) string {
+	t.Helper()
+	channel := data.NewChannel("0", "ECG")
+	channel.SampleRate = 10
+	for i := 0; i < 20; i++ {
+		channel.AddDataPoint(float64(i)/10, float64(i))
+	}
+
+	path := filepath.Join(t.TempDir(), "test.edf")
+	w, err := fileio.CreateEDF(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteChannels([]*data.Channel{channel}, opts); err != nil {
+		w.Close()
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
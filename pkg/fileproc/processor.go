@@ -0,0 +1,294 @@
+package fileproc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+	"github.com/ljx520ljx/chartSystem/internal/model"
+	"github.com/ljx520ljx/chartSystem/pkg/fileio"
+)
+
+// 格式检测时读取的文件头字节数
+const sniffBytes = 512
+
+// ErrUnknownFormat 表示没有已注册的格式能识别该文件
+var ErrUnknownFormat = errors.New("无法识别的文件格式")
+
+// FormatInfo 表示一种文件格式的描述信息
+type FormatInfo struct {
+	ID          string `json:"format_id"`
+	Name        string `json:"name"`
+	Extension   string `json:"extension"`
+	MimeType    string `json:"mime_type"`
+	Description string `json:"description"`
+}
+
+// SignalInfo 表示文件中一个信号的元数据
+type SignalInfo struct {
+	Index       int     `json:"index"` // 在文件中的信号索引，用于ReadWindow
+	Name        string  `json:"name"`
+	Unit        string  `json:"unit"`
+	SampleRate  float64 `json:"sample_rate"`
+	PhysicalMin float64 `json:"physical_min"`
+	PhysicalMax float64 `json:"physical_max"`
+	NumSamples  int64   `json:"num_samples"`
+}
+
+// Annotation 表示文件中记录的一条事件注释
+type Annotation struct {
+	Onset    float64 `json:"onset"`    // 相对记录开始的时间（秒）
+	Duration float64 `json:"duration"` // 持续时间（秒），未指定时为0
+	Text     string  `json:"text"`
+	Type     string  `json:"type,omitempty"` // 标记点类型，为空时使用"annotation"
+}
+
+// Metadata 表示从文件中提取的元数据
+type Metadata struct {
+	Format      string       `json:"format"`
+	StartTime   time.Time    `json:"start_time"` // 记录开始的绝对时间，未知时为零值
+	Duration    float64      `json:"duration"`   // 记录总时长（秒）
+	Signals     []SignalInfo `json:"signals"`    // 只包含可按时间窗口读取的数据信号
+	Gaps        []data.Gap   `json:"gaps,omitempty"`
+	Annotations []Annotation `json:"annotations,omitempty"`
+	Warnings    []string     `json:"warnings,omitempty"` // 打开文件时发现的非致命问题
+}
+
+// Reader 表示一个已打开的信号文件
+type Reader interface {
+	// Metadata 返回文件的元数据
+	Metadata() *Metadata
+	// ReadWindow 读取信号在时间窗口[t0, t1)内的数据点，X为样本时间（秒）
+	ReadWindow(signalIndex int, t0, t1 float64) ([]data.DataPoint, error)
+	// Close 关闭文件
+	Close() error
+}
+
+// BulkReader 是能一次读取多个信号全部数据的Reader
+// LoadChannels优先使用ReadAll，避免按通道重复解码整个文件。
+type BulkReader interface {
+	Reader
+	// ReadAll 读取多个信号的全部数据点，结果与signalIndices一一对应
+	ReadAll(signalIndices []int) ([][]data.DataPoint, error)
+}
+
+// Format 表示一种可注册的文件格式
+type Format interface {
+	// Info 返回格式的描述信息
+	Info() FormatInfo
+	// Sniff 根据文件名和文件开头的字节判断文件是否为该格式
+	Sniff(name string, head []byte) bool
+	// Open 打开文件并提取元数据
+	Open(path string) (Reader, error)
+}
+
+// 已注册的格式，按注册顺序检测
+var (
+	registryMu sync.RWMutex
+	registry   []Format
+)
+
+// Register 注册一种文件格式，格式ID重复时panic
+// 通常在格式实现的init函数中调用。
+func Register(format Format) {
+	if err := register(format); err != nil {
+		panic("fileproc: " + err.Error())
+	}
+}
+
+// 注册一种文件格式，格式ID重复时返回错误
+func register(format Format) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	id := format.Info().ID
+	for _, f := range registry {
+		if f.Info().ID == id {
+			return fmt.Errorf("文件格式%q重复注册", id)
+		}
+	}
+	registry = append(registry, format)
+	return nil
+}
+
+// Formats 返回所有已注册格式的描述信息，按格式ID排序
+func Formats() []FormatInfo {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	infos := make([]FormatInfo, len(registry))
+	for i, f := range registry {
+		infos[i] = f.Info()
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
+
+// Lookup 根据格式ID查找已注册的格式
+func Lookup(id string) (Format, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for _, f := range registry {
+		if f.Info().ID == id {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// DetectBytes 根据文件名和文件开头的字节检测格式
+func DetectBytes(name string, head []byte) (Format, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for _, f := range registry {
+		if f.Sniff(name, head) {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, filepath.Base(name))
+}
+
+// Detect 检测文件的格式
+func Detect(path string) (Format, error) {
+	head, err := readHead(path)
+	if err != nil {
+		return nil, err
+	}
+	return DetectBytes(path, head)
+}
+
+// 读取用于格式检测的文件头
+func readHead(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffBytes)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return head[:n], nil
+}
+
+// Open 自动检测格式并打开文件
+// 无法识别的zip或gzip压缩包中只有一个可识别的记录时，打开该记录。
+func Open(path string) (Reader, error) {
+	head, err := readHead(path)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := DetectBytes(path, head)
+	if err != nil {
+		if errors.Is(err, ErrUnknownFormat) && IsArchive(head) {
+			return openSingleArchiveEntry(path)
+		}
+		return nil, err
+	}
+	return format.Open(path)
+}
+
+// ReadMetadata 自动检测格式并提取文件的元数据
+func ReadMetadata(path string) (*Metadata, error) {
+	reader, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return reader.Metadata(), nil
+}
+
+// LoadChannel 将信号的全部数据加载到通道
+func LoadChannel(reader Reader, signalIndex int, channel *data.Channel) error {
+	return LoadChannels(reader, []int{signalIndex}, []*data.Channel{channel})
+}
+
+// LoadChannels 将多个信号的全部数据分别加载到对应的通道
+// Reader实现了BulkReader时一次读取所有信号，否则逐个信号按时间窗口读取。
+func LoadChannels(reader Reader, signalIndices []int, channels []*data.Channel) error {
+	if len(signalIndices) != len(channels) {
+		return fmt.Errorf("信号数%d与通道数%d不一致", len(signalIndices), len(channels))
+	}
+
+	meta := reader.Metadata()
+	points := make([][]data.DataPoint, len(signalIndices))
+	if bulk, ok := reader.(BulkReader); ok {
+		all, err := bulk.ReadAll(signalIndices)
+		if err != nil {
+			return err
+		}
+		points = all
+	} else if meta.Duration > 0 {
+		for k, signalIndex := range signalIndices {
+			p, err := reader.ReadWindow(signalIndex, 0, meta.Duration)
+			if err != nil {
+				return err
+			}
+			points[k] = p
+		}
+	}
+
+	for k, channel := range channels {
+		channel.ClearData()
+		channel.Data = points[k]
+		if channel.Data == nil {
+			channel.Data = []data.DataPoint{}
+		}
+		channel.StartTime = meta.StartTime
+		channel.Gaps = append(channel.Gaps, meta.Gaps...)
+		for _, s := range meta.Signals {
+			if s.Index == signalIndices[k] {
+				channel.SampleRate = s.SampleRate
+			}
+		}
+	}
+
+	return nil
+}
+
+// DataChannels 将元数据中的信号转换为数据通道模型
+// DataOffset保存信号在文件中的索引，读取时传给Reader.ReadWindow。
+func (m *Metadata) DataChannels(fileID uint) []model.DataChannel {
+	channels := make([]model.DataChannel, 0, len(m.Signals))
+	for _, s := range m.Signals {
+		channels = append(channels, model.DataChannel{
+			FileID:     fileID,
+			Name:       s.Name,
+			Unit:       s.Unit,
+			SampleRate: s.SampleRate,
+			DataFormat: m.Format,
+			DataOffset: int64(s.Index),
+			DataLength: s.NumSamples,
+			MinValue:   s.PhysicalMin,
+			MaxValue:   s.PhysicalMax,
+		})
+	}
+	return channels
+}
+
+// Markers 将元数据中的注释转换为标记点
+func (m *Metadata) Markers(fileID, channelID, createdBy uint) []*model.Marker {
+	annotations := make([]fileio.Annotation, len(m.Annotations))
+	for i, a := range m.Annotations {
+		annotations[i] = fileio.Annotation{Onset: a.Onset, Duration: a.Duration, Text: a.Text}
+	}
+	markers := fileio.AnnotationsToMarkers(annotations, fileID, channelID, createdBy)
+	for i, a := range m.Annotations {
+		if a.Type != "" {
+			markers[i].Type = a.Type
+		}
+	}
+	return markers
+}
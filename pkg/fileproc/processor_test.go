@@ -0,0 +1,317 @@
+package fileproc
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+	"github.com/ljx520ljx/chartSystem/pkg/fileio"
+)
+
+// fakeFormat 是只用于测试注册表的格式
+type fakeFormat struct {
+	id string
+}
+
+func (f fakeFormat) Info() FormatInfo {
+	return FormatInfo{ID: f.id, Name: "Fake", Extension: ".fake"}
+}
+
+func (f fakeFormat) Sniff(name string, head []byte) bool {
+	return strings.HasSuffix(name, ".fake")
+}
+
+func (f fakeFormat) Open(path string) (Reader, error) {
+	return nil, errors.New("not implemented")
+}
+
+// 测试结束后恢复注册表
+func restoreRegistry(t *testing.T) {
+	registryMu.RLock()
+	saved := append([]Format(nil), registry...)
+	registryMu.RUnlock()
+	t.Cleanup(func() {
+		registryMu.Lock()
+		registry = saved
+		registryMu.Unlock()
+	})
+}
+
+func TestRegister(t *testing.T) {
+	restoreRegistry(t)
+
+	if err := register(fakeFormat{id: "fake"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := register(fakeFormat{id: "fake"}); err == nil {
+		t.Error("重复注册应返回错误")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Register重复的格式ID应panic")
+			}
+		}()
+		Register(fakeFormat{id: "edf"})
+	}()
+
+	if f, ok := Lookup("fake"); !ok || f.Info().Name != "Fake" {
+		t.Errorf("Lookup(fake) = %v, %v", f, ok)
+	}
+	if _, ok := Lookup("missing"); ok {
+		t.Error("Lookup(missing)应返回false")
+	}
+
+	infos := Formats()
+	ids := make([]string, len(infos))
+	for i, info := range infos {
+		ids[i] = info.ID
+	}
+	if !sort.StringsAreSorted(ids) {
+		t.Errorf("Formats应按ID排序: %v", ids)
+	}
+	for _, id := range []string{"aecg", "bdf", "csv", "dicom", "edf", "fake", "wfdb"} {
+		if i := sort.SearchStrings(ids, id); i == len(ids) || ids[i] != id {
+			t.Errorf("Formats中缺少%s: %v", id, ids)
+		}
+	}
+}
+
+func TestDetectBytes(t *testing.T) {
+	dicom := append(make([]byte, 128), "DICM"...)
+
+	tests := []struct {
+		name string
+		head string
+		want string
+	}{
+		{"a.edf", "0       X X X X", "edf"},
+		{"noext", "0       X X X X", "edf"},
+		{"a.bdf", "\xffBIOSEMI", "bdf"},
+		{"100.hea", "# MIT-BIH\n100 2 360 650000\n100.dat 212 200 11 1024", "wfdb"},
+		{"a.csv", "time,ecg\n0,1\n", "csv"},
+		{"a.tsv", "time\tecg\n0\t1\n", "csv"},
+		{"a.txt", "0;1,5\n", "csv"},
+		{"a.xml", `<?xml version="1.0"?><AnnotatedECG xmlns="urn:hl7-org:v3">`, "aecg"},
+		{"a.dcm", string(dicom), "dicom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := DetectBytes(tt.name, []byte(tt.head))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id := f.Info().ID; id != tt.want {
+				t.Errorf("DetectBytes = %s, want %s", id, tt.want)
+			}
+		})
+	}
+
+	for name, head := range map[string]string{
+		"a.hea":  "# 只有注释\n",
+		"a.csv":  "a,b\x00c",
+		"a.bin":  "\x01\x02\x03",
+		"a.xml":  "<root/>",
+		"e.edf":  "1       ",
+		"empty":  "",
+		"a.dat":  "0\n",
+		"x.hea2": "100 2\n",
+	} {
+		if f, err := DetectBytes(name, []byte(head)); !errors.Is(err, ErrUnknownFormat) {
+			t.Errorf("DetectBytes(%s) = %v, %v，应无法识别", name, f, err)
+		}
+	}
+}
+
+func TestOpenEDF(t *testing.T) {
+	path := writeTestEDF(t, fileio.EDFWriteOptions{
+		Annotations: []fileio.Annotation{{Onset: 0.5, Duration: 2, Text: "Event"}},
+	})
+
+	format, err := Detect(path)
+	if err != nil || format.Info().ID != "edf" {
+		t.Fatalf("Detect = %v, %v", format, err)
+	}
+
+	reader, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	meta := reader.Metadata()
+	if meta.Format != "edf" || meta.Duration != 2 || len(meta.Signals) != 1 {
+		t.Fatalf("Metadata = %+v", meta)
+	}
+	s := meta.Signals[0]
+	if s.Index != 0 || s.Name != "ECG" || s.SampleRate != 10 || s.NumSamples != 20 {
+		t.Errorf("Signals[0] = %+v", s)
+	}
+	if len(meta.Annotations) != 1 || meta.Annotations[0] != (Annotation{Onset: 0.5, Duration: 2, Text: "Event"}) {
+		t.Errorf("Annotations = %+v", meta.Annotations)
+	}
+
+	points, err := reader.ReadWindow(0, 0.5, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 5 || math.Abs(points[0].X-0.5) > 1e-9 || math.Abs(points[0].Y-5) > 0.01 {
+		t.Errorf("ReadWindow = %v", points)
+	}
+
+	channel := data.NewChannel("0", "ECG")
+	if err := LoadChannel(reader, 0, channel); err != nil {
+		t.Fatal(err)
+	}
+	if len(channel.Data) != 20 || channel.SampleRate != 10 {
+		t.Errorf("LoadChannel加载了%d个点，采样率%g", len(channel.Data), channel.SampleRate)
+	}
+
+	channels := meta.DataChannels(3)
+	if len(channels) != 1 || channels[0].FileID != 3 || channels[0].DataOffset != 0 || channels[0].DataLength != 20 || channels[0].DataFormat != "edf" {
+		t.Errorf("DataChannels = %+v", channels)
+	}
+
+	meta.Annotations = append(meta.Annotations, Annotation{Onset: 1, Text: "N", Type: "beat"})
+	markers := meta.Markers(3, 4, 5)
+	if len(markers) != 2 || markers[0].Type != "annotation" || markers[1].Type != "beat" || markers[1].ChannelID != 4 {
+		t.Errorf("Markers返回%d个标记点: %+v", len(markers), markers)
+	}
+}
+
+func TestOpenUnknown(t *testing.T) {
+	path := writeTestFile(t, "a.bin", []byte{1, 2, 3})
+	if _, err := Open(path); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Open = %v，应返回ErrUnknownFormat", err)
+	}
+	if _, err := ReadMetadata(path + ".missing"); err == nil {
+		t.Error("文件不存在时应返回错误")
+	}
+}
+
+// countingReader 是记录读取次数的Reader，每个信号在每秒有一个值为信号索引的点
+type countingReader struct {
+	meta    *Metadata
+	windows int
+}
+
+func (r *countingReader) Metadata() *Metadata { return r.meta }
+
+func (r *countingReader) ReadWindow(signalIndex int, t0, t1 float64) ([]data.DataPoint, error) {
+	if t1 <= t0 {
+		return nil, errors.New("无效的时间窗口")
+	}
+	r.windows++
+	var points []data.DataPoint
+	for x := math.Ceil(t0); x < t1 && x < r.meta.Duration; x++ {
+		points = append(points, data.DataPoint{X: x, Y: float64(signalIndex)})
+	}
+	return points, nil
+}
+
+func (r *countingReader) Close() error { return nil }
+
+// bulkCountingReader 在countingReader的基础上实现BulkReader
+type bulkCountingReader struct {
+	countingReader
+	bulk int
+}
+
+func (r *bulkCountingReader) ReadAll(signalIndices []int) ([][]data.DataPoint, error) {
+	r.bulk++
+	result := make([][]data.DataPoint, len(signalIndices))
+	for k, signalIndex := range signalIndices {
+		for x := 0.0; x < r.meta.Duration; x++ {
+			result[k] = append(result[k], data.DataPoint{X: x, Y: float64(signalIndex)})
+		}
+	}
+	return result, nil
+}
+
+func TestLoadChannels(t *testing.T) {
+	meta := func(duration float64) *Metadata {
+		return &Metadata{
+			Duration: duration,
+			Signals:  []SignalInfo{{Index: 1, SampleRate: 1}, {Index: 3, SampleRate: 2}},
+			Gaps:     []data.Gap{{Start: 1, End: 2}},
+		}
+	}
+	newChannels := func() []*data.Channel {
+		return []*data.Channel{data.NewChannel("1", "A"), data.NewChannel("3", "B")}
+	}
+	check := func(t *testing.T, channels []*data.Channel, points int) {
+		t.Helper()
+		for k, channel := range channels {
+			if len(channel.Data) != points || channel.Data == nil {
+				t.Fatalf("通道%d加载了%d个点, want %d", k, len(channel.Data), points)
+			}
+			for _, p := range channel.Data {
+				if want := float64(2*k + 1); p.Y != want {
+					t.Fatalf("通道%d的数据 = %g, want %g", k, p.Y, want)
+				}
+			}
+			if channel.SampleRate != float64(k+1) || len(channel.Gaps) != 1 {
+				t.Errorf("通道%d采样率%g，间断%v", k, channel.SampleRate, channel.Gaps)
+			}
+		}
+	}
+
+	t.Run("一次读取", func(t *testing.T) {
+		reader := &bulkCountingReader{countingReader: countingReader{meta: meta(5)}}
+		channels := newChannels()
+		if err := LoadChannels(reader, []int{1, 3}, channels); err != nil {
+			t.Fatal(err)
+		}
+		check(t, channels, 5)
+		if reader.bulk != 1 || reader.windows != 0 {
+			t.Errorf("ReadAll调用%d次，ReadWindow调用%d次", reader.bulk, reader.windows)
+		}
+	})
+
+	t.Run("按时间窗口读取", func(t *testing.T) {
+		reader := &countingReader{meta: meta(5)}
+		channels := newChannels()
+		if err := LoadChannels(reader, []int{1, 3}, channels); err != nil {
+			t.Fatal(err)
+		}
+		check(t, channels, 5)
+		if reader.windows != 2 {
+			t.Errorf("ReadWindow调用%d次, want 2", reader.windows)
+		}
+	})
+
+	t.Run("空记录", func(t *testing.T) {
+		reader := &countingReader{meta: meta(0)}
+		channels := newChannels()
+		if err := LoadChannels(reader, []int{1, 3}, channels); err != nil {
+			t.Fatalf("时长为0时应返回空通道: %v", err)
+		}
+		check(t, channels, 0)
+	})
+
+	if err := LoadChannels(&countingReader{meta: meta(5)}, []int{1}, newChannels()); err == nil {
+		t.Error("信号数与通道数不一致时应返回错误")
+	}
+}
+
+func TestLoadChannelsEDF(t *testing.T) {
+	reader, err := Open(writeTestEDF(t, fileio.EDFWriteOptions{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	if _, ok := reader.(BulkReader); !ok {
+		t.Fatal("EDF的Reader应实现BulkReader")
+	}
+	channel := data.NewChannel("0", "ECG")
+	if err := LoadChannels(reader, []int{0}, []*data.Channel{channel}); err != nil {
+		t.Fatal(err)
+	}
+	if len(channel.Data) != 20 || math.Abs(channel.Data[19].X-1.9) > 1e-9 || math.Abs(channel.Data[19].Y-19) > 0.01 {
+		t.Errorf("LoadChannels加载了%d个点: %v", len(channel.Data), channel.Data)
+	}
+}
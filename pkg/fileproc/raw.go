@@ -0,0 +1,202 @@
+package fileproc
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+	"github.com/ljx520ljx/chartSystem/internal/model"
+	"github.com/ljx520ljx/chartSystem/pkg/dataproc"
+)
+
+// descriptorFormat 是由格式描述定义的原始二进制格式
+type descriptorFormat struct {
+	desc  *dataproc.FormatDescriptor
+	magic []byte
+}
+
+// NewDescriptorFormat 根据格式描述创建文件格式
+// 描述中定义了扩展名或文件头标识时才能被自动检测，两者都定义时须同时满足。
+func NewDescriptorFormat(desc *dataproc.FormatDescriptor) (Format, error) {
+	if err := desc.Validate(); err != nil {
+		return nil, err
+	}
+
+	magic, err := desc.MagicBytes()
+	if err != nil {
+		return nil, err
+	}
+	if magic != nil && desc.Magic.Offset+int64(len(magic)) > sniffBytes {
+		return nil, fmt.Errorf("文件头标识须位于文件开头%d字节内", sniffBytes)
+	}
+
+	return &descriptorFormat{desc: desc, magic: magic}, nil
+}
+
+// RegisterDescriptor 将格式描述注册为全局可用的文件格式，格式ID重复时返回错误
+func RegisterDescriptor(desc *dataproc.FormatDescriptor) error {
+	format, err := NewDescriptorFormat(desc)
+	if err != nil {
+		return err
+	}
+	return register(format)
+}
+
+// RegisterDescriptorDir 注册目录中所有的格式描述文件（*.xml和*.json），返回注册的格式数
+// 目录不存在时不做任何操作。
+func RegisterDescriptorDir(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	count := 0
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".xml" && ext != ".json") {
+			continue
+		}
+
+		desc, err := dataproc.LoadDescriptor(filepath.Join(dir, entry.Name()))
+		if err == nil {
+			err = RegisterDescriptor(desc)
+		}
+		if err != nil {
+			return count, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// OpenWithDescriptors 打开文件，优先尝试给定的格式描述（如用户自定义的格式），再使用已注册的格式
+func OpenWithDescriptors(path string, descs []*dataproc.FormatDescriptor) (Reader, error) {
+	head, err := readHead(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, desc := range descs {
+		format, err := NewDescriptorFormat(desc)
+		if err != nil {
+			return nil, fmt.Errorf("格式描述%s无效: %w", desc.ID, err)
+		}
+		if format.Sniff(path, head) {
+			return format.Open(path)
+		}
+	}
+
+	format, err := DetectBytes(path, head)
+	if err != nil {
+		return nil, err
+	}
+	return format.Open(path)
+}
+
+// OpenUserFile 打开用户的文件，先尝试用户可用的自定义格式描述，再使用已注册的格式
+// descs通常为FormatDescriptorRepository.ListByUser的结果，格式ID相同时使用先出现的描述。
+// 来自压缩包的记录只使用已注册的格式。
+func OpenUserFile(file *model.File, descs []*model.FormatDescriptor) (Reader, error) {
+	if file.ArchiveEntry != "" {
+		return OpenFile(file)
+	}
+
+	parsed := make([]*dataproc.FormatDescriptor, 0, len(descs))
+	seen := make(map[string]bool)
+	for _, d := range descs {
+		if seen[d.FormatID] {
+			continue
+		}
+		seen[d.FormatID] = true
+
+		desc, err := dataproc.ParseDescriptor([]byte(d.Content))
+		if err != nil {
+			return nil, fmt.Errorf("格式描述%s无效: %w", d.FormatID, err)
+		}
+		parsed = append(parsed, desc)
+	}
+	return OpenWithDescriptors(file.FilePath, parsed)
+}
+
+// Info 返回格式的描述信息
+func (f *descriptorFormat) Info() FormatInfo {
+	return FormatInfo{
+		ID:          f.desc.ID,
+		Name:        f.desc.Name,
+		Extension:   f.desc.Extension,
+		MimeType:    "application/octet-stream",
+		Description: f.desc.Description,
+	}
+}
+
+// Sniff 根据描述中的扩展名和文件头标识判断
+func (f *descriptorFormat) Sniff(name string, head []byte) bool {
+	if f.desc.Extension == "" && f.magic == nil {
+		return false
+	}
+	if f.desc.Extension != "" && !strings.EqualFold(filepath.Ext(name), f.desc.Extension) {
+		return false
+	}
+	if f.magic != nil {
+		offset := int(f.desc.Magic.Offset)
+		if len(head) < offset+len(f.magic) || !bytes.Equal(head[offset:offset+len(f.magic)], f.magic) {
+			return false
+		}
+	}
+	return true
+}
+
+// Open 按格式描述打开文件
+func (f *descriptorFormat) Open(path string) (Reader, error) {
+	reader, err := dataproc.OpenRaw(path, f.desc)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &Metadata{
+		Format:   f.desc.ID,
+		Duration: reader.Duration(),
+		Warnings: reader.Warnings(),
+	}
+	for i := 0; i < reader.GetNumSignals(); i++ {
+		name, unit, min, max := reader.GetChannelInfo(i)
+		meta.Signals = append(meta.Signals, SignalInfo{
+			Index:       i,
+			Name:        name,
+			Unit:        unit,
+			SampleRate:  reader.GetSignalSamplingRate(i),
+			PhysicalMin: min,
+			PhysicalMax: max,
+			NumSamples:  reader.NumSamples(i),
+		})
+	}
+
+	return &rawReader{reader: reader, meta: meta}, nil
+}
+
+// rawReader 将dataproc.RawReader适配为Reader
+type rawReader struct {
+	reader *dataproc.RawReader
+	meta   *Metadata
+}
+
+// Metadata 返回文件的元数据
+func (r *rawReader) Metadata() *Metadata {
+	return r.meta
+}
+
+// ReadWindow 读取信号在时间窗口内的数据点
+func (r *rawReader) ReadWindow(signalIndex int, t0, t1 float64) ([]data.DataPoint, error) {
+	return r.reader.ReadWindowPoints(signalIndex, t0, t1)
+}
+
+// Close 关闭文件
+func (r *rawReader) Close() error {
+	return r.reader.Close()
+}
@@ -0,0 +1,228 @@
+package fileproc
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ljx520ljx/chartSystem/internal/model"
+	"github.com/ljx520ljx/chartSystem/pkg/dataproc"
+	"github.com/ljx520ljx/chartSystem/pkg/fileio"
+)
+
+// 扩展名为.acm、以"ACME"开头、文件头8字节的int16双通道格式
+func testDescriptor(id string) *dataproc.FormatDescriptor {
+	return &dataproc.FormatDescriptor{
+		ID:         id,
+		Name:       "ACME",
+		Extension:  ".acm",
+		Magic:      &dataproc.MagicDescriptor{Value: "41434D45"},
+		HeaderSize: 8,
+		SampleType: "int16",
+		SampleRate: 10,
+		Channels:   []dataproc.ChannelDescriptor{{Name: "ECG", Unit: "mV", Gain: 0.5}, {Name: "Resp"}},
+	}
+}
+
+// 按testDescriptor布局写入n帧数据
+func writeTestRaw(t *testing.T, name string, n int) string {
+	content := []byte("ACME\x00\x00\x00\x00")
+	for i := 0; i < n; i++ {
+		content = binary.LittleEndian.AppendUint16(content, uint16(i))
+		content = binary.LittleEndian.AppendUint16(content, uint16(100+i))
+	}
+	return writeTestFile(t, name, content)
+}
+
+func TestDescriptorFormatSniff(t *testing.T) {
+	format, err := NewDescriptorFormat(testDescriptor("acme"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info := format.Info(); info.ID != "acme" || info.Name != "ACME" || info.Extension != ".acm" {
+		t.Errorf("Info = %+v", info)
+	}
+
+	tests := []struct {
+		name string
+		head string
+		want bool
+	}{
+		{"a.acm", "ACME....", true},
+		{"A.ACM", "ACME", true},
+		{"a.raw", "ACME....", false},
+		{"a.acm", "ACMX....", false},
+		{"a.acm", "ACM", false},
+	}
+	for _, tt := range tests {
+		if got := format.Sniff(tt.name, []byte(tt.head)); got != tt.want {
+			t.Errorf("Sniff(%s, %q) = %v, want %v", tt.name, tt.head, got, tt.want)
+		}
+	}
+
+	// 没有扩展名和文件头标识时不参与自动检测
+	desc := testDescriptor("plain")
+	desc.Extension, desc.Magic = "", nil
+	if format, err := NewDescriptorFormat(desc); err != nil || format.Sniff("a.acm", []byte("ACME")) {
+		t.Errorf("NewDescriptorFormat = %v, %v，不应识别任何文件", format, err)
+	}
+
+	desc = testDescriptor("far")
+	desc.Magic.Offset = sniffBytes
+	if _, err := NewDescriptorFormat(desc); err == nil {
+		t.Error("文件头标识超出检测范围时应返回错误")
+	}
+	desc = testDescriptor("bad")
+	desc.SampleType = "int12"
+	if _, err := NewDescriptorFormat(desc); err == nil {
+		t.Error("描述无效时应返回错误")
+	}
+}
+
+func TestOpenDescriptorFormat(t *testing.T) {
+	format, err := NewDescriptorFormat(testDescriptor("acme"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := format.Open(writeTestRaw(t, "test.acm", 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	meta := reader.Metadata()
+	if meta.Format != "acme" || meta.Duration != 2 || len(meta.Signals) != 2 || len(meta.Warnings) != 0 {
+		t.Fatalf("Metadata = %+v", meta)
+	}
+	if s := meta.Signals[0]; s.Name != "ECG" || s.Unit != "mV" || s.SampleRate != 10 || s.NumSamples != 20 || s.PhysicalMin != -16384 || s.PhysicalMax != 16383.5 {
+		t.Errorf("Signals[0] = %+v", s)
+	}
+
+	points, err := reader.ReadWindow(1, 1, 1.3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 3 || points[0].X != 1 || points[0].Y != 110 || points[2].Y != 112 {
+		t.Errorf("ReadWindow = %v", points)
+	}
+}
+
+func TestRegisterDescriptorDir(t *testing.T) {
+	restoreRegistry(t)
+
+	dir := t.TempDir()
+	xmlDesc, err := dataproc.MarshalDescriptor(testDescriptor("acme-xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"acme.xml":   string(xmlDesc),
+		"other.json": `{"format_id": "other", "name": "Other", "extension": ".oth", "sample_type": "uint8", "sample_rate": 1, "channels": [{"name": "a"}]}`,
+		"readme.txt": "不是格式描述",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub.xml"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := RegisterDescriptorDir(dir)
+	if err != nil || n != 2 {
+		t.Fatalf("RegisterDescriptorDir = %d, %v", n, err)
+	}
+	if _, ok := Lookup("other"); !ok {
+		t.Error("JSON描述未注册")
+	}
+
+	// 注册后可以自动检测和打开
+	reader, err := Open(writeTestRaw(t, "auto.acm", 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if reader.Metadata().Format != "acme-xml" {
+		t.Errorf("Format = %s", reader.Metadata().Format)
+	}
+
+	// 格式ID重复
+	if n, err := RegisterDescriptorDir(dir); err == nil {
+		t.Errorf("重复注册应返回错误，注册了%d个", n)
+	}
+	if n, err := RegisterDescriptorDir(filepath.Join(dir, "missing")); n != 0 || err != nil {
+		t.Errorf("目录不存在时应忽略: %d, %v", n, err)
+	}
+}
+
+func TestOpenWithDescriptors(t *testing.T) {
+	path := writeTestRaw(t, "user.acm", 3)
+
+	reader, err := OpenWithDescriptors(path, []*dataproc.FormatDescriptor{testDescriptor("user-acme")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if meta := reader.Metadata(); meta.Format != "user-acme" || meta.Signals[0].NumSamples != 3 {
+		t.Errorf("Metadata = %+v", meta)
+	}
+
+	// 给定的描述都不匹配时使用已注册的格式
+	edf := writeTestEDF(t, fileio.EDFWriteOptions{})
+	reader, err = OpenWithDescriptors(edf, []*dataproc.FormatDescriptor{testDescriptor("user-acme")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if reader.Metadata().Format != "edf" {
+		t.Errorf("Format = %s", reader.Metadata().Format)
+	}
+
+	bad := testDescriptor("bad")
+	bad.SampleRate = 0
+	if _, err := OpenWithDescriptors(path, []*dataproc.FormatDescriptor{bad}); err == nil {
+		t.Error("描述无效时应返回错误")
+	}
+}
+
+// 将格式描述保存为数据库中的格式描述模型
+func descriptorModel(t *testing.T, userID uint, desc *dataproc.FormatDescriptor) *model.FormatDescriptor {
+	t.Helper()
+	content, err := dataproc.MarshalDescriptor(desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &model.FormatDescriptor{UserID: userID, FormatID: desc.ID, Name: desc.Name, Content: string(content)}
+}
+
+func TestOpenUserFile(t *testing.T) {
+	path := writeTestRaw(t, "user.acm", 3)
+	file := &model.File{FilePath: path, UserID: 7}
+
+	// 格式ID相同时使用先出现的用户描述，忽略后面的系统级描述
+	system := testDescriptor("acme")
+	system.SampleRate = 20
+	user := testDescriptor("acme")
+	reader, err := OpenUserFile(file, []*model.FormatDescriptor{descriptorModel(t, 7, user), descriptorModel(t, 0, system)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if meta := reader.Metadata(); meta.Format != "acme" || meta.Signals[0].SampleRate != 10 {
+		t.Errorf("Metadata = %+v", meta)
+	}
+
+	// 没有用户描述时使用已注册的格式
+	if _, err := OpenUserFile(file, nil); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("OpenUserFile = %v，应返回ErrUnknownFormat", err)
+	}
+
+	bad := descriptorModel(t, 7, user)
+	bad.Content = "<format"
+	if _, err := OpenUserFile(file, []*model.FormatDescriptor{bad}); err == nil {
+		t.Error("描述无效时应返回错误")
+	}
+}
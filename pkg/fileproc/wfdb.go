@@ -0,0 +1,134 @@
+package fileproc
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+	"github.com/ljx520ljx/chartSystem/pkg/fileio"
+)
+
+// 默认读取的参考注释文件扩展名
+const wfdbAnnotator = "atr"
+
+func init() {
+	Register(wfdbFormat{})
+}
+
+// wfdbFormat 是PhysioNet WFDB格式（.hea头文件及.dat数据文件）的实现
+type wfdbFormat struct{}
+
+// Info 返回格式的描述信息
+func (wfdbFormat) Info() FormatInfo {
+	return FormatInfo{
+		ID:          "wfdb",
+		Name:        "PhysioNet WFDB",
+		Extension:   ".hea",
+		MimeType:    "text/plain",
+		Description: "PhysioNet WFDB记录（如MIT-BIH），头文件引用212/16/61/80格式的数据文件，支持.atr注释",
+	}
+}
+
+// Sniff 根据头文件扩展名及记录行判断文件格式
+func (wfdbFormat) Sniff(name string, head []byte) bool {
+	if !strings.EqualFold(filepath.Ext(name), ".hea") {
+		return false
+	}
+
+	// 第一个非注释行应为记录行: 记录名 信号数 ...
+	for _, line := range strings.Split(string(head), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return len(strings.Fields(line)) >= 2
+	}
+	return false
+}
+
+// Open 打开WFDB记录，存在参考注释文件时一并读取
+func (wfdbFormat) Open(path string) (Reader, error) {
+	reader, err := fileio.OpenWFDB(path)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := wfdbMetadata(reader)
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+
+	return &wfdbReader{reader: reader, meta: meta}, nil
+}
+
+// wfdbReader 将fileio.WFDBReader适配为Reader
+type wfdbReader struct {
+	reader *fileio.WFDBReader
+	meta   *Metadata
+}
+
+// Metadata 返回文件的元数据
+func (r *wfdbReader) Metadata() *Metadata {
+	return r.meta
+}
+
+// ReadWindow 读取信号在时间窗口内的数据点
+func (r *wfdbReader) ReadWindow(signalIndex int, t0, t1 float64) ([]data.DataPoint, error) {
+	return r.reader.ReadWindowPoints(signalIndex, t0, t1)
+}
+
+// Close 关闭文件
+func (r *wfdbReader) Close() error {
+	return r.reader.Close()
+}
+
+// 从WFDB头文件和参考注释中提取元数据
+func wfdbMetadata(reader *fileio.WFDBReader) (*Metadata, error) {
+	header := reader.GetHeader()
+
+	meta := &Metadata{
+		Format:    "wfdb",
+		StartTime: header.StartTime,
+		Duration:  reader.Duration(),
+	}
+
+	for i, s := range header.Signals {
+		name, unit, physMin, physMax := reader.GetChannelInfo(i)
+		meta.Signals = append(meta.Signals, SignalInfo{
+			Index:       i,
+			Name:        name,
+			Unit:        unit,
+			SampleRate:  reader.GetSignalSamplingRate(i),
+			PhysicalMin: physMin,
+			PhysicalMax: physMax,
+			NumSamples:  header.NumFrames * int64(s.SamplesPerFrame),
+		})
+	}
+
+	annotations, err := reader.ReadAnnotations(wfdbAnnotator)
+	if errors.Is(err, fs.ErrNotExist) {
+		return meta, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range annotations {
+		if a.Code == 0 {
+			continue
+		}
+
+		ann := Annotation{Onset: a.Time, Text: a.Symbol, Type: "annotation"}
+		if a.IsBeat() {
+			ann.Type = "beat"
+		} else if a.Aux != "" {
+			ann.Text = a.Aux
+		}
+		meta.Annotations = append(meta.Annotations, ann)
+	}
+
+	return meta, nil
+}
@@ -0,0 +1,98 @@
+package fileproc
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 写出一个格式16的单信号WFDB记录，annotations非nil时同时写出.atr文件
+func writeTestWFDB(t *testing.T, annotations []byte) string {
+	t.Helper()
+	var samples []byte
+	for _, v := range []int16{0, 100, 200, -100, 50, 0, 0, 0, 0, 0} {
+		samples = binary.LittleEndian.AppendUint16(samples, uint16(v))
+	}
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"rec.hea": []byte("rec 1 10 10 08:00:00 02/01/2020\nrec.dat 16 100/mV 16 0 0 0 0 MLII\n"),
+		"rec.dat": samples,
+	}
+	if annotations != nil {
+		files["rec.atr"] = annotations
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "rec.hea")
+}
+
+// MIT格式注释的一个16位字
+func mitWord(code, value int) []byte {
+	return binary.LittleEndian.AppendUint16(nil, uint16(code<<10|value&0x3ff))
+}
+
+func TestOpenWFDB(t *testing.T) {
+	var atr []byte
+	atr = append(atr, mitWord(1, 2)...)  // N @0.2s
+	atr = append(atr, mitWord(28, 3)...) // + @0.5s
+	atr = append(atr, mitWord(63, 4)...)
+	atr = append(atr, "(AFI"...)
+	atr = append(atr, mitWord(5, 1)...) // V @0.6s
+	atr = append(atr, mitWord(0, 0)...)
+
+	reader, err := Open(writeTestWFDB(t, atr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	meta := reader.Metadata()
+	if meta.Format != "wfdb" || meta.Duration != 1 || meta.StartTime.Hour() != 8 {
+		t.Errorf("Metadata = %+v", meta)
+	}
+	if len(meta.Signals) != 1 || meta.Signals[0].Name != "MLII" || meta.Signals[0].SampleRate != 10 || meta.Signals[0].NumSamples != 10 {
+		t.Errorf("Signals = %+v", meta.Signals)
+	}
+
+	want := []Annotation{
+		{Onset: 0.2, Text: "N", Type: "beat"},
+		{Onset: 0.5, Text: "(AFI", Type: "annotation"}, // 附加文本替代助记符
+		{Onset: 0.6, Text: "V", Type: "beat"},
+	}
+	if len(meta.Annotations) != len(want) {
+		t.Fatalf("Annotations = %+v, want %+v", meta.Annotations, want)
+	}
+	for i, a := range meta.Annotations {
+		if a.Text != want[i].Text || a.Type != want[i].Type || math.Abs(a.Onset-want[i].Onset) > 1e-12 {
+			t.Errorf("Annotations[%d] = %+v, want %+v", i, a, want[i])
+		}
+	}
+
+	points, err := reader.ReadWindow(0, 0.1, 0.4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 3 || points[0].Y != 1 || points[1].Y != 2 || points[2].Y != -1 {
+		t.Errorf("ReadWindow = %v", points)
+	}
+}
+
+func TestOpenWFDBWithoutAnnotations(t *testing.T) {
+	meta, err := ReadMetadata(writeTestWFDB(t, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(meta.Annotations) != 0 {
+		t.Errorf("没有注释文件时Annotations = %+v", meta.Annotations)
+	}
+
+	if _, err := ReadMetadata(writeTestWFDB(t, mitWord(63, 2))); err == nil {
+		t.Error("注释文件无效时应返回错误")
+	}
+}
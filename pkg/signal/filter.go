@@ -0,0 +1,165 @@
+package signal
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+// 滤波器设计方法
+const (
+	DesignButterworth = "butterworth"
+	DesignChebyshev1  = "chebyshev1"
+	DesignFIR         = "fir"
+)
+
+// 未指定时的滤波器阶数、切比雪夫通带纹波（dB）和FIR设计参数
+const (
+	defaultFilterOrder     = 4
+	defaultFilterRipple    = 1.0
+	defaultFIRWindow       = WindowHamming
+	defaultFIRAttenuation  = 60.0 // dB
+	defaultFIRTransitionFr = 0.2  // 过渡带宽占最低截止频率的比例
+)
+
+// FilterParams 表示滤波处理的参数，对应处理任务中process_type为"filter"时的parameters
+type FilterParams struct {
+	FilterType  string  `json:"filter_type"` // lowpass、highpass、bandpass、bandstop、notch或comb
+	Design      string  `json:"design"`      // butterworth（默认）、chebyshev1或fir
+	Cutoff      float64 `json:"cutoff"`      // 低通和高通的截止频率（Hz）
+	LowCutoff   float64 `json:"low_cutoff"`  // 带通和带阻的低截止频率（Hz）
+	HighCutoff  float64 `json:"high_cutoff"` // 带通和带阻的高截止频率（Hz）
+	Order       int     `json:"order"`       // 滤波器阶数，默认4
+	Ripple      float64 `json:"ripple"`      // 切比雪夫I型的通带纹波（dB），默认1
+	ZeroPhase   bool    `json:"zero_phase"`  // 使用正反向零相位滤波，适用于离线分析
+	NotchFreq   float64 `json:"notch_freq"`  // 陷波频率（Hz），未指定时使用cutoff，默认50
+	Q           float64 `json:"q"`           // 陷波的品质因数，默认30
+	Harmonics   int     `json:"harmonics"`   // 梳状陷波的陷波个数（包括基波），0表示奈奎斯特频率以下的全部谐波
+	NumTaps     int     `json:"num_taps"`    // FIR抽头数，为0时按attenuation和transition用凯泽窗设计
+	Window      string  `json:"window"`      // 指定num_taps时FIR使用的窗函数，默认hamming
+	Attenuation float64 `json:"attenuation"` // FIR阻带衰减（dB），默认60
+	Transition  float64 `json:"transition"`  // FIR过渡带宽（Hz），默认为最低截止频率的20%
+}
+
+// DesignFilter 按参数设计滤波器
+// 低通和高通未指定cutoff时分别使用high_cutoff和low_cutoff。
+func (fp FilterParams) DesignFilter(sampleRate float64) (LinearFilter, error) {
+	var cutoffs []float64
+	switch fp.FilterType {
+	case LowPass:
+		cutoffs = []float64{firstNonZero(fp.Cutoff, fp.HighCutoff)}
+	case HighPass:
+		cutoffs = []float64{firstNonZero(fp.Cutoff, fp.LowCutoff)}
+	case BandPass, BandStop:
+		cutoffs = []float64{fp.LowCutoff, fp.HighCutoff}
+	case FilterNotch, FilterComb:
+		freq := firstNonZero(fp.NotchFreq, fp.Cutoff, defaultNotchFreq)
+		q := firstNonZero(fp.Q, defaultNotchQ)
+		if fp.FilterType == FilterComb {
+			return sosFilterResult(Comb(freq, q, fp.Harmonics, sampleRate))
+		}
+		return sosFilterResult(Notch(freq, q, sampleRate))
+	default:
+		return nil, fmt.Errorf("不支持的滤波器类型: %s", fp.FilterType)
+	}
+
+	order := fp.Order
+	if order == 0 {
+		order = defaultFilterOrder
+	}
+
+	switch fp.Design {
+	case "", DesignButterworth:
+		return sosFilterResult(Butterworth(order, fp.FilterType, sampleRate, cutoffs...))
+	case DesignChebyshev1:
+		ripple := firstNonZero(fp.Ripple, defaultFilterRipple)
+		return sosFilterResult(Chebyshev1(order, ripple, fp.FilterType, sampleRate, cutoffs...))
+	case DesignFIR:
+		return fp.designFIR(sampleRate, cutoffs)
+	}
+	return nil, fmt.Errorf("不支持的滤波器设计方法: %s", fp.Design)
+}
+
+// 设计FIR滤波器
+func (fp FilterParams) designFIR(sampleRate float64, cutoffs []float64) (LinearFilter, error) {
+	var filter *FIRFilter
+	var err error
+
+	if fp.NumTaps > 0 {
+		windowName := fp.Window
+		if windowName == "" {
+			windowName = defaultFIRWindow
+		}
+		filter, err = FIRWindow(fp.NumTaps, fp.FilterType, windowName, sampleRate, cutoffs...)
+	} else {
+		lowest := cutoffs[0]
+		for _, f := range cutoffs {
+			lowest = math.Min(lowest, f)
+		}
+		attenuation := firstNonZero(fp.Attenuation, defaultFIRAttenuation)
+		transition := firstNonZero(fp.Transition, defaultFIRTransitionFr*lowest)
+		filter, err = FIRKaiser(attenuation, transition, fp.FilterType, sampleRate, cutoffs...)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// 将二阶节滤波器设计结果转换为LinearFilter，出错时返回nil接口
+func sosFilterResult(filter *SOSFilter, err error) (LinearFilter, error) {
+	if err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// ApplyFilter 按参数设计滤波器并对通道数据滤波，结果写入ProcessedData
+func (p *Processor) ApplyFilter(channel *data.Channel, params FilterParams) error {
+	filter, err := params.DesignFilter(p.SampleRate)
+	if err != nil {
+		return err
+	}
+
+	return p.ApplyLinearFilter(channel, filter, params.ZeroPhase)
+}
+
+// ApplyLinearFilter 用给定的滤波器处理通道数据，zeroPhase为true时使用零相位滤波
+// 零相位滤波时数据点数须大于边缘延拓长度，否则返回错误且不修改ProcessedData。
+func (p *Processor) ApplyLinearFilter(channel *data.Channel, filter LinearFilter, zeroPhase bool) error {
+	if zeroPhase {
+		return applyToChannel(channel, func(x []float64) ([]float64, error) { return FiltFilt(filter, x) })
+	}
+	return applyToChannel(channel, func(x []float64) ([]float64, error) { return filter.Filter(x), nil })
+}
+
+// 对通道的Y值进行处理，结果与原X值一起写入ProcessedData
+func applyToChannel(channel *data.Channel, fn func([]float64) ([]float64, error)) error {
+	values := make([]float64, len(channel.Data))
+	for i, pt := range channel.Data {
+		values[i] = pt.Y
+	}
+
+	out, err := fn(values)
+	if err != nil {
+		return err
+	}
+
+	channel.ProcessedData = make([]data.DataPoint, len(channel.Data))
+	for i, pt := range channel.Data {
+		channel.ProcessedData[i] = data.DataPoint{X: pt.X, Y: out[i]}
+	}
+	return nil
+}
+
+// 返回第一个非零值
+func firstNonZero(values ...float64) float64 {
+	for _, v := range values {
+		if v != 0 {
+			return v
+		}
+	}
+	return 0
+}
@@ -0,0 +1,47 @@
+package signal
+
+import "fmt"
+
+// LinearFilter 表示可用于零相位滤波的线性时不变滤波器
+type LinearFilter interface {
+	// Filter 对x进行因果滤波，初始状态为零
+	Filter(x []float64) []float64
+	// FilterSteady 对x进行因果滤波，初始状态为输入恒为x[0]时的稳态
+	FilterSteady(x []float64) []float64
+	// Order 返回滤波器的阶数，用于确定边缘延拓的长度
+	Order() int
+}
+
+// FiltFilt 对x进行正向和反向两次滤波，得到零相位的输出
+// 与SciPy的filtfilt相同，两端先做长度为3*(阶数+1)的奇对称延拓，每次滤波的初始状态取延拓起点处的稳态，
+// 以抑制边缘瞬态。与SciPy相同，信号长度不大于延拓长度时返回错误。
+// 零相位滤波的幅频响应为原滤波器的平方，且需要完整的数据，只适用于离线分析。
+func FiltFilt(filter LinearFilter, x []float64) ([]float64, error) {
+	n := len(x)
+	padLen := 3 * (filter.Order() + 1)
+	if n <= padLen {
+		return nil, fmt.Errorf("零相位滤波的信号长度%d必须大于边缘延拓长度%d", n, padLen)
+	}
+
+	// 奇对称延拓：x[-i] = 2*x[0] - x[i]，x[n-1+i] = 2*x[n-1] - x[n-1-i]
+	ext := make([]float64, n+2*padLen)
+	for i := 0; i < padLen; i++ {
+		ext[i] = 2*x[0] - x[padLen-i]
+		ext[padLen+n+i] = 2*x[n-1] - x[n-2-i]
+	}
+	copy(ext[padLen:], x)
+
+	y := filter.FilterSteady(ext)
+	reverse(y)
+	y = filter.FilterSteady(y)
+	reverse(y)
+
+	return y[padLen : padLen+n], nil
+}
+
+// 原地反转切片
+func reverse(x []float64) {
+	for i, j := 0, len(x)-1; i < j; i, j = i+1, j-1 {
+		x[i], x[j] = x[j], x[i]
+	}
+}
@@ -0,0 +1,102 @@
+package signal
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+func TestFiltFilt(t *testing.T) {
+	filter, err := Butterworth(4, LowPass, 100, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x := make([]float64, 24)
+	for i := range x {
+		x[i] = math.Sin(0.9*float64(i)) + 0.05*float64(i)
+	}
+
+	// 参考值为scipy.signal.filtfilt(*butter(4, 0.2), x)
+	want := []float64{
+		0.007230167649, 0.08749166095, 0.1442272308, 0.1714229887, 0.184659302, 0.2092726615,
+		0.2612660209, 0.3357453231, 0.4118010418, 0.4699609502, 0.5084033857, 0.5447398072,
+		0.6010942859, 0.6833298369, 0.7706925398, 0.8255607276, 0.8189258588, 0.7557072978,
+		0.6831483073, 0.67576848, 0.8043575597, 1.104949487, 1.562090673, 2.111975609,
+	}
+	got, err := FiltFilt(filter, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !coefficientsNear(got, want, 1e-8) {
+		t.Errorf("FiltFilt = %v, want %v", got, want)
+	}
+
+	// 输入不被修改
+	if x[1] != math.Sin(0.9)+0.05 {
+		t.Error("FiltFilt修改了输入")
+	}
+}
+
+func TestFiltFiltZeroPhase(t *testing.T) {
+	filter, err := Butterworth(2, BandPass, 250, 5, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 对称的脉冲经零相位滤波后在脉冲附近仍对称，峰值位置不变
+	x := make([]float64, 401)
+	for i := range x {
+		d := float64(i-200) / 5
+		x[i] = math.Exp(-d * d)
+	}
+	y, err := FiltFilt(filter, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	peak := 0
+	for i := range y {
+		if y[i] > y[peak] {
+			peak = i
+		}
+	}
+	for d := 1; d <= 50; d++ {
+		if math.Abs(y[200-d]-y[200+d]) > 1e-6 {
+			t.Fatalf("输出不对称: y[%d] = %g, y[%d] = %g", 200-d, y[200-d], 200+d, y[200+d])
+		}
+	}
+	if peak != 200 {
+		t.Errorf("峰值位置 = %d, want 200", peak)
+	}
+}
+
+func TestFiltFiltShortInput(t *testing.T) {
+	// 4阶滤波器的延拓长度为3*(4+1)=15
+	filter, err := Butterworth(4, LowPass, 100, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{0, 1, 15} {
+		if _, err := FiltFilt(filter, make([]float64, n)); err == nil {
+			t.Errorf("长度为%d时应返回错误", n)
+		}
+	}
+	if y, err := FiltFilt(filter, make([]float64, 16)); err != nil || len(y) != 16 {
+		t.Errorf("FiltFilt(16) = %d个点, %v", len(y), err)
+	}
+
+	// 零相位滤波失败时不修改通道的处理结果
+	channel := data.NewChannel("0", "test")
+	for i := 0; i < 10; i++ {
+		channel.AddDataPoint(float64(i)/100, float64(i))
+	}
+	channel.ProcessedData = []data.DataPoint{{X: 0, Y: 42}}
+	err = NewProcessor(100).ApplyFilter(channel, FilterParams{FilterType: LowPass, Cutoff: 10, ZeroPhase: true})
+	if err == nil {
+		t.Error("数据点数不大于延拓长度时应返回错误")
+	}
+	if len(channel.ProcessedData) != 1 || channel.ProcessedData[0].Y != 42 {
+		t.Errorf("ProcessedData = %v", channel.ProcessedData)
+	}
+}
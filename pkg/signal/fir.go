@@ -0,0 +1,246 @@
+package signal
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"gonum.org/v1/gonum/dsp/fourier"
+)
+
+// 卷积核长度超过该值且信号长于卷积核时使用FFT重叠相加卷积
+const fftConvolveMinTaps = 64
+
+// FIRFilter 表示有限冲激响应滤波器
+type FIRFilter struct {
+	Taps []float64
+}
+
+// FIRWindow 用窗函数法设计线性相位FIR滤波器，与SciPy的firwin相同
+// numTaps为抽头数，高通和带阻要求为奇数；windowName见Window，凯泽窗使用默认β；
+// 截止频率为幅频响应下降到-6dB处的频率，个数要求同Butterworth。
+func FIRWindow(numTaps int, band, windowName string, sampleRate float64, cutoffs ...float64) (*FIRFilter, error) {
+	return firDesign(numTaps, band, windowName, 0, sampleRate, cutoffs)
+}
+
+// FIRKaiser 用凯泽窗设计FIR滤波器，抽头数和β由阻带衰减（dB）和过渡带宽（Hz）确定
+func FIRKaiser(attenuation, transition float64, band string, sampleRate float64, cutoffs ...float64) (*FIRFilter, error) {
+	if !(attenuation > 0) || !(transition > 0 && transition < sampleRate/2) {
+		return nil, fmt.Errorf("无效的阻带衰减%gdB或过渡带宽%gHz", attenuation, transition)
+	}
+
+	// 与SciPy的kaiserord相同的经验公式
+	width := transition / (sampleRate / 2)
+	numTaps := int(math.Ceil((attenuation-7.95)/(2.285*math.Pi*width) + 1))
+	if numTaps < 1 {
+		numTaps = 1
+	}
+	if band == HighPass || band == BandStop {
+		numTaps |= 1
+	}
+	return firDesign(numTaps, band, WindowKaiser, KaiserBeta(attenuation), sampleRate, cutoffs)
+}
+
+// 窗函数法设计FIR滤波器：理想频带响应的sinc冲激响应乘以窗函数，再在通带中心处归一化增益
+func firDesign(numTaps int, band, windowName string, beta, sampleRate float64, cutoffs []float64) (*FIRFilter, error) {
+	if numTaps < 1 {
+		return nil, fmt.Errorf("抽头数必须大于0: %d", numTaps)
+	}
+	if !(sampleRate > 0) {
+		return nil, fmt.Errorf("无效的采样率: %g", sampleRate)
+	}
+
+	// 以奈奎斯特频率归一化的通带边界
+	nyq := sampleRate / 2
+	norm := make([]float64, len(cutoffs))
+	for i, f := range cutoffs {
+		if !(f > 0 && f < nyq) {
+			return nil, fmt.Errorf("截止频率%gHz必须在0和奈奎斯特频率%gHz之间", f, nyq)
+		}
+		norm[i] = f / nyq
+	}
+
+	var bands [][2]float64
+	switch band {
+	case LowPass, HighPass:
+		if len(norm) != 1 {
+			return nil, fmt.Errorf("%s滤波器需要1个截止频率，实际为%d个", band, len(norm))
+		}
+		if band == LowPass {
+			bands = [][2]float64{{0, norm[0]}}
+		} else {
+			bands = [][2]float64{{norm[0], 1}}
+		}
+	case BandPass, BandStop:
+		if len(norm) != 2 {
+			return nil, fmt.Errorf("%s滤波器需要2个截止频率，实际为%d个", band, len(norm))
+		}
+		if norm[0] >= norm[1] {
+			return nil, fmt.Errorf("低截止频率%gHz必须小于高截止频率%gHz", cutoffs[0], cutoffs[1])
+		}
+		if band == BandPass {
+			bands = [][2]float64{{norm[0], norm[1]}}
+		} else {
+			bands = [][2]float64{{0, norm[0]}, {norm[1], 1}}
+		}
+	default:
+		return nil, fmt.Errorf("不支持的滤波器类型: %s", band)
+	}
+
+	// 通带包含奈奎斯特频率时，偶数抽头的滤波器在该处响应必为零
+	if bands[len(bands)-1][1] == 1 && numTaps%2 == 0 {
+		return nil, fmt.Errorf("%s滤波器的抽头数必须为奇数: %d", band, numTaps)
+	}
+
+	w, err := Window(windowName, numTaps, beta)
+	if err != nil {
+		return nil, err
+	}
+
+	center := float64(numTaps-1) / 2
+	taps := make([]float64, numTaps)
+	for i := range taps {
+		m := float64(i) - center
+		for _, b := range bands {
+			taps[i] += b[1]*sinc(b[1]*m) - b[0]*sinc(b[0]*m)
+		}
+		taps[i] *= w[i]
+	}
+
+	// 在第一个通带的中心（低通和带阻为直流，高通为奈奎斯特频率）归一化增益
+	first := bands[0]
+	scaleFreq := (first[0] + first[1]) / 2
+	switch {
+	case first[0] == 0:
+		scaleFreq = 0
+	case first[1] == 1:
+		scaleFreq = 1
+	}
+	gain := 0.0
+	for i, h := range taps {
+		gain += h * math.Cos(math.Pi*(float64(i)-center)*scaleFreq)
+	}
+	for i := range taps {
+		taps[i] /= gain
+	}
+
+	return &FIRFilter{Taps: taps}, nil
+}
+
+// 归一化sinc函数sin(πx)/(πx)
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// Filter 对x进行因果滤波，初始状态为零
+func (f *FIRFilter) Filter(x []float64) []float64 {
+	return Convolve(x, f.Taps)[:len(x)]
+}
+
+// FilterSteady 对x进行因果滤波，初始状态为输入恒为x[0]时的稳态
+func (f *FIRFilter) FilterSteady(x []float64) []float64 {
+	if len(x) == 0 {
+		return []float64{}
+	}
+
+	// 在开头补len(Taps)-1个x[0]，相当于此前的输入一直为x[0]
+	pad := len(f.Taps) - 1
+	ext := make([]float64, pad+len(x))
+	for i := 0; i < pad; i++ {
+		ext[i] = x[0]
+	}
+	copy(ext[pad:], x)
+
+	return Convolve(ext, f.Taps)[pad : pad+len(x)]
+}
+
+// Order 返回滤波器的阶数
+func (f *FIRFilter) Order() int {
+	return len(f.Taps) - 1
+}
+
+// Delay 返回线性相位滤波器的群延迟（样本数）
+func (f *FIRFilter) Delay() float64 {
+	return float64(len(f.Taps)-1) / 2
+}
+
+// FrequencyResponse 计算滤波器在各频率（Hz）处的复频率响应
+func (f *FIRFilter) FrequencyResponse(freqs []float64, sampleRate float64) []complex128 {
+	h := make([]complex128, len(freqs))
+	for i, freq := range freqs {
+		w := -2 * math.Pi * freq / sampleRate
+		for n, tap := range f.Taps {
+			h[i] += complex(tap, 0) * cmplx.Exp(complex(0, w*float64(n)))
+		}
+	}
+	return h
+}
+
+// Convolve 计算x与h的线性卷积，结果长度为len(x)+len(h)-1
+// h较长时使用FFT重叠相加法，否则直接计算。
+func Convolve(x, h []float64) []float64 {
+	if len(x) == 0 || len(h) == 0 {
+		return []float64{}
+	}
+	if len(h) > len(x) {
+		x, h = h, x
+	}
+	if len(h) <= fftConvolveMinTaps {
+		return directConvolve(x, h)
+	}
+	return overlapAddConvolve(x, h)
+}
+
+// 直接计算线性卷积
+func directConvolve(x, h []float64) []float64 {
+	y := make([]float64, len(x)+len(h)-1)
+	for i, xv := range x {
+		for j, hv := range h {
+			y[i+j] += xv * hv
+		}
+	}
+	return y
+}
+
+// FFT重叠相加卷积：把x分成长度为L的块，每块与h做长度为nfft的循环卷积后叠加
+func overlapAddConvolve(x, h []float64) []float64 {
+	nfft := 1
+	for nfft < 4*len(h) {
+		nfft *= 2
+	}
+	block := nfft - len(h) + 1
+
+	fft := fourier.NewFFT(nfft)
+	buf := make([]float64, nfft)
+	copy(buf, h)
+	hSpec := fft.Coefficients(nil, buf)
+
+	y := make([]float64, len(x)+len(h)-1)
+	spec := make([]complex128, len(hSpec))
+	for start := 0; start < len(x); start += block {
+		end := start + block
+		if end > len(x) {
+			end = len(x)
+		}
+
+		for i := range buf {
+			buf[i] = 0
+		}
+		copy(buf, x[start:end])
+		spec = fft.Coefficients(spec, buf)
+		for i := range spec {
+			spec[i] *= hSpec[i]
+		}
+		buf = fft.Sequence(buf, spec)
+
+		// 逆变换未归一化
+		n := end - start + len(h) - 1
+		for i := 0; i < n; i++ {
+			y[start+i] += buf[i] / float64(nfft)
+		}
+	}
+	return y
+}
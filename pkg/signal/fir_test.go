@@ -0,0 +1,247 @@
+package signal
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// 参考值来自scipy.signal.firwin（fs=2，cutoff为相对奈奎斯特频率的截止频率），这里采样率取100Hz
+func TestFIRWindowTaps(t *testing.T) {
+	tests := []struct {
+		name    string
+		numTaps int
+		band    string
+		window  string
+		cutoffs []float64
+		want    []float64
+	}{
+		{
+			name: "firwin(3, 0.1)", numTaps: 3, band: LowPass, window: WindowHamming, cutoffs: []float64{5},
+			want: []float64{0.06799016674, 0.8640196665, 0.06799016674},
+		},
+		{
+			name: "firwin(11, 0.2)", numTaps: 11, band: LowPass, window: WindowHamming, cutoffs: []float64{10},
+			want: []float64{0, 0.009304283145, 0.04757776613, 0.1223635464, 0.2022465584, 0.2370156919,
+				0.2022465584, 0.1223635464, 0.04757776613, 0.009304283145, 0},
+		},
+		{
+			name: "firwin(9, 0.4, window='hann', pass_zero=False)", numTaps: 9, band: HighPass, window: WindowHann, cutoffs: []float64{20},
+			want: []float64{0, 0.009088040861, -0.04654276855, -0.2571168487, 0.5970279213,
+				-0.2571168487, -0.04654276855, 0.009088040861, 0},
+		},
+		{
+			name: "firwin(9, [0.2, 0.5], pass_zero=False)", numTaps: 9, band: BandPass, window: WindowHamming, cutoffs: []float64{10, 25},
+			want: []float64{-0.006349867432, -0.07543260012, -0.1387030679, 0.1926596235, 0.5090812537,
+				0.1926596235, -0.1387030679, -0.07543260012, -0.006349867432},
+		},
+	}
+	for _, tt := range tests {
+		filter, err := FIRWindow(tt.numTaps, tt.band, tt.window, 100, tt.cutoffs...)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !coefficientsNear(filter.Taps, tt.want, 1e-9) {
+			t.Errorf("%s: Taps = %v, want %v", tt.name, filter.Taps, tt.want)
+		}
+		if filter.Order() != tt.numTaps-1 || filter.Delay() != float64(tt.numTaps-1)/2 {
+			t.Errorf("%s: Order = %d, Delay = %g", tt.name, filter.Order(), filter.Delay())
+		}
+	}
+}
+
+func TestFIRWindowResponse(t *testing.T) {
+	// 各种频带和窗函数在截止频率处的增益都约为-6dB
+	for _, band := range []string{LowPass, HighPass, BandPass, BandStop} {
+		cutoffs := []float64{40}
+		if band == BandPass || band == BandStop {
+			cutoffs = []float64{30, 80}
+		}
+		for _, window := range []string{WindowHamming, WindowHann, WindowBlackman, WindowKaiser} {
+			filter, err := FIRWindow(151, band, window, 500, cutoffs...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, f := range cutoffs {
+				if g := filterGainDB(filter, f, 500); math.Abs(g+6.02) > 0.3 {
+					t.Errorf("%s/%s在%gHz的增益 = %gdB", band, window, f, g)
+				}
+			}
+		}
+	}
+}
+
+func TestFIRKaiser(t *testing.T) {
+	// kaiserord(60, 0.04)为183个抽头，β=5.65326
+	filter, err := FIRKaiser(60, 10, LowPass, 500, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filter.Taps) != 183 {
+		t.Errorf("抽头数 = %d, want 183", len(filter.Taps))
+	}
+	if g := filterGainDB(filter, 0, 500); math.Abs(g) > 0.01 {
+		t.Errorf("直流增益 = %gdB", g)
+	}
+	for _, f := range []float64{45, 50, 100, 250} {
+		if g := filterGainDB(filter, f, 500); g > -59 {
+			t.Errorf("阻带%gHz的增益 = %gdB", f, g)
+		}
+	}
+
+	// 高通和带阻的抽头数为奇数
+	if filter, err := FIRKaiser(60, 10, HighPass, 500, 40); err != nil || len(filter.Taps)%2 != 1 {
+		t.Errorf("FIRKaiser高通: %v", err)
+	}
+
+	for attenuation, want := range map[float64]float64{60: 5.65326, 30: 2.116624861, 20: 0} {
+		if beta := KaiserBeta(attenuation); math.Abs(beta-want) > 1e-8 {
+			t.Errorf("KaiserBeta(%g) = %g, want %g", attenuation, beta, want)
+		}
+	}
+}
+
+func TestFIRDesignErrors(t *testing.T) {
+	tests := map[string]func() (*FIRFilter, error){
+		"抽头数为0":        func() (*FIRFilter, error) { return FIRWindow(0, LowPass, WindowHamming, 500, 40) },
+		"高通抽头数为偶数":     func() (*FIRFilter, error) { return FIRWindow(100, HighPass, WindowHann, 500, 40) },
+		"带阻抽头数为偶数":     func() (*FIRFilter, error) { return FIRWindow(100, BandStop, WindowHann, 500, 30, 80) },
+		"窗函数":          func() (*FIRFilter, error) { return FIRWindow(101, LowPass, "triangle", 500, 40) },
+		"截止频率超过奈奎斯特频率": func() (*FIRFilter, error) { return FIRWindow(101, LowPass, WindowHann, 500, 250) },
+		"截止频率个数":       func() (*FIRFilter, error) { return FIRWindow(101, BandPass, WindowHann, 500, 40) },
+		"阻带衰减为0":       func() (*FIRFilter, error) { return FIRKaiser(0, 10, LowPass, 500, 40) },
+		"过渡带宽超过奈奎斯特频率": func() (*FIRFilter, error) { return FIRKaiser(60, 300, LowPass, 500, 40) },
+	}
+	for name, design := range tests {
+		if _, err := design(); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+}
+
+func TestFIRFilter(t *testing.T) {
+	filter, err := FIRWindow(101, LowPass, WindowHamming, 500, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 恒定输入从稳态开始时输出没有瞬态
+	x := make([]float64, 600)
+	for i := range x {
+		x[i] = 3
+	}
+	for i, y := range filter.FilterSteady(x) {
+		if math.Abs(y-3) > 1e-9 {
+			t.Fatalf("FilterSteady第%d个输出 = %g", i, y)
+		}
+	}
+
+	// 因果滤波把通带内的正弦延迟Delay个样本，零相位滤波不延迟
+	for i := range x {
+		x[i] = math.Sin(2 * math.Pi * 5 * float64(i) / 500)
+	}
+	y := filter.Filter(x)
+	zero, err := FiltFilt(filter, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	delay := int(filter.Delay())
+	for i := 200; i < 400; i++ {
+		if math.Abs(y[i]-x[i-delay]) > 0.01 || math.Abs(zero[i]-x[i]) > 0.01 {
+			t.Fatalf("第%d个输出: 因果%g, 零相位%g, 输入%g", i, y[i], zero[i], x[i])
+		}
+	}
+}
+
+func TestConvolve(t *testing.T) {
+	if got := Convolve([]float64{1, 2, 3}, []float64{0, 1, 0.5}); !coefficientsNear(got, []float64{0, 1, 2.5, 4, 1.5}, 1e-12) {
+		t.Errorf("Convolve = %v", got)
+	}
+	if got := Convolve(nil, []float64{1}); len(got) != 0 {
+		t.Errorf("Convolve(nil) = %v", got)
+	}
+
+	// 长卷积核使用FFT重叠相加，结果与直接计算相同，且与参数顺序无关
+	rng := rand.New(rand.NewSource(1))
+	x := make([]float64, 5000)
+	for i := range x {
+		x[i] = rng.NormFloat64()
+	}
+	h := make([]float64, 300)
+	for i := range h {
+		h[i] = rng.NormFloat64()
+	}
+	want := directConvolve(x, h)
+	if got := Convolve(x, h); !coefficientsNear(got, want, 1e-9) {
+		t.Error("FFT卷积与直接卷积结果不同")
+	}
+	if got := Convolve(h, x); !coefficientsNear(got, want, 1e-9) {
+		t.Error("交换参数后结果不同")
+	}
+}
+
+func TestWindow(t *testing.T) {
+	// 对称窗两端相等，周期窗为长度加1的对称窗去掉最后一点
+	w, err := Window(WindowHann, 5, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !coefficientsNear(w, []float64{0, 0.5, 1, 0.5, 0}, 1e-12) {
+		t.Errorf("Window(hann, 5) = %v", w)
+	}
+	p, err := PeriodicWindow(WindowHann, 4, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !coefficientsNear(p, []float64{0, 0.5, 1, 0.5}, 1e-12) {
+		t.Errorf("PeriodicWindow(hann, 4) = %v", p)
+	}
+
+	// 凯泽窗β=0时为矩形窗，Window中β为0时使用默认值
+	if k := KaiserWindow(4, 0); !coefficientsNear(k, []float64{1, 1, 1, 1}, 1e-12) {
+		t.Errorf("KaiserWindow(4, 0) = %v", k)
+	}
+	k, err := Window(WindowKaiser, 7, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !coefficientsNear(k, KaiserWindow(7, defaultKaiserBeta), 1e-12) || k[3] != 1 {
+		t.Errorf("Window(kaiser, 7) = %v", k)
+	}
+
+	if _, err := Window("triangle", 5, 0); err == nil {
+		t.Error("不支持的窗函数应返回错误")
+	}
+	if _, err := Window(WindowHann, 0, 0); err == nil {
+		t.Error("长度为0时应返回错误")
+	}
+}
+
+func TestFilterParamsFIR(t *testing.T) {
+	// 指定抽头数时用窗函数法设计
+	filter, err := FilterParams{FilterType: BandPass, Design: DesignFIR, NumTaps: 201, Window: WindowBlackman, LowCutoff: 1, HighCutoff: 40}.DesignFilter(500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fir, ok := filter.(*FIRFilter); !ok || fir.Order() != 200 {
+		t.Errorf("DesignFilter = %T, Order = %d", filter, filter.Order())
+	}
+
+	// 未指定抽头数时用凯泽窗设计，过渡带宽默认为最低截止频率的0.2倍
+	filter, err = FilterParams{FilterType: HighPass, Design: DesignFIR, Cutoff: 5}.DesignFilter(500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := FIRKaiser(defaultFIRAttenuation, 1, HighPass, 500, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fir, ok := filter.(*FIRFilter); !ok || !coefficientsNear(fir.Taps, want.Taps, 1e-12) {
+		t.Errorf("DesignFilter = %T, Order = %d, want %d", filter, filter.Order(), want.Order())
+	}
+
+	if filter, err := (FilterParams{FilterType: HighPass, Design: DesignFIR, NumTaps: 100, Cutoff: 5}).DesignFilter(500); err == nil || filter != nil {
+		t.Errorf("DesignFilter = %v, %v，应返回错误", filter, err)
+	}
+}
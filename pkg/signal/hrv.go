@@ -0,0 +1,313 @@
+package signal
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+// HRV分析的参数
+const (
+	minNNInterval      = 0.3       // 生理上合理的最短RR间期（秒）
+	maxNNInterval      = 2.0       // 生理上合理的最长RR间期（秒）
+	maxNNChange        = 0.2       // 与上一正常间期相差超过该比例的间期视为异位搏动或误检
+	maxNNRejects       = 3         // 因变化过大连续排除的最多间期数
+	triangularBinWidth = 1.0 / 128 // HRV三角指数直方图的组距（秒），与1/128秒的采样间隔对应
+	minSpectralSpan    = 120.0     // 计算频域指标所需的最短记录时长（秒）
+	lombFreqStep       = 0.001     // Lomb-Scargle周期图的频率间隔（Hz）
+)
+
+// HRV频带边界（Hz）
+const (
+	VLFLow  = 0.0033
+	VLFHigh = 0.04
+	LFHigh  = 0.15
+	HFHigh  = 0.4
+)
+
+// NNInterval 表示两次正常心搏之间的间期
+type NNInterval struct {
+	Time     float64 // 间期结束的心搏时间（秒）
+	Interval float64 // 间期长度（秒）
+}
+
+// HRVTimeDomain 表示心率变异性的时域和Poincaré图指标，时间单位为毫秒
+type HRVTimeDomain struct {
+	MeanNN          float64 `json:"mean_nn"`
+	MeanHR          float64 `json:"mean_hr"` // 平均心率（次/分）
+	SDNN            float64 `json:"sdnn"`
+	RMSSD           float64 `json:"rmssd"`
+	NN50            int     `json:"nn50"`
+	PNN50           float64 `json:"pnn50"`            // 相邻间期相差超过50ms的比例（%）
+	TriangularIndex float64 `json:"triangular_index"` // 间期总数除以直方图最高组的频数
+	SD1             float64 `json:"sd1"`
+	SD2             float64 `json:"sd2"`
+	SD1SD2          float64 `json:"sd1_sd2"`
+}
+
+// HRVFrequencyDomain 表示心率变异性的频域指标，功率单位为ms²
+type HRVFrequencyDomain struct {
+	VLF        float64 `json:"vlf"`
+	LF         float64 `json:"lf"`
+	HF         float64 `json:"hf"`
+	TotalPower float64 `json:"total_power"` // VLF到HF上限的总功率
+	LFHF       float64 `json:"lf_hf"`
+	LFNu       float64 `json:"lf_nu"` // 标准化LF功率，LF/(LF+HF)*100
+	HFNu       float64 `json:"hf_nu"`
+	PeakLF     float64 `json:"peak_lf"` // LF频带内的谱峰频率（Hz）
+	PeakHF     float64 `json:"peak_hf"`
+}
+
+// HRVResult 表示一段信号的心率变异性分析结果
+type HRVResult struct {
+	StartTime       float64             `json:"start_time"`
+	EndTime         float64             `json:"end_time"`
+	Beats           int                 `json:"beats"`
+	NNIntervals     int                 `json:"nn_intervals"`
+	Excluded        int                 `json:"excluded"` // 被排除的RR间期数（跨越间断、超出生理范围或异位搏动）
+	TimeDomain      *HRVTimeDomain      `json:"time_domain,omitempty"`
+	FrequencyDomain *HRVFrequencyDomain `json:"frequency_domain,omitempty"`
+	Warnings        []string            `json:"warnings,omitempty"`
+}
+
+// NNIntervals 由心搏计算正常RR间期
+// 跨越数据间断、超出0.3~2秒范围或与上一正常间期相差超过20%的间期被排除，返回值excluded为排除的个数。
+// 连续3个间期都因变化过大被排除时认为心率发生了真实的改变，从下一个间期重新开始比较。
+func NNIntervals(beats []Beat, channel *data.Channel) (nn []NNInterval, excluded int) {
+	changed := 0
+	for i := 1; i < len(beats); i++ {
+		t0, t1 := beats[i-1].Time, beats[i].Time
+		rr := t1 - t0
+		if (channel != nil && channel.SpansGap(t0, t1)) || rr < minNNInterval || rr > maxNNInterval {
+			excluded++
+			continue
+		}
+		if n := len(nn); n > 0 && changed < maxNNRejects && math.Abs(rr-nn[n-1].Interval) > maxNNChange*nn[n-1].Interval {
+			excluded++
+			changed++
+			continue
+		}
+		changed = 0
+		nn = append(nn, NNInterval{Time: t1, Interval: rr})
+	}
+	return nn, excluded
+}
+
+// HRVTimeDomainMetrics 计算时域指标，至少需要3个间期
+func HRVTimeDomainMetrics(nn []NNInterval) (*HRVTimeDomain, error) {
+	if len(nn) < 3 {
+		return nil, fmt.Errorf("正常RR间期数%d太少，无法计算时域指标", len(nn))
+	}
+
+	ms := make([]float64, len(nn))
+	for i, v := range nn {
+		ms[i] = v.Interval * 1000
+	}
+	diffs := make([]float64, len(ms)-1)
+	for i := range diffs {
+		diffs[i] = ms[i+1] - ms[i]
+	}
+
+	td := &HRVTimeDomain{}
+	td.MeanNN = mean(ms)
+	td.MeanHR = 60000 / td.MeanNN
+	td.SDNN = math.Sqrt(variance(ms))
+
+	sumSq := 0.0
+	for _, d := range diffs {
+		sumSq += d * d
+		if math.Abs(d) > 50 {
+			td.NN50++
+		}
+	}
+	td.RMSSD = math.Sqrt(sumSq / float64(len(diffs)))
+	td.PNN50 = float64(td.NN50) / float64(len(diffs)) * 100
+
+	// 三角指数：间期直方图的总频数除以最高组的频数
+	counts := make(map[int]int)
+	maxCount := 0
+	for _, v := range nn {
+		bin := int(math.Floor(v.Interval / triangularBinWidth))
+		counts[bin]++
+		if counts[bin] > maxCount {
+			maxCount = counts[bin]
+		}
+	}
+	td.TriangularIndex = float64(len(nn)) / float64(maxCount)
+
+	// Poincaré图：SD1²=Var(ΔRR)/2，SD2²=2·SDNN²-Var(ΔRR)/2
+	varDiff := variance(diffs)
+	td.SD1 = math.Sqrt(varDiff / 2)
+	td.SD2 = math.Sqrt(math.Max(0, 2*td.SDNN*td.SDNN-varDiff/2))
+	if td.SD2 > 0 {
+		td.SD1SD2 = td.SD1 / td.SD2
+	}
+	return td, nil
+}
+
+// HRVFrequencyDomainMetrics 用Lomb-Scargle周期图计算频域指标
+// Lomb-Scargle方法直接处理不等间隔的RR序列，不需要重采样。记录时长至少需要2分钟。
+func HRVFrequencyDomainMetrics(nn []NNInterval) (*HRVFrequencyDomain, error) {
+	if len(nn) < 3 {
+		return nil, fmt.Errorf("正常RR间期数%d太少，无法计算频域指标", len(nn))
+	}
+	span := nn[len(nn)-1].Time - nn[0].Time
+	if span < minSpectralSpan {
+		return nil, fmt.Errorf("记录时长%.0f秒不足%.0f秒，无法计算频域指标", span, minSpectralSpan)
+	}
+
+	times := make([]float64, len(nn))
+	values := make([]float64, len(nn))
+	for i, v := range nn {
+		times[i] = v.Time
+		values[i] = v.Interval * 1000
+	}
+
+	freqs := make([]float64, int(math.Round(HFHigh/lombFreqStep)))
+	for i := range freqs {
+		freqs[i] = float64(i+1) * lombFreqStep
+	}
+
+	// 周期图换算为单边功率谱密度（ms²/Hz），使各频带功率之和近似等于方差
+	psd := LombScargle(times, values, freqs)
+	meanInterval := span / float64(len(nn)-1)
+	for i := range psd {
+		psd[i] *= 2 * meanInterval
+	}
+
+	fd := &HRVFrequencyDomain{
+		VLF: bandPower(freqs, psd, VLFLow, VLFHigh),
+		LF:  bandPower(freqs, psd, VLFHigh, LFHigh),
+		HF:  bandPower(freqs, psd, LFHigh, HFHigh),
+	}
+	fd.TotalPower = fd.VLF + fd.LF + fd.HF
+	if fd.HF > 0 {
+		fd.LFHF = fd.LF / fd.HF
+	}
+	if fd.LF+fd.HF > 0 {
+		fd.LFNu = fd.LF / (fd.LF + fd.HF) * 100
+		fd.HFNu = fd.HF / (fd.LF + fd.HF) * 100
+	}
+	fd.PeakLF = peakFrequency(freqs, psd, VLFHigh, LFHigh)
+	fd.PeakHF = peakFrequency(freqs, psd, LFHigh, HFHigh)
+	return fd, nil
+}
+
+// LombScargle 计算不等间隔采样序列在各频率（Hz）处的Lomb-Scargle周期图
+// 计算前减去均值，结果为未归一化的功率：P(f) = [(Σy·cos)²/Σcos² + (Σy·sin)²/Σsin²] / 2。
+func LombScargle(times, values, freqs []float64) []float64 {
+	m := mean(values)
+	p := make([]float64, len(freqs))
+	for k, f := range freqs {
+		w := 2 * math.Pi * f
+		if w == 0 {
+			continue
+		}
+
+		// 时间偏移τ使正弦项和余弦项正交
+		var s2, c2 float64
+		for _, t := range times {
+			s2 += math.Sin(2 * w * t)
+			c2 += math.Cos(2 * w * t)
+		}
+		tau := math.Atan2(s2, c2) / (2 * w)
+
+		var yc, ys, cc, ss float64
+		for i, t := range times {
+			s, c := math.Sincos(w * (t - tau))
+			y := values[i] - m
+			yc += y * c
+			ys += y * s
+			cc += c * c
+			ss += s * s
+		}
+		if cc > 0 {
+			p[k] += yc * yc / cc
+		}
+		if ss > 0 {
+			p[k] += ys * ys / ss
+		}
+		p[k] /= 2
+	}
+	return p
+}
+
+// 对等间隔频率上的功率谱密度求和，得到频带[lo, hi)内的功率
+func bandPower(freqs, psd []float64, lo, hi float64) float64 {
+	if len(freqs) < 2 {
+		return 0
+	}
+	df := freqs[1] - freqs[0]
+	power := 0.0
+	for i, f := range freqs {
+		if f >= lo && f < hi {
+			power += psd[i] * df
+		}
+	}
+	return power
+}
+
+// 返回频带[lo, hi]内功率谱密度最大处的频率
+func peakFrequency(freqs, psd []float64, lo, hi float64) float64 {
+	peak, best := 0.0, -1.0
+	for i, f := range freqs {
+		if f >= lo && f <= hi && psd[i] > best {
+			peak, best = f, psd[i]
+		}
+	}
+	return peak
+}
+
+// 平均值
+func mean(x []float64) float64 {
+	if len(x) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range x {
+		sum += v
+	}
+	return sum / float64(len(x))
+}
+
+// 样本方差（除以n-1）
+func variance(x []float64) float64 {
+	if len(x) < 2 {
+		return 0
+	}
+	m := mean(x)
+	sum := 0.0
+	for _, v := range x {
+		sum += (v - m) * (v - m)
+	}
+	return sum / float64(len(x)-1)
+}
+
+// AnalyzeHRV 检测通道中的心搏并计算心率变异性指标
+// 时域指标至少需要3个正常间期；记录短于2分钟时不计算频域指标，并在Warnings中说明。
+func (p *Processor) AnalyzeHRV(channel *data.Channel) (*HRVResult, error) {
+	beats, err := p.DetectQRS(channel)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &HRVResult{Beats: len(beats)}
+	if n := len(channel.Data); n > 0 {
+		result.StartTime = channel.Data[0].X
+		result.EndTime = channel.Data[n-1].X
+	}
+
+	nn, excluded := NNIntervals(beats, channel)
+	result.NNIntervals = len(nn)
+	result.Excluded = excluded
+
+	result.TimeDomain, err = HRVTimeDomainMetrics(nn)
+	if err != nil {
+		return nil, err
+	}
+	if result.FrequencyDomain, err = HRVFrequencyDomainMetrics(nn); err != nil {
+		result.Warnings = append(result.Warnings, err.Error())
+	}
+	return result, nil
+}
@@ -0,0 +1,176 @@
+package signal
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+// 由依次的间期（秒）生成从start开始的心搏
+func beatsFromIntervals(start float64, intervals ...float64) []Beat {
+	beats := []Beat{{Time: start}}
+	for _, rr := range intervals {
+		start += rr
+		beats = append(beats, Beat{Time: start})
+	}
+	return beats
+}
+
+// 返回各间期的长度
+func nnLengths(nn []NNInterval) []float64 {
+	lengths := make([]float64, len(nn))
+	for i, v := range nn {
+		lengths[i] = v.Interval
+	}
+	return lengths
+}
+
+func TestNNIntervals(t *testing.T) {
+	tests := []struct {
+		name      string
+		intervals []float64
+		want      []float64
+		excluded  int
+	}{
+		{"全部正常", []float64{0.8, 0.82, 0.79}, []float64{0.8, 0.82, 0.79}, 0},
+		{"超出生理范围", []float64{0.8, 0.25, 0.8, 2.5, 0.8}, []float64{0.8, 0.8, 0.8}, 2},
+		{"早搏及代偿间期", []float64{0.8, 0.8, 0.45, 1.15, 0.8}, []float64{0.8, 0.8, 0.8}, 2},
+		{"连续3个间期变化过大后重新开始比较", []float64{0.8, 0.5, 0.5, 0.5, 0.5, 0.5}, []float64{0.8, 0.5, 0.5}, 3},
+	}
+	for _, tt := range tests {
+		nn, excluded := NNIntervals(beatsFromIntervals(1, tt.intervals...), nil)
+		if got := nnLengths(nn); !coefficientsNear(got, tt.want, 1e-9) || excluded != tt.excluded {
+			t.Errorf("%s: NNIntervals = %v, %d, want %v, %d", tt.name, got, excluded, tt.want, tt.excluded)
+		}
+	}
+
+	// 跨越数据间断的间期被排除，Time为间期结束的心搏时间
+	channel := data.NewChannel("0", "ECG")
+	channel.Gaps = []data.Gap{{Start: 2.7, End: 2.8}}
+	nn, excluded := NNIntervals(beatsFromIntervals(1, 0.8, 0.8, 0.8, 0.8), channel)
+	if excluded != 1 || len(nn) != 3 || math.Abs(nn[2].Time-4.2) > 1e-9 {
+		t.Errorf("NNIntervals = %+v, %d", nn, excluded)
+	}
+}
+
+func TestHRVTimeDomainMetrics(t *testing.T) {
+	var nn []NNInterval
+	for _, ms := range []float64{800, 820, 880, 840, 800, 780, 810} {
+		nn = append(nn, NNInterval{Interval: ms / 1000})
+	}
+	td, err := HRVTimeDomainMetrics(nn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []float64{td.MeanNN, td.MeanHR, td.SDNN, td.RMSSD, float64(td.NN50), td.PNN50, td.TriangularIndex, td.SD1, td.SD2, td.SD1SD2}
+	want := []float64{818.5714285714286, 73.29842931937173, 32.877840272018794, 37.63863263545405, 1, 100.0 / 6, 3.5,
+		29.126162351626988, 36.24322596805406, 0.8036305150457556}
+	if !coefficientsNear(got, want, 1e-9) {
+		t.Errorf("HRVTimeDomainMetrics = %+v", *td)
+	}
+
+	if _, err := HRVTimeDomainMetrics(nn[:2]); err == nil {
+		t.Error("间期数少于3时应返回错误")
+	}
+}
+
+// 间期受0.1Hz（幅度30ms）和0.25Hz（幅度20ms）正弦调制的心搏序列
+func modulatedBeats(seconds float64) []Beat {
+	rng := rand.New(rand.NewSource(1))
+	var beats []Beat
+	for t := 1.0; t < seconds; {
+		beats = append(beats, Beat{Time: t})
+		t += 0.8 + 0.03*math.Sin(2*math.Pi*0.1*t) + 0.02*math.Sin(2*math.Pi*0.25*t) + 0.002*rng.NormFloat64()
+	}
+	return beats
+}
+
+func TestHRVFrequencyDomainMetrics(t *testing.T) {
+	nn, excluded := NNIntervals(modulatedBeats(600), nil)
+	if excluded != 0 {
+		t.Fatalf("排除了%d个间期", excluded)
+	}
+	fd, err := HRVFrequencyDomainMetrics(nn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 正弦调制的功率为幅度平方的一半：LF约450ms²，HF约200ms²
+	if math.Abs(fd.LF-450)/450 > 0.15 || math.Abs(fd.HF-200)/200 > 0.15 {
+		t.Errorf("LF = %g, HF = %g", fd.LF, fd.HF)
+	}
+	if math.Abs(fd.PeakLF-0.1) > 0.003 || math.Abs(fd.PeakHF-0.25) > 0.003 {
+		t.Errorf("PeakLF = %g, PeakHF = %g", fd.PeakLF, fd.PeakHF)
+	}
+	if math.Abs(fd.TotalPower-(fd.VLF+fd.LF+fd.HF)) > 1e-9 || math.Abs(fd.LFHF-fd.LF/fd.HF) > 1e-9 || math.Abs(fd.LFNu+fd.HFNu-100) > 1e-9 {
+		t.Errorf("HRVFrequencyDomainMetrics = %+v", *fd)
+	}
+
+	// 时域方差与各频带功率之和相近
+	td, err := HRVTimeDomainMetrics(nn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := fd.TotalPower / (td.SDNN * td.SDNN); r < 0.9 || r > 1.1 {
+		t.Errorf("总功率%g与方差%g不符", fd.TotalPower, td.SDNN*td.SDNN)
+	}
+
+	short, _ := NNIntervals(modulatedBeats(100), nil)
+	if _, err := HRVFrequencyDomainMetrics(short); err == nil {
+		t.Error("记录短于2分钟时应返回错误")
+	}
+}
+
+func TestLombScargle(t *testing.T) {
+	// 整数个周期的等间隔余弦，在其频率处的功率为N·A²/4，均值不影响结果
+	n := 200
+	times := make([]float64, n)
+	values := make([]float64, n)
+	for i := range times {
+		times[i] = float64(i) * 0.5
+		values[i] = 5 + 3*math.Cos(2*math.Pi*0.1*times[i])
+	}
+	p := LombScargle(times, values, []float64{0, 0.1, 0.35})
+	if p[0] != 0 || math.Abs(p[1]-float64(n)*9/4) > 1e-6 || p[2] > 1e-6 {
+		t.Errorf("LombScargle = %v", p)
+	}
+}
+
+func TestAnalyzeHRV(t *testing.T) {
+	x, ref := synthECG(250, 180, 7, false)
+	channel := data.NewChannel("0", "ECG")
+	for i, v := range x {
+		channel.AddDataPoint(float64(i)/250, v)
+	}
+	result, err := NewProcessor(250).AnalyzeHRV(channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.StartTime != 0 || result.EndTime != channel.Data[len(x)-1].X || result.Beats < len(ref)-2 || result.NNIntervals+result.Excluded != result.Beats-1 {
+		t.Errorf("AnalyzeHRV = %+v", *result)
+	}
+	if result.TimeDomain == nil || result.FrequencyDomain == nil || len(result.Warnings) != 0 {
+		t.Fatalf("AnalyzeHRV = %+v", *result)
+	}
+	if math.Abs(result.TimeDomain.MeanNN-800) > 30 {
+		t.Errorf("MeanNN = %g", result.TimeDomain.MeanNN)
+	}
+
+	// 短记录只计算时域指标
+	channel.Data = channel.Data[:60*250]
+	result, err = NewProcessor(250).AnalyzeHRV(channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TimeDomain == nil || result.FrequencyDomain != nil || len(result.Warnings) != 1 {
+		t.Errorf("短记录: %+v", *result)
+	}
+
+	channel.Data = channel.Data[:250]
+	if _, err := NewProcessor(250).AnalyzeHRV(channel); err == nil {
+		t.Error("心搏太少时应返回错误")
+	}
+}
@@ -0,0 +1,420 @@
+package signal
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+)
+
+// 滤波器频带类型
+const (
+	LowPass  = "lowpass"
+	HighPass = "highpass"
+	BandPass = "bandpass"
+	BandStop = "bandstop"
+)
+
+// Biquad 表示一个二阶节，传递函数为
+// H(z) = (B0 + B1·z⁻¹ + B2·z⁻²) / (1 + A1·z⁻¹ + A2·z⁻²)
+type Biquad struct {
+	B0, B1, B2 float64
+	A1, A2     float64
+}
+
+// SOSFilter 表示由二阶节级联而成的IIR滤波器
+type SOSFilter struct {
+	Sections []Biquad
+}
+
+// zpk 表示由零点、极点和增益描述的传递函数
+type zpk struct {
+	z []complex128
+	p []complex128
+	k float64
+}
+
+// Butterworth 设计order阶巴特沃斯滤波器
+// 低通和高通需要一个截止频率，带通和带阻需要低、高两个截止频率（Hz）。
+// 带通和带阻滤波器的实际阶数为2*order。
+func Butterworth(order int, band string, sampleRate float64, cutoffs ...float64) (*SOSFilter, error) {
+	if order < 1 {
+		return nil, fmt.Errorf("滤波器阶数必须大于0: %d", order)
+	}
+
+	proto := zpk{k: 1}
+	for k := 0; k < order; k++ {
+		theta := math.Pi * float64(2*k+order+1) / float64(2*order)
+		proto.p = append(proto.p, cmplx.Exp(complex(0, theta)))
+	}
+	return designIIR(proto, band, sampleRate, cutoffs)
+}
+
+// Chebyshev1 设计order阶切比雪夫I型滤波器，ripple为通带纹波（dB）
+// 截止频率为增益下降到-ripple dB处的频率，参数含义同Butterworth。
+func Chebyshev1(order int, ripple float64, band string, sampleRate float64, cutoffs ...float64) (*SOSFilter, error) {
+	if order < 1 {
+		return nil, fmt.Errorf("滤波器阶数必须大于0: %d", order)
+	}
+	if !(ripple > 0) {
+		return nil, fmt.Errorf("通带纹波必须大于0: %g", ripple)
+	}
+
+	eps := math.Sqrt(math.Pow(10, ripple/10) - 1)
+	mu := math.Asinh(1/eps) / float64(order)
+
+	proto := zpk{k: 1}
+	prod := complex(1, 0)
+	for k := 0; k < order; k++ {
+		theta := math.Pi * float64(2*k+1) / float64(2*order)
+		p := complex(-math.Sinh(mu)*math.Sin(theta), math.Cosh(mu)*math.Cos(theta))
+		proto.p = append(proto.p, p)
+		prod *= -p
+	}
+	proto.k = real(prod)
+	// 偶数阶时直流增益为通带纹波的下限
+	if order%2 == 0 {
+		proto.k /= math.Sqrt(1 + eps*eps)
+	}
+	return designIIR(proto, band, sampleRate, cutoffs)
+}
+
+// 将归一化的模拟低通原型变换为所需频带，再经双线性变换得到数字滤波器
+func designIIR(proto zpk, band string, sampleRate float64, cutoffs []float64) (*SOSFilter, error) {
+	if !(sampleRate > 0) {
+		return nil, fmt.Errorf("无效的采样率: %g", sampleRate)
+	}
+
+	want := 1
+	if band == BandPass || band == BandStop {
+		want = 2
+	} else if band != LowPass && band != HighPass {
+		return nil, fmt.Errorf("不支持的滤波器类型: %s", band)
+	}
+	if len(cutoffs) != want {
+		return nil, fmt.Errorf("%s滤波器需要%d个截止频率，实际为%d个", band, want, len(cutoffs))
+	}
+
+	// 预畸变截止频率，使数字滤波器的截止频率准确
+	warped := make([]float64, len(cutoffs))
+	for i, f := range cutoffs {
+		if !(f > 0 && f < sampleRate/2) {
+			return nil, fmt.Errorf("截止频率%gHz必须在0和奈奎斯特频率%gHz之间", f, sampleRate/2)
+		}
+		warped[i] = 2 * sampleRate * math.Tan(math.Pi*f/sampleRate)
+	}
+	if want == 2 && cutoffs[0] >= cutoffs[1] {
+		return nil, fmt.Errorf("低截止频率%gHz必须小于高截止频率%gHz", cutoffs[0], cutoffs[1])
+	}
+
+	var analog zpk
+	switch band {
+	case LowPass:
+		analog = proto.lowPassToLowPass(warped[0])
+	case HighPass:
+		analog = proto.lowPassToHighPass(warped[0])
+	case BandPass:
+		analog = proto.lowPassToBandPass(math.Sqrt(warped[0]*warped[1]), warped[1]-warped[0])
+	case BandStop:
+		analog = proto.lowPassToBandStop(math.Sqrt(warped[0]*warped[1]), warped[1]-warped[0])
+	}
+
+	return analog.bilinear(sampleRate).sections(), nil
+}
+
+// 低通到低通变换
+func (f zpk) lowPassToLowPass(wo float64) zpk {
+	degree := len(f.p) - len(f.z)
+	out := zpk{k: f.k * math.Pow(wo, float64(degree))}
+	for _, z := range f.z {
+		out.z = append(out.z, z*complex(wo, 0))
+	}
+	for _, p := range f.p {
+		out.p = append(out.p, p*complex(wo, 0))
+	}
+	return out
+}
+
+// 低通到高通变换，原型的无穷远零点变为原点处的零点
+func (f zpk) lowPassToHighPass(wo float64) zpk {
+	out := zpk{k: f.k * real(prodNeg(f.z)/prodNeg(f.p))}
+	for _, z := range f.z {
+		out.z = append(out.z, complex(wo, 0)/z)
+	}
+	for _, p := range f.p {
+		out.p = append(out.p, complex(wo, 0)/p)
+	}
+	for i := len(f.z); i < len(f.p); i++ {
+		out.z = append(out.z, 0)
+	}
+	return out
+}
+
+// 低通到带通变换，wo为中心角频率，bw为带宽
+func (f zpk) lowPassToBandPass(wo, bw float64) zpk {
+	degree := len(f.p) - len(f.z)
+	out := zpk{k: f.k * math.Pow(bw, float64(degree))}
+	out.z = splitRoots(f.z, complex(bw/2, 0), wo)
+	out.p = splitRoots(f.p, complex(bw/2, 0), wo)
+	for i := 0; i < degree; i++ {
+		out.z = append(out.z, 0)
+	}
+	return out
+}
+
+// 低通到带阻变换，原型的无穷远零点变为±j·wo处的零点
+func (f zpk) lowPassToBandStop(wo, bw float64) zpk {
+	degree := len(f.p) - len(f.z)
+	out := zpk{k: f.k * real(prodNeg(f.z)/prodNeg(f.p))}
+
+	inv := func(roots []complex128) []complex128 {
+		res := make([]complex128, len(roots))
+		for i, r := range roots {
+			res[i] = complex(bw/2, 0) / r
+		}
+		return res
+	}
+	out.z = splitRoots(inv(f.z), 1, wo)
+	out.p = splitRoots(inv(f.p), 1, wo)
+	for i := 0; i < degree; i++ {
+		out.z = append(out.z, complex(0, wo), complex(0, -wo))
+	}
+	return out
+}
+
+// 双线性变换，模拟滤波器的无穷远零点映射到z=-1
+func (f zpk) bilinear(sampleRate float64) zpk {
+	fs2 := complex(2*sampleRate, 0)
+	degree := len(f.p) - len(f.z)
+
+	out := zpk{k: f.k}
+	num, den := complex(1, 0), complex(1, 0)
+	for _, z := range f.z {
+		out.z = append(out.z, (fs2+z)/(fs2-z))
+		num *= fs2 - z
+	}
+	for _, p := range f.p {
+		out.p = append(out.p, (fs2+p)/(fs2-p))
+		den *= fs2 - p
+	}
+	for i := 0; i < degree; i++ {
+		out.z = append(out.z, -1)
+	}
+	out.k *= real(num / den)
+	return out
+}
+
+// 将数字滤波器的零极点组合为二阶节
+// 极点按离单位圆由远到近排列，每组极点与最近的零点组配对，增益放在第一节。
+func (f zpk) sections() *SOSFilter {
+	poles := groupRoots(f.p)
+	zeros := groupRoots(f.z)
+	sort.SliceStable(poles, func(i, j int) bool {
+		return cmplx.Abs(poles[i][0]) < cmplx.Abs(poles[j][0])
+	})
+
+	filter := &SOSFilter{}
+	for _, pg := range poles {
+		best := -1
+		for i, zg := range zeros {
+			if best < 0 || cmplx.Abs(zg[0]-pg[0]) < cmplx.Abs(zeros[best][0]-pg[0]) {
+				best = i
+			}
+		}
+
+		var zg []complex128
+		if best >= 0 {
+			zg = zeros[best]
+			zeros = append(zeros[:best], zeros[best+1:]...)
+		}
+
+		b := rootsToQuadratic(zg)
+		a := rootsToQuadratic(pg)
+		filter.Sections = append(filter.Sections, Biquad{B0: b[0], B1: b[1], B2: b[2], A1: a[1], A2: a[2]})
+	}
+
+	if len(filter.Sections) == 0 {
+		filter.Sections = append(filter.Sections, Biquad{B0: 1})
+	}
+	s := &filter.Sections[0]
+	s.B0, s.B1, s.B2 = s.B0*f.k, s.B1*f.k, s.B2*f.k
+	return filter
+}
+
+// 对每个根r计算r' = r·scale ± sqrt((r·scale)² - wo²)
+func splitRoots(roots []complex128, scale complex128, wo float64) []complex128 {
+	out := make([]complex128, 0, 2*len(roots))
+	for _, r := range roots {
+		r *= scale
+		d := cmplx.Sqrt(r*r - complex(wo*wo, 0))
+		out = append(out, r+d, r-d)
+	}
+	return out
+}
+
+// 计算∏(-r)
+func prodNeg(roots []complex128) complex128 {
+	prod := complex(1, 0)
+	for _, r := range roots {
+		prod *= -r
+	}
+	return prod
+}
+
+// 将根分组：共轭复根为一组，实根两两一组，剩余的单个实根单独一组
+func groupRoots(roots []complex128) [][]complex128 {
+	const tol = 1e-10
+
+	groups := make([][]complex128, 0, (len(roots)+1)/2)
+	reals := make([]complex128, 0)
+	for _, r := range roots {
+		switch {
+		case math.Abs(imag(r)) <= tol*math.Max(1, cmplx.Abs(r)):
+			reals = append(reals, complex(real(r), 0))
+		case imag(r) > 0:
+			groups = append(groups, []complex128{r, cmplx.Conj(r)})
+		}
+	}
+
+	sort.Slice(reals, func(i, j int) bool { return real(reals[i]) < real(reals[j]) })
+	for i := 0; i < len(reals); i += 2 {
+		if i+1 < len(reals) {
+			groups = append(groups, []complex128{reals[i], reals[i+1]})
+		} else {
+			groups = append(groups, []complex128{reals[i]})
+		}
+	}
+	return groups
+}
+
+// 由至多两个根构造实系数多项式[1, c1, c2]
+func rootsToQuadratic(roots []complex128) [3]float64 {
+	switch len(roots) {
+	case 1:
+		return [3]float64{1, -real(roots[0]), 0}
+	case 2:
+		return [3]float64{1, -real(roots[0] + roots[1]), real(roots[0] * roots[1])}
+	}
+	return [3]float64{1, 0, 0}
+}
+
+// Filter 对x进行因果滤波，初始状态为零
+func (f *SOSFilter) Filter(x []float64) []float64 {
+	return f.NewState().Process(x)
+}
+
+// FilterSteady 对x进行因果滤波，初始状态为输入恒为x[0]时的稳态，可避免开头的阶跃瞬态
+func (f *SOSFilter) FilterSteady(x []float64) []float64 {
+	state := f.NewState()
+	if len(x) > 0 {
+		state.SetSteady(x[0])
+	}
+	return state.Process(x)
+}
+
+// Order 返回滤波器的阶数，即展开后分子和分母多项式的最高次数
+// 奇数阶滤波器中一阶极点节可能与二次零点配对，不能按节累加。
+func (f *SOSFilter) Order() int {
+	b, a := f.Coefficients()
+	return max(len(b), len(a)) - 1
+}
+
+// NewState 创建初始状态为零的滤波状态
+func (f *SOSFilter) NewState() *SOSState {
+	return &SOSState{filter: f, z: make([][2]float64, len(f.Sections))}
+}
+
+// SOSState 保存二阶节滤波器的内部状态（直接II型转置结构）
+// 流式处理时对连续到达的数据块依次调用Process，输出与一次性滤波相同。
+type SOSState struct {
+	filter *SOSFilter
+	z      [][2]float64
+}
+
+// Reset 将状态清零
+func (s *SOSState) Reset() {
+	for i := range s.z {
+		s.z[i] = [2]float64{}
+	}
+}
+
+// SetSteady 将状态设为输入恒为x0时的稳态
+func (s *SOSState) SetSteady(x0 float64) {
+	scale := x0
+	for i, sec := range s.filter.Sections {
+		// 直流增益为1+A1+A2为零时（如带通的极点在z=1）没有稳态，保持零状态
+		den := 1 + sec.A1 + sec.A2
+		if den == 0 {
+			s.z[i] = [2]float64{}
+			continue
+		}
+		y := (sec.B0 + sec.B1 + sec.B2) / den
+		z2 := sec.B2 - sec.A2*y
+		z1 := sec.B1 - sec.A1*y + z2
+		s.z[i] = [2]float64{z1 * scale, z2 * scale}
+		scale *= y
+	}
+}
+
+// Process 对数据块进行因果滤波并更新状态
+func (s *SOSState) Process(x []float64) []float64 {
+	y := make([]float64, len(x))
+	copy(y, x)
+	for i, sec := range s.filter.Sections {
+		z1, z2 := s.z[i][0], s.z[i][1]
+		for j, v := range y {
+			out := sec.B0*v + z1
+			z1 = sec.B1*v - sec.A1*out + z2
+			z2 = sec.B2*v - sec.A2*out
+			y[j] = out
+		}
+		s.z[i] = [2]float64{z1, z2}
+	}
+	return y
+}
+
+// Coefficients 将二阶节展开为传递函数的分子b和分母a多项式系数
+func (f *SOSFilter) Coefficients() (b, a []float64) {
+	b, a = []float64{1}, []float64{1}
+	for _, s := range f.Sections {
+		b = polyMul(b, []float64{s.B0, s.B1, s.B2})
+		a = polyMul(a, []float64{1, s.A1, s.A2})
+	}
+	return trimPoly(b), trimPoly(a)
+}
+
+// FrequencyResponse 计算滤波器在各频率（Hz）处的复频率响应
+func (f *SOSFilter) FrequencyResponse(freqs []float64, sampleRate float64) []complex128 {
+	h := make([]complex128, len(freqs))
+	for i, freq := range freqs {
+		z1 := cmplx.Exp(complex(0, -2*math.Pi*freq/sampleRate))
+		z2 := z1 * z1
+		resp := complex(1, 0)
+		for _, s := range f.Sections {
+			num := complex(s.B0, 0) + complex(s.B1, 0)*z1 + complex(s.B2, 0)*z2
+			den := 1 + complex(s.A1, 0)*z1 + complex(s.A2, 0)*z2
+			resp *= num / den
+		}
+		h[i] = resp
+	}
+	return h
+}
+
+// 多项式乘法，系数按z⁻¹的升幂排列
+func polyMul(p, q []float64) []float64 {
+	out := make([]float64, len(p)+len(q)-1)
+	for i, a := range p {
+		for j, b := range q {
+			out[i+j] += a * b
+		}
+	}
+	return out
+}
+
+// 去掉末尾为零的高次项（一阶节展开后产生）
+func trimPoly(p []float64) []float64 {
+	for len(p) > 1 && p[len(p)-1] == 0 {
+		p = p[:len(p)-1]
+	}
+	return p
+}
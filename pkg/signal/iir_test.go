@@ -0,0 +1,173 @@
+package signal
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+// 判断两组系数在相对误差tol内相等
+func coefficientsNear(got, want []float64, tol float64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if math.Abs(got[i]-want[i]) > tol*math.Max(1, math.Abs(want[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+// 参考值来自scipy.signal的butter和cheby1（fs=2，Wn为相对奈奎斯特频率的截止频率），
+// 这里采样率取100Hz，Wn=0.2对应10Hz。
+func TestIIRCoefficients(t *testing.T) {
+	tests := []struct {
+		name   string
+		design func() (*SOSFilter, error)
+		b, a   []float64
+	}{
+		{
+			name:   "butter(4, 0.2)",
+			design: func() (*SOSFilter, error) { return Butterworth(4, LowPass, 100, 10) },
+			b:      []float64{0.004824343358, 0.01929737343, 0.02894606015, 0.01929737343, 0.004824343358},
+			a:      []float64{1, -2.369513007, 2.313988414, -1.054665406, 0.1873794924},
+		},
+		{
+			name:   "butter(4, 0.2, 'high')",
+			design: func() (*SOSFilter, error) { return Butterworth(4, HighPass, 100, 10) },
+			b:      []float64{0.432846645, -1.73138658, 2.59707987, -1.73138658, 0.432846645},
+			a:      []float64{1, -2.369513007, 2.313988414, -1.054665406, 0.1873794924},
+		},
+		{
+			name:   "butter(2, [0.1, 0.3], 'band')",
+			design: func() (*SOSFilter, error) { return Butterworth(2, BandPass, 100, 5, 15) },
+			b:      []float64{0.06745527389, 0, -0.1349105478, 0, 0.06745527389},
+			a:      []float64{1, -2.673578905, 2.992361804, -1.674577315, 0.4128015981},
+		},
+		{
+			name:   "cheby1(4, 1, 0.2)",
+			design: func() (*SOSFilter, error) { return Chebyshev1(4, 1, LowPass, 100, 10) },
+			b:      []float64{0.001835550372, 0.007342201488, 0.01101330223, 0.007342201488, 0.001835550372},
+			a:      []float64{1, -3.054339676, 3.828999227, -2.292451729, 0.5507445206},
+		},
+		{
+			name:   "cheby1(3, 0.5, 0.3, 'high')",
+			design: func() (*SOSFilter, error) { return Chebyshev1(3, 0.5, HighPass, 100, 15) },
+			b:      []float64{0.3660239384, -1.098071815, 1.098071815, -0.3660239384},
+			a:      []float64{1, -1.128731077, 0.7347032818, -0.06475714863},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := tt.design()
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, a := filter.Coefficients()
+			if !coefficientsNear(b, tt.b, 1e-8) {
+				t.Errorf("b = %v, want %v", b, tt.b)
+			}
+			if !coefficientsNear(a, tt.a, 1e-8) {
+				t.Errorf("a = %v, want %v", a, tt.a)
+			}
+			if filter.Order() != len(tt.a)-1 {
+				t.Errorf("Order = %d, want %d", filter.Order(), len(tt.a)-1)
+			}
+		})
+	}
+}
+
+func TestIIRFrequencyResponse(t *testing.T) {
+	gainDB := func(filter *SOSFilter, freq float64) float64 {
+		return 20 * math.Log10(cmplx.Abs(filter.FrequencyResponse([]float64{freq}, 500)[0]))
+	}
+
+	// 巴特沃斯带通在截止频率处为-3dB，通带中心为0dB
+	band, err := Butterworth(4, BandPass, 500, 5, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []float64{5, 40} {
+		if g := gainDB(band, f); math.Abs(g+3.0103) > 1e-3 {
+			t.Errorf("带通在%gHz的增益 = %gdB, want -3.01dB", f, g)
+		}
+	}
+	if g := gainDB(band, math.Sqrt(5*40)); math.Abs(g) > 1e-6 {
+		t.Errorf("带通中心增益 = %gdB", g)
+	}
+
+	// 偶数阶切比雪夫I型的直流增益和截止频率处增益都为-ripple dB
+	cheby, err := Chebyshev1(4, 1, LowPass, 500, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []float64{0, 40} {
+		if g := gainDB(cheby, f); math.Abs(g+1) > 1e-6 {
+			t.Errorf("切比雪夫在%gHz的增益 = %gdB, want -1dB", f, g)
+		}
+	}
+
+	// 带阻在中心频率处衰减，直流和奈奎斯特频率处通过
+	stop, err := Butterworth(2, BandStop, 500, 45, 55)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := gainDB(stop, math.Sqrt(45*55)); g > -60 {
+		t.Errorf("带阻中心增益 = %gdB", g)
+	}
+	if g0, gn := gainDB(stop, 0), gainDB(stop, 250); math.Abs(g0) > 1e-6 || math.Abs(gn) > 1e-6 {
+		t.Errorf("带阻直流增益 = %gdB，奈奎斯特频率增益 = %gdB", g0, gn)
+	}
+}
+
+func TestIIRDesignErrors(t *testing.T) {
+	tests := map[string]func() (*SOSFilter, error){
+		"阶数为0":          func() (*SOSFilter, error) { return Butterworth(0, LowPass, 100, 10) },
+		"纹波为0":          func() (*SOSFilter, error) { return Chebyshev1(4, 0, LowPass, 100, 10) },
+		"采样率为0":         func() (*SOSFilter, error) { return Butterworth(4, LowPass, 0, 10) },
+		"频带类型":          func() (*SOSFilter, error) { return Butterworth(4, "allpass", 100, 10) },
+		"截止频率个数":        func() (*SOSFilter, error) { return Butterworth(4, BandPass, 100, 10) },
+		"截止频率超过奈奎斯特频率":  func() (*SOSFilter, error) { return Butterworth(4, HighPass, 100, 50) },
+		"截止频率为负数":       func() (*SOSFilter, error) { return Butterworth(4, LowPass, 100, -1) },
+		"低截止频率不小于高截止频率": func() (*SOSFilter, error) { return Chebyshev1(2, 1, BandStop, 100, 20, 10) },
+	}
+	for name, design := range tests {
+		if _, err := design(); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+}
+
+func TestSOSFilterSteady(t *testing.T) {
+	filter, err := Butterworth(4, LowPass, 100, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 恒定输入从稳态开始时输出没有瞬态
+	x := make([]float64, 50)
+	for i := range x {
+		x[i] = 3
+	}
+	for i, y := range filter.FilterSteady(x) {
+		if math.Abs(y-3) > 1e-9 {
+			t.Fatalf("FilterSteady第%d个输出 = %g", i, y)
+		}
+	}
+	if y := filter.Filter(x); math.Abs(y[0]-3*0.004824343358) > 1e-9 {
+		t.Errorf("零初始状态的第一个输出 = %g", y[0])
+	}
+
+	// 分块处理与一次处理结果相同
+	for i := range x {
+		x[i] = math.Sin(float64(i))
+	}
+	whole := filter.Filter(x)
+	state := filter.NewState()
+	parts := append(state.Process(x[:17]), state.Process(x[17:])...)
+	if !coefficientsNear(parts, whole, 1e-12) {
+		t.Errorf("分块处理结果不同: %v", parts)
+	}
+}
@@ -0,0 +1,98 @@
+package signal
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+// 陷波滤波器类型，用于FilterParams.FilterType
+const (
+	FilterNotch = "notch"
+	FilterComb  = "comb"
+)
+
+// 陷波滤波器的默认参数
+const (
+	defaultNotchFreq = 50.0 // 工频（Hz）
+	defaultNotchQ    = 30.0
+)
+
+// Notch 设计二阶IIR陷波器
+// freq为陷波频率（Hz），q为品质因数，即陷波频率与-3dB带宽之比。
+func Notch(freq, q, sampleRate float64) (*SOSFilter, error) {
+	if err := checkNotch(freq, q, sampleRate); err != nil {
+		return nil, err
+	}
+	return &SOSFilter{Sections: []Biquad{notchSection(freq, freq/q, sampleRate)}}, nil
+}
+
+// Comb 设计梳状陷波器，在freq及其谐波处各放置一个二阶陷波
+// 各陷波的-3dB带宽均为freq/q；harmonics为陷波的个数（包括基波），0表示奈奎斯特频率以下的全部谐波。
+func Comb(freq, q float64, harmonics int, sampleRate float64) (*SOSFilter, error) {
+	if err := checkNotch(freq, q, sampleRate); err != nil {
+		return nil, err
+	}
+	if harmonics < 0 {
+		return nil, fmt.Errorf("谐波个数不能为负数: %d", harmonics)
+	}
+
+	filter := &SOSFilter{}
+	for k := 1; float64(k)*freq < sampleRate/2; k++ {
+		if harmonics > 0 && k > harmonics {
+			break
+		}
+		filter.Sections = append(filter.Sections, notchSection(float64(k)*freq, freq/q, sampleRate))
+	}
+	return filter, nil
+}
+
+// 检查陷波参数
+func checkNotch(freq, q, sampleRate float64) error {
+	if !(sampleRate > 0) {
+		return fmt.Errorf("无效的采样率: %g", sampleRate)
+	}
+	if !(freq > 0 && freq < sampleRate/2) {
+		return fmt.Errorf("陷波频率%gHz必须在0和奈奎斯特频率%gHz之间", freq, sampleRate/2)
+	}
+	if !(q > 0) {
+		return fmt.Errorf("品质因数必须大于0: %g", q)
+	}
+	return nil
+}
+
+// 陷波频率为freq、-3dB带宽为bandwidth（Hz）的二阶节，与SciPy的iirnotch相同
+func notchSection(freq, bandwidth, sampleRate float64) Biquad {
+	w0 := 2 * math.Pi * freq / sampleRate
+	bw := 2 * math.Pi * bandwidth / sampleRate
+	gain := 1 / (1 + math.Tan(bw/2))
+
+	return Biquad{
+		B0: gain,
+		B1: -2 * gain * math.Cos(w0),
+		B2: gain,
+		A1: -2 * gain * math.Cos(w0),
+		A2: 2*gain - 1,
+	}
+}
+
+// ApplyNotchFilter 应用工频陷波，freq为陷波频率（Hz），q为品质因数
+func (p *Processor) ApplyNotchFilter(channel *data.Channel, freq, q float64) error {
+	filter, err := Notch(freq, q, p.SampleRate)
+	if err != nil {
+		return err
+	}
+
+	return p.ApplyLinearFilter(channel, filter, false)
+}
+
+// ApplyCombFilter 应用梳状陷波，同时去除工频及其谐波
+func (p *Processor) ApplyCombFilter(channel *data.Channel, freq, q float64, harmonics int) error {
+	filter, err := Comb(freq, q, harmonics, p.SampleRate)
+	if err != nil {
+		return err
+	}
+
+	return p.ApplyLinearFilter(channel, filter, false)
+}
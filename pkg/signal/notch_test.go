@@ -0,0 +1,135 @@
+package signal
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+// 在freq（Hz）处的增益（dB）
+func filterGainDB(filter interface {
+	FrequencyResponse([]float64, float64) []complex128
+}, freq, sampleRate float64) float64 {
+	return 20 * math.Log10(cmplx.Abs(filter.FrequencyResponse([]float64{freq}, sampleRate)[0]))
+}
+
+func TestNotchCoefficients(t *testing.T) {
+	// 参考值为scipy.signal.iirnotch(w0, Q, fs)
+	tests := []struct {
+		freq, q, rate float64
+		b, a          []float64
+	}{
+		{60, 30, 500, []float64{0.9875889381, -1.439842705, 0.9875889381}, []float64{1, -1.439842705, 0.9751778762}},
+		{50, 35, 250, []float64{0.9823627701, -0.6071335812, 0.9823627701}, []float64{1, -0.6071335812, 0.9647255402}},
+	}
+	for _, tt := range tests {
+		filter, err := Notch(tt.freq, tt.q, tt.rate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, a := filter.Coefficients()
+		if !coefficientsNear(b, tt.b, 1e-9) || !coefficientsNear(a, tt.a, 1e-9) {
+			t.Errorf("Notch(%g, %g, %g) = %v, %v, want %v, %v", tt.freq, tt.q, tt.rate, b, a, tt.b, tt.a)
+		}
+	}
+}
+
+func TestNotchResponse(t *testing.T) {
+	filter, err := Notch(50, 30, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := filterGainDB(filter, 50, 500); g > -100 {
+		t.Errorf("陷波频率处的增益 = %gdB", g)
+	}
+	for _, f := range []float64{0, 40, 60, 250} {
+		if g := filterGainDB(filter, f, 500); math.Abs(g) > 0.1 {
+			t.Errorf("%gHz处的增益 = %gdB", f, g)
+		}
+	}
+}
+
+func TestComb(t *testing.T) {
+	// 0表示奈奎斯特频率以下的全部谐波
+	tests := []struct {
+		harmonics, sections int
+	}{
+		{0, 4},
+		{3, 3},
+		{10, 4},
+	}
+	for _, tt := range tests {
+		filter, err := Comb(50, 30, tt.harmonics, 500)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(filter.Sections) != tt.sections {
+			t.Errorf("Comb(harmonics=%d)有%d个二阶节, want %d", tt.harmonics, len(filter.Sections), tt.sections)
+		}
+	}
+
+	filter, err := Comb(50, 30, 0, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []float64{50, 100, 150, 200} {
+		if g := filterGainDB(filter, f, 500); g > -100 {
+			t.Errorf("谐波%gHz处的增益 = %gdB", f, g)
+		}
+	}
+	for _, f := range []float64{0, 25, 75, 125} {
+		if g := filterGainDB(filter, f, 500); math.Abs(g) > 0.1 {
+			t.Errorf("%gHz处的增益 = %gdB", f, g)
+		}
+	}
+}
+
+func TestNotchErrors(t *testing.T) {
+	tests := map[string]func() (*SOSFilter, error){
+		"采样率为0":        func() (*SOSFilter, error) { return Notch(50, 30, 0) },
+		"陷波频率为0":       func() (*SOSFilter, error) { return Notch(0, 30, 500) },
+		"陷波频率等于奈奎斯特频率": func() (*SOSFilter, error) { return Notch(250, 30, 500) },
+		"品质因数为0":       func() (*SOSFilter, error) { return Notch(50, 0, 500) },
+		"谐波个数为负数":      func() (*SOSFilter, error) { return Comb(50, 30, -1, 500) },
+	}
+	for name, design := range tests {
+		if _, err := design(); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+}
+
+func TestApplyNotchFilter(t *testing.T) {
+	p := NewProcessor(500)
+
+	// 去掉50Hz工频和100Hz谐波后只剩5Hz分量，后半段的均方根约为1/√2
+	rms := func(channel *data.Channel) float64 {
+		sum := 0.0
+		for _, pt := range channel.ProcessedData[1000:] {
+			sum += pt.Y * pt.Y
+		}
+		return math.Sqrt(sum / float64(len(channel.ProcessedData)-1000))
+	}
+
+	channel := sineTestChannel(500, 2000, 5, 50)
+	if err := p.ApplyNotchFilter(channel, 50, 30); err != nil {
+		t.Fatal(err)
+	}
+	if r := rms(channel); math.Abs(r-math.Sqrt(0.5)) > 0.01 {
+		t.Errorf("陷波后的均方根 = %g, want %g", r, math.Sqrt(0.5))
+	}
+
+	channel = sineTestChannel(500, 2000, 5, 50, 100)
+	if err := p.ApplyCombFilter(channel, 50, 30, 2); err != nil {
+		t.Fatal(err)
+	}
+	if r := rms(channel); math.Abs(r-math.Sqrt(0.5)) > 0.01 {
+		t.Errorf("梳状陷波后的均方根 = %g, want %g", r, math.Sqrt(0.5))
+	}
+
+	if err := p.ApplyNotchFilter(channel, 300, 30); err == nil {
+		t.Error("陷波频率超过奈奎斯特频率时应返回错误")
+	}
+}
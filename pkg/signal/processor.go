@@ -1,10 +1,9 @@
 package signal
 
 import (
-	"math"
 	"math/cmplx"
 
-	"github.com/liujiaxin/chartSystem/internal/data"
+	"github.com/ljx520ljx/chartSystem/internal/data"
 	"gonum.org/v1/gonum/dsp/fourier"
 	"gonum.org/v1/gonum/dsp/window"
 )
@@ -60,98 +59,31 @@ func (p *Processor) ApplyDifferential(channel *data.Channel) {
 	}
 }
 
-// ApplyLowPassFilter 应用低通滤波
-func (p *Processor) ApplyLowPassFilter(channel *data.Channel, cutoffFreq float64) {
+// ApplyLowPassFilter 应用低通滤波（4阶巴特沃斯），截止频率无效时返回错误
+func (p *Processor) ApplyLowPassFilter(channel *data.Channel, cutoffFreq float64) error {
 	if len(channel.Data) < 3 {
-		return
+		return nil
 	}
 
-	// 计算RC时间常数
-	RC := 1.0 / (2.0 * math.Pi * cutoffFreq)
-	
-	// 计算alpha系数
-	dt := 1.0 / p.SampleRate
-	alpha := dt / (RC + dt)
-	
-	// 分配结果数组
-	channel.ProcessedData = make([]data.DataPoint, len(channel.Data))
-	
-	// 第一个点不变
-	channel.ProcessedData[0] = data.DataPoint{
-		X: channel.Data[0].X,
-		Y: channel.Data[0].Y,
-	}
-	
-	// 应用一阶低通滤波器
-	for i := 1; i < len(channel.Data); i++ {
-		y := channel.ProcessedData[i-1].Y + alpha * (channel.Data[i].Y - channel.ProcessedData[i-1].Y)
-		
-		channel.ProcessedData[i] = data.DataPoint{
-			X: channel.Data[i].X,
-			Y: y,
-		}
-	}
+	return p.ApplyFilter(channel, FilterParams{FilterType: LowPass, Cutoff: cutoffFreq})
 }
 
-// ApplyHighPassFilter 应用高通滤波
-func (p *Processor) ApplyHighPassFilter(channel *data.Channel, cutoffFreq float64) {
+// ApplyHighPassFilter 应用高通滤波（4阶巴特沃斯），截止频率无效时返回错误
+func (p *Processor) ApplyHighPassFilter(channel *data.Channel, cutoffFreq float64) error {
 	if len(channel.Data) < 3 {
-		return
+		return nil
 	}
 
-	// 计算RC时间常数
-	RC := 1.0 / (2.0 * math.Pi * cutoffFreq)
-	
-	// 计算alpha系数
-	dt := 1.0 / p.SampleRate
-	alpha := RC / (RC + dt)
-	
-	// 分配结果数组
-	channel.ProcessedData = make([]data.DataPoint, len(channel.Data))
-	
-	// 第一个点不变
-	channel.ProcessedData[0] = data.DataPoint{
-		X: channel.Data[0].X,
-		Y: channel.Data[0].Y,
-	}
-	
-	// 应用一阶高通滤波器
-	for i := 1; i < len(channel.Data); i++ {
-		y := alpha * (channel.ProcessedData[i-1].Y + channel.Data[i].Y - channel.Data[i-1].Y)
-		
-		channel.ProcessedData[i] = data.DataPoint{
-			X: channel.Data[i].X,
-			Y: y,
-		}
-	}
+	return p.ApplyFilter(channel, FilterParams{FilterType: HighPass, Cutoff: cutoffFreq})
 }
 
-// ApplyBandPassFilter 应用带通滤波
-func (p *Processor) ApplyBandPassFilter(channel *data.Channel, lowCutoff, highCutoff float64) {
-	// 创建临时通道进行中间处理
-	tempChannel := &data.Channel{
-		ID:         "temp",
-		Name:       "temp",
-		Data:       channel.Data,
-		ProcessedData: nil,
-		Visible:    true,
-		Color:      "#FF0000",
-		Scale:      1.0,
-		YAxisMin:   -1.0,
-		YAxisMax:   1.0,
+// ApplyBandPassFilter 应用带通滤波（4阶巴特沃斯），截止频率无效时返回错误
+func (p *Processor) ApplyBandPassFilter(channel *data.Channel, lowCutoff, highCutoff float64) error {
+	if len(channel.Data) < 3 {
+		return nil
 	}
-	
-	// 先应用高通滤波器
-	p.ApplyHighPassFilter(tempChannel, lowCutoff)
-	
-	// 更新临时通道的数据
-	tempChannel.Data = tempChannel.ProcessedData
-	
-	// 再应用低通滤波器
-	p.ApplyLowPassFilter(tempChannel, highCutoff)
-	
-	// 将处理结果复制到原通道
-	channel.ProcessedData = tempChannel.ProcessedData
+
+	return p.ApplyFilter(channel, FilterParams{FilterType: BandPass, LowCutoff: lowCutoff, HighCutoff: highCutoff})
 }
 
 // ApplyMovingAverage 应用移动平均滤波
@@ -188,6 +120,7 @@ func (p *Processor) ApplyMovingAverage(channel *data.Channel, windowSize int) {
 }
 
 // ApplyFFT 应用快速傅里叶变换
+// 结果为单次加汉宁窗的幅度谱，需要可比较的功率谱时使用ApplyWelch。
 func (p *Processor) ApplyFFT(channel *data.Channel) []complex128 {
 	// 获取数据点数量
 	n := len(channel.Data)
@@ -219,26 +152,13 @@ func (p *Processor) ApplyFFT(channel *data.Channel) []complex128 {
 	windowedData := make([]float64, len(yValues))
 	copy(windowedData, yValues)
 	
-	// 修正: 手动实现汉宁窗函数 w(n) = 0.5 * (1 - cos(2π*n/(N-1)))
-	for i := range windowedData {
-		if len(windowedData) > 1 {
-			hannCoef := 0.5 * (1 - math.Cos(2*math.Pi*float64(i)/float64(len(windowedData)-1)))
-			windowedData[i] = windowedData[i] * hannCoef
-		}
-	}
+	window.Hann(windowedData)
 	
 	// 创建FFT实例
 	fft := fourier.NewFFT(n)
 	
-	// 将实数转换为复数
-	complexData := make([]complex128, n)
-	for i, v := range windowedData {
-		complexData[i] = complex(v, 0)
-	}
-	
-	// 执行FFT
-	// 修正: 使用正确的方法计算FFT结果
-	result := fft.Coefficients(nil, complexData)
+	// 执行实数FFT，结果为0到n/2的n/2+1个系数
+	result := fft.Coefficients(nil, windowedData)
 	
 	// 计算处理后的数据
 	channel.ProcessedData = make([]data.DataPoint, n/2)
@@ -293,26 +213,32 @@ func (p *Processor) DetectPeaks(channel *data.Channel, threshold float64) []int
 }
 
 // CalculateHeartRate 计算心率
+// 使用Pan-Tompkins算法检测R波，跨越数据间断的RR间期不参与平均。
 func (p *Processor) CalculateHeartRate(channel *data.Channel) float64 {
-	// 检测R波峰值
-	peaks := p.DetectPeaks(channel, 0.5) // 阈值可能需要调整
-	
-	if len(peaks) < 2 {
+	beats, err := p.DetectQRS(channel)
+	if err != nil {
 		return 0
 	}
-	
+
 	// 计算RR间隔的平均值
 	totalTime := 0.0
-	for i := 1; i < len(peaks); i++ {
-		interval := channel.Data[peaks[i]].X - channel.Data[peaks[i-1]].X
-		totalTime += interval
+	intervals := 0
+	for i := 1; i < len(beats); i++ {
+		if channel.SpansGap(beats[i-1].Time, beats[i].Time) {
+			continue
+		}
+		totalTime += beats[i].Time - beats[i-1].Time
+		intervals++
 	}
-	
+	if intervals == 0 {
+		return 0
+	}
+
 	// 计算平均RR间隔（秒）
-	avgRRInterval := totalTime / float64(len(peaks)-1)
-	
+	avgRRInterval := totalTime / float64(intervals)
+
 	// 计算心率（次/分）
 	heartRate := 60.0 / avgRRInterval
-	
+
 	return heartRate
 }
@@ -0,0 +1,104 @@
+package signal
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+// 采样率为rate、包含给定频率正弦分量之和的通道
+func sineTestChannel(rate float64, n int, freqs ...float64) *data.Channel {
+	channel := data.NewChannel("0", "test")
+	channel.SampleRate = rate
+	for i := 0; i < n; i++ {
+		t := float64(i) / rate
+		y := 0.0
+		for _, f := range freqs {
+			y += math.Sin(2 * math.Pi * f * t)
+		}
+		channel.AddDataPoint(t, y)
+	}
+	return channel
+}
+
+func TestApplyPassFilters(t *testing.T) {
+	p := NewProcessor(500)
+
+	// 低通去掉100Hz分量，只保留5Hz分量，后半段的均方根约为1/√2
+	channel := sineTestChannel(500, 1000, 5, 100)
+	if err := p.ApplyLowPassFilter(channel, 20); err != nil {
+		t.Fatal(err)
+	}
+	if len(channel.ProcessedData) != 1000 {
+		t.Fatalf("len(ProcessedData) = %d", len(channel.ProcessedData))
+	}
+	sum := 0.0
+	for _, pt := range channel.ProcessedData[500:] {
+		sum += pt.Y * pt.Y
+	}
+	if rms := math.Sqrt(sum / 500); math.Abs(rms-math.Sqrt(0.5)) > 0.01 {
+		t.Errorf("低通后的均方根 = %g, want %g", rms, math.Sqrt(0.5))
+	}
+
+	if err := p.ApplyHighPassFilter(channel, 50); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ApplyBandPassFilter(channel, 50, 150); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplyPassFiltersErrors(t *testing.T) {
+	p := NewProcessor(500)
+	channel := sineTestChannel(500, 100, 5)
+
+	if err := p.ApplyLowPassFilter(channel, 300); err == nil {
+		t.Error("截止频率超过奈奎斯特频率时应返回错误")
+	}
+	if err := p.ApplyHighPassFilter(channel, 0); err == nil {
+		t.Error("截止频率为0时应返回错误")
+	}
+	if err := p.ApplyBandPassFilter(channel, 40, 10); err == nil {
+		t.Error("低截止频率大于高截止频率时应返回错误")
+	}
+
+	// 数据点太少时不处理
+	short := sineTestChannel(500, 2, 5)
+	if err := p.ApplyLowPassFilter(short, 300); err != nil || len(short.ProcessedData) != 0 {
+		t.Errorf("ApplyLowPassFilter = %v, ProcessedData = %v", err, short.ProcessedData)
+	}
+}
+
+func TestFilterParamsDesign(t *testing.T) {
+	tests := []struct {
+		name   string
+		params FilterParams
+		order  int
+	}{
+		{"默认巴特沃斯低通", FilterParams{FilterType: LowPass, Cutoff: 40}, 4},
+		{"高通使用low_cutoff", FilterParams{FilterType: HighPass, LowCutoff: 0.5, Order: 2}, 2},
+		{"切比雪夫带阻", FilterParams{FilterType: BandStop, Design: DesignChebyshev1, LowCutoff: 45, HighCutoff: 55, Order: 3}, 6},
+		{"陷波", FilterParams{FilterType: FilterNotch, NotchFreq: 50}, 2},
+	}
+	for _, tt := range tests {
+		filter, err := tt.params.DesignFilter(500)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if sos, ok := filter.(*SOSFilter); !ok || sos.Order() != tt.order {
+			t.Errorf("%s: %T, Order = %d, want %d", tt.name, filter, filter.Order(), tt.order)
+		}
+	}
+
+	for _, params := range []FilterParams{
+		{FilterType: "smooth"},
+		{FilterType: LowPass, Cutoff: 40, Design: "elliptic"},
+		{FilterType: LowPass},
+	} {
+		if filter, err := params.DesignFilter(500); err == nil || filter != nil {
+			t.Errorf("DesignFilter(%+v) = %v, %v，应返回错误", params, filter, err)
+		}
+	}
+}
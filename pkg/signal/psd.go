@@ -0,0 +1,199 @@
+package signal
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+	"gonum.org/v1/gonum/dsp/fourier"
+)
+
+// 去趋势方式
+const (
+	DetrendNone     = "none"
+	DetrendConstant = "constant" // 减去每段的均值
+	DetrendLinear   = "linear"   // 减去每段的最小二乘直线
+)
+
+// 功率谱的标定方式
+const (
+	ScalingDensity  = "density"  // 功率谱密度，单位为物理单位²/Hz
+	ScalingSpectrum = "spectrum" // 功率谱，单位为物理单位²，正弦分量的谱峰等于其均方值
+)
+
+// WelchParams 表示Welch功率谱估计的参数，对应处理任务中process_type为"psd"时的parameters
+type WelchParams struct {
+	SegmentLength int     `json:"segment_length"` // 每段的样本数，信号较短时取信号长度
+	Overlap       float64 `json:"overlap"`        // 相邻段重叠的比例，0到1之间（不含1）
+	NFFT          int     `json:"nfft"`           // FFT长度，不小于段长，为0时等于段长
+	Window        string  `json:"window"`         // 窗函数名称，见Window
+	Detrend       string  `json:"detrend"`        // none、constant或linear
+	Scaling       string  `json:"scaling"`        // density或spectrum
+}
+
+// DefaultWelchParams 返回默认的Welch参数：256点汉宁窗、50%重叠、去均值、功率谱密度
+func DefaultWelchParams() WelchParams {
+	return WelchParams{
+		SegmentLength: 256,
+		Overlap:       0.5,
+		Window:        WindowHann,
+		Detrend:       DetrendConstant,
+		Scaling:       ScalingDensity,
+	}
+}
+
+// PSD 表示功率谱估计的结果
+type PSD struct {
+	Frequencies []float64 // 各频点的频率（Hz），从0到奈奎斯特频率
+	Power       []float64 // 各频点的功率，单位取决于Scaling
+	Scaling     string
+	Segments    int     // 参与平均的段数
+	Resolution  float64 // 频率间隔（Hz）
+}
+
+// Welch 用Welch方法估计x的单边功率谱
+// 信号被分成有重叠的段，每段去趋势、加窗后做FFT，各段的周期图取平均。
+// 功率谱密度按窗函数能量归一化，与采样率和段长无关，不同记录的结果可以直接比较。
+func Welch(x []float64, sampleRate float64, params WelchParams) (*PSD, error) {
+	if !(sampleRate > 0) {
+		return nil, fmt.Errorf("无效的采样率: %g", sampleRate)
+	}
+	if len(x) < 2 {
+		return nil, fmt.Errorf("数据点数%d太少，无法估计功率谱", len(x))
+	}
+
+	segLen := params.SegmentLength
+	if segLen < 2 {
+		return nil, fmt.Errorf("段长必须不小于2: %d", segLen)
+	}
+	if segLen > len(x) {
+		segLen = len(x)
+	}
+	if !(params.Overlap >= 0 && params.Overlap < 1) {
+		return nil, fmt.Errorf("重叠比例必须在0和1之间: %g", params.Overlap)
+	}
+	step := segLen - int(math.Round(params.Overlap*float64(segLen)))
+	if step < 1 {
+		step = 1
+	}
+	nfft := params.NFFT
+	if nfft == 0 {
+		nfft = segLen
+	}
+	if nfft < segLen {
+		return nil, fmt.Errorf("FFT长度%d不能小于段长%d", nfft, segLen)
+	}
+
+	w, err := PeriodicWindow(params.Window, segLen, 0)
+	if err != nil {
+		return nil, err
+	}
+	var sumW, sumW2 float64
+	for _, v := range w {
+		sumW += v
+		sumW2 += v * v
+	}
+
+	var scale float64
+	switch params.Scaling {
+	case ScalingDensity:
+		scale = 1 / (sampleRate * sumW2)
+	case ScalingSpectrum:
+		scale = 1 / (sumW * sumW)
+	default:
+		return nil, fmt.Errorf("不支持的功率谱标定方式: %s", params.Scaling)
+	}
+	switch params.Detrend {
+	case DetrendNone, DetrendConstant, DetrendLinear:
+	default:
+		return nil, fmt.Errorf("不支持的去趋势方式: %s", params.Detrend)
+	}
+
+	fft := fourier.NewFFT(nfft)
+	buf := make([]float64, nfft)
+	var coeffs []complex128
+	power := make([]float64, nfft/2+1)
+	segments := 0
+	for start := 0; start+segLen <= len(x); start += step {
+		for i := range buf {
+			buf[i] = 0
+		}
+		copy(buf, x[start:start+segLen])
+		detrend(buf[:segLen], params.Detrend)
+		for i, v := range w {
+			buf[i] *= v
+		}
+
+		coeffs = fft.Coefficients(coeffs, buf)
+		for k, c := range coeffs {
+			power[k] += real(c)*real(c) + imag(c)*imag(c)
+		}
+		segments++
+	}
+
+	// 单边谱：除直流和奈奎斯特频率外的频点功率加倍
+	psd := &PSD{
+		Frequencies: make([]float64, len(power)),
+		Power:       power,
+		Scaling:     params.Scaling,
+		Segments:    segments,
+		Resolution:  sampleRate / float64(nfft),
+	}
+	for k := range power {
+		power[k] *= scale / float64(segments)
+		if k > 0 && !(nfft%2 == 0 && k == nfft/2) {
+			power[k] *= 2
+		}
+		psd.Frequencies[k] = float64(k) * psd.Resolution
+	}
+	return psd, nil
+}
+
+// 原地去除x的趋势
+func detrend(x []float64, method string) {
+	switch method {
+	case DetrendConstant:
+		m := mean(x)
+		for i := range x {
+			x[i] -= m
+		}
+
+	case DetrendLinear:
+		// 最小二乘拟合x = a + b·(i - c)，c为样本序号的均值
+		n := float64(len(x))
+		c := (n - 1) / 2
+		a := mean(x)
+		var sxy, sxx float64
+		for i, v := range x {
+			d := float64(i) - c
+			sxy += d * (v - a)
+			sxx += d * d
+		}
+		b := 0.0
+		if sxx > 0 {
+			b = sxy / sxx
+		}
+		for i := range x {
+			x[i] -= a + b*(float64(i)-c)
+		}
+	}
+}
+
+// ApplyWelch 估计通道数据的功率谱，结果写入ProcessedData，X为频率（Hz），Y为功率
+func (p *Processor) ApplyWelch(channel *data.Channel, params WelchParams) (*PSD, error) {
+	values := make([]float64, len(channel.Data))
+	for i, pt := range channel.Data {
+		values[i] = pt.Y
+	}
+
+	psd, err := Welch(values, p.SampleRate, params)
+	if err != nil {
+		return nil, err
+	}
+
+	channel.ProcessedData = make([]data.DataPoint, len(psd.Power))
+	for i := range psd.Power {
+		channel.ProcessedData[i] = data.DataPoint{X: psd.Frequencies[i], Y: psd.Power[i]}
+	}
+	return psd, nil
+}
@@ -0,0 +1,184 @@
+package signal
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+// 参考值为scipy.signal.welch(x, fs=10, window='hann', nperseg=16, noverlap=8, ...)
+func TestWelchSciPy(t *testing.T) {
+	x := make([]float64, 32)
+	for i := range x {
+		x[i] = math.Sin(0.7*float64(i)) + 0.1*float64(i)
+	}
+
+	tests := []struct {
+		name   string
+		params WelchParams
+		want   []float64
+	}{
+		{
+			name:   "detrend='constant', scaling='density'",
+			params: WelchParams{SegmentLength: 16, Overlap: 0.5, Window: WindowHann, Detrend: DetrendConstant, Scaling: ScalingDensity},
+			want: []float64{0.008952250963, 0.3846711052, 0.5223405497, 0.06109126519, 0.0002750640175,
+				2.518504897e-05, 4.896453012e-06, 1.30784016e-06, 3.214202577e-07},
+		},
+		{
+			name:   "nfft=20, detrend='linear', scaling='spectrum'",
+			params: WelchParams{SegmentLength: 16, Overlap: 0.5, NFFT: 20, Window: WindowHann, Detrend: DetrendLinear, Scaling: ScalingSpectrum},
+			want: []float64{0.02109856478, 0.2263910307, 0.5375911799, 0.2970262289, 0.0230199276, 0.000233231528,
+				3.950536036e-07, 7.362967247e-06, 4.79303316e-06, 1.559524999e-06, 3.013314916e-07},
+		},
+	}
+	for _, tt := range tests {
+		psd, err := Welch(x, 10, tt.params)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !coefficientsNear(psd.Power, tt.want, 1e-8) {
+			t.Errorf("%s: Power = %v, want %v", tt.name, psd.Power, tt.want)
+		}
+		nfft := max(tt.params.NFFT, tt.params.SegmentLength)
+		if psd.Segments != 3 || psd.Resolution != 10/float64(nfft) || psd.Frequencies[1] != psd.Resolution || psd.Scaling != tt.params.Scaling {
+			t.Errorf("%s: Segments = %d, Resolution = %g, Frequencies = %v", tt.name, psd.Segments, psd.Resolution, psd.Frequencies)
+		}
+	}
+}
+
+func TestWelchScaling(t *testing.T) {
+	// 方差为4的白噪声、幅度为3的50Hz正弦（均方值4.5）、直流偏置和线性趋势
+	fs := 500.0
+	rng := rand.New(rand.NewSource(2))
+	x := make([]float64, 100000)
+	for i := range x {
+		x[i] = 2*rng.NormFloat64() + 3*math.Sin(2*math.Pi*50*float64(i)/fs) + 5 + 0.001*float64(i)
+	}
+	params := DefaultWelchParams()
+	params.SegmentLength = 1000
+	params.Detrend = DetrendLinear
+
+	// 功率谱密度的积分等于去趋势后的方差，噪声的单边谱密度为2σ²/fs
+	psd, err := Welch(x, fs, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total, noise, count := 0.0, 0.0, 0
+	for k, f := range psd.Frequencies {
+		total += psd.Power[k] * psd.Resolution
+		if f > 100 && f < 200 {
+			noise += psd.Power[k]
+			count++
+		}
+	}
+	if math.Abs(total-8.5) > 0.3 {
+		t.Errorf("功率谱密度的积分 = %g, want 8.5", total)
+	}
+	if noise /= float64(count); math.Abs(noise-8/fs)/(8/fs) > 0.05 {
+		t.Errorf("噪声谱密度 = %g, want %g", noise, 8/fs)
+	}
+
+	// 功率谱在正弦频率处等于其均方值
+	params.Scaling = ScalingSpectrum
+	psd, err = Welch(x, fs, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if psd.Frequencies[100] != 50 || math.Abs(psd.Power[100]-4.5) > 0.1 {
+		t.Errorf("%gHz处的功率 = %g, want 4.5", psd.Frequencies[100], psd.Power[100])
+	}
+}
+
+func TestWelchSegments(t *testing.T) {
+	x := make([]float64, 5000)
+	tests := []struct {
+		params           WelchParams
+		segments, points int
+	}{
+		{WelchParams{SegmentLength: 333, NFFT: 401, Window: WindowHamming, Detrend: DetrendNone, Scaling: ScalingDensity}, 15, 201},
+		{WelchParams{SegmentLength: 1000, Overlap: 0.5, Window: WindowHann, Detrend: DetrendConstant, Scaling: ScalingDensity}, 9, 501},
+		// 信号短于段长时只有一段
+		{WelchParams{SegmentLength: 8192, Overlap: 0.5, Window: WindowHann, Detrend: DetrendConstant, Scaling: ScalingDensity}, 1, 2501},
+	}
+	for _, tt := range tests {
+		psd, err := Welch(x, 500, tt.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if psd.Segments != tt.segments || len(psd.Power) != tt.points || psd.Frequencies[len(psd.Frequencies)-1] > 250 {
+			t.Errorf("Welch(%+v): %d段, %d个频点", tt.params, psd.Segments, len(psd.Power))
+		}
+	}
+}
+
+func TestWelchErrors(t *testing.T) {
+	x := make([]float64, 1000)
+	valid := DefaultWelchParams()
+	tests := map[string]func(*WelchParams){
+		"段长小于2":     func(p *WelchParams) { p.SegmentLength = 1 },
+		"重叠比例为1":    func(p *WelchParams) { p.Overlap = 1 },
+		"重叠比例为负数":   func(p *WelchParams) { p.Overlap = -0.1 },
+		"FFT长度小于段长": func(p *WelchParams) { p.NFFT = 100 },
+		"窗函数":       func(p *WelchParams) { p.Window = "triangle" },
+		"去趋势方式":     func(p *WelchParams) { p.Detrend = "quadratic" },
+		"标定方式":      func(p *WelchParams) { p.Scaling = "magnitude" },
+	}
+	for name, modify := range tests {
+		params := valid
+		modify(&params)
+		if _, err := Welch(x, 500, params); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+	if _, err := Welch(x, 0, valid); err == nil {
+		t.Error("采样率为0时应返回错误")
+	}
+	if _, err := Welch(x[:1], 500, valid); err == nil {
+		t.Error("数据点太少时应返回错误")
+	}
+}
+
+func TestDetrend(t *testing.T) {
+	x := []float64{1, 3, 5, 7}
+	detrend(x, DetrendLinear)
+	if !coefficientsNear(x, []float64{0, 0, 0, 0}, 1e-12) {
+		t.Errorf("去线性趋势 = %v", x)
+	}
+	x = []float64{1, 3, 5, 7}
+	detrend(x, DetrendConstant)
+	if !coefficientsNear(x, []float64{-3, -1, 1, 3}, 1e-12) {
+		t.Errorf("去均值 = %v", x)
+	}
+	x = []float64{1, 3}
+	detrend(x, DetrendNone)
+	if x[0] != 1 || x[1] != 3 {
+		t.Errorf("不去趋势 = %v", x)
+	}
+}
+
+func TestApplyWelch(t *testing.T) {
+	channel := sineTestChannel(500, 5000, 50)
+	psd, err := NewProcessor(500).ApplyWelch(channel, DefaultWelchParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(channel.ProcessedData) != len(psd.Power) || len(channel.Data) != 5000 {
+		t.Fatalf("len(ProcessedData) = %d", len(channel.ProcessedData))
+	}
+	peak := channel.ProcessedData[0]
+	for _, pt := range channel.ProcessedData {
+		if pt.Y > peak.Y {
+			peak = pt
+		}
+	}
+	if math.Abs(peak.X-50) > psd.Resolution {
+		t.Errorf("谱峰频率 = %g, want 50", peak.X)
+	}
+
+	empty := data.NewChannel("1", "empty")
+	if _, err := NewProcessor(500).ApplyWelch(empty, DefaultWelchParams()); err == nil || len(empty.ProcessedData) != 0 {
+		t.Errorf("空通道应返回错误: %v", err)
+	}
+}
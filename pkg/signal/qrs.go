@@ -0,0 +1,462 @@
+package signal
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+// QRS检测的默认参数（秒或Hz）
+const (
+	defaultQRSLowCutoff      = 5.0
+	defaultQRSHighCutoff     = 15.0
+	defaultIntegrationWindow = 0.150
+	defaultRefractoryPeriod  = 0.200
+	defaultTWaveWindow       = 0.360
+	qrsLearningPeriod        = 2.0  // 初始化阈值所用的信号长度
+	qrsRRHistory             = 8    // 计算RR平均值所用的间期个数
+	qrsMissedRRFactor        = 1.66 // 超过RR平均值的该倍数仍未检出心搏时进行回溯
+)
+
+// Beat 表示检测到的一次心搏
+type Beat struct {
+	Index      int     // R波峰在信号中的样本序号
+	Time       float64 // R波峰的时间（秒）
+	Confidence float64 // 检测置信度，0到1之间
+	SearchBack bool    // 是否由回溯检测得到
+}
+
+// QRSDetector 使用Pan-Tompkins算法检测QRS波群
+// 信号依次经过带通滤波、微分、平方和滑动窗口积分，再在积分信号和带通信号上
+// 用自适应阈值区分QRS波和噪声峰，漏检时以较低阈值回溯搜索。
+// 带通滤波和积分都采用零相位处理，检测位置不需要延迟补偿。
+type QRSDetector struct {
+	SampleRate        float64
+	LowCutoff         float64 // 带通滤波的低截止频率（Hz），默认5
+	HighCutoff        float64 // 带通滤波的高截止频率（Hz），默认15
+	IntegrationWindow float64 // 滑动窗口积分的宽度（秒），默认0.15
+	RefractoryPeriod  float64 // 不应期（秒），期间不会再检出心搏，默认0.2
+	TWaveWindow       float64 // 在上一心搏后该时间（秒）内的峰需要排除T波，默认0.36
+}
+
+// NewQRSDetector 创建使用默认参数的QRS检测器
+func NewQRSDetector(sampleRate float64) *QRSDetector {
+	return &QRSDetector{
+		SampleRate:        sampleRate,
+		LowCutoff:         defaultQRSLowCutoff,
+		HighCutoff:        defaultQRSHighCutoff,
+		IntegrationWindow: defaultIntegrationWindow,
+		RefractoryPeriod:  defaultRefractoryPeriod,
+		TWaveWindow:       defaultTWaveWindow,
+	}
+}
+
+// 检测器的运行状态：信号峰和噪声峰的估计值、阈值及RR间期历史
+type qrsState struct {
+	spki, npki float64 // 积分信号上的信号峰和噪声峰估计
+	spkf, npkf float64 // 带通信号上的信号峰和噪声峰估计
+	irregular  bool    // 最近的RR间期不规则时阈值减半
+
+	rrRecent   []int // 最近的RR间期（样本数）
+	rrSelected []int // 落在正常范围内的最近RR间期
+}
+
+// 返回积分信号和带通信号上的第一阈值
+func (s *qrsState) thresholds() (float64, float64) {
+	thi := s.npki + 0.25*(s.spki-s.npki)
+	thf := s.npkf + 0.25*(s.spkf-s.npkf)
+	if s.irregular {
+		thi, thf = thi/2, thf/2
+	}
+	return thi, thf
+}
+
+// 返回用于判断漏检和规则性的RR平均值（样本数），尚无间期时返回0
+func (s *qrsState) rrAverage() float64 {
+	if len(s.rrSelected) > 0 {
+		return meanInt(s.rrSelected)
+	}
+	return meanInt(s.rrRecent)
+}
+
+// 记录新的RR间期
+func (s *qrsState) addRR(rr int) {
+	avg := s.rrAverage()
+	regular := avg == 0 || (float64(rr) >= 0.92*avg && float64(rr) <= 1.16*avg)
+
+	s.rrRecent = appendLimited(s.rrRecent, rr, qrsRRHistory)
+	if regular {
+		s.rrSelected = appendLimited(s.rrSelected, rr, qrsRRHistory)
+	}
+	s.irregular = !regular
+}
+
+// 积分信号上的候选峰
+type qrsPeak struct {
+	index int     // 积分信号峰的位置
+	peakI float64 // 积分信号峰值
+	peakF float64 // 对应的带通信号绝对值峰值
+	posF  int     // 带通信号峰的位置
+	slope float64 // 峰附近的最大斜率，用于区分T波
+}
+
+// Detect 检测x中的QRS波群，返回按时间排序的心搏，Time按采样率从0开始计算
+func (d *QRSDetector) Detect(x []float64) ([]Beat, error) {
+	fs := d.SampleRate
+	if !(fs > 0) {
+		return nil, fmt.Errorf("无效的采样率: %g", fs)
+	}
+	bandpass, err := Butterworth(2, BandPass, fs, d.LowCutoff, d.HighCutoff)
+	if err != nil {
+		return nil, fmt.Errorf("设计QRS带通滤波器失败: %w", err)
+	}
+
+	window := int(math.Round(d.IntegrationWindow * fs))
+	refractory := int(math.Round(d.RefractoryPeriod * fs))
+	tWave := int(math.Round(d.TWaveWindow * fs))
+	if window < 1 {
+		window = 1
+	}
+	if len(x) < 2*window {
+		return []Beat{}, nil
+	}
+
+	// 带通滤波、五点微分、平方和滑动窗口积分
+	filtered, err := FiltFilt(bandpass, x)
+	if err != nil {
+		return nil, err
+	}
+	derivative := fivePointDerivative(filtered, fs)
+	squared := make([]float64, len(derivative))
+	for i, v := range derivative {
+		squared[i] = v * v
+	}
+	integrated := centeredMovingAverage(squared, window)
+
+	peaks := d.findPeaks(integrated, filtered, derivative, window, refractory)
+	if len(peaks) == 0 {
+		return []Beat{}, nil
+	}
+
+	// 用前2秒的信号初始化阈值
+	learn := int(qrsLearningPeriod * fs)
+	if learn > len(x) {
+		learn = len(x)
+	}
+	maxI, meanI := maxMean(integrated[:learn])
+	maxF, meanF := maxMean(absValues(filtered[:learn]))
+	state := &qrsState{spki: maxI / 3, npki: meanI / 2, spkf: maxF / 3, npkf: meanF / 2}
+
+	var beats []Beat
+	var noise []qrsPeak // 上一心搏之后的噪声峰，用于回溯
+	var lastSlope float64
+	last := -1
+
+	accept := func(pk qrsPeak, searchBack bool, confidence float64) {
+		if last >= 0 {
+			state.addRR(pk.posF - last)
+		}
+		beats = append(beats, Beat{
+			Index:      refinePeak(x, pk.posF, window),
+			Confidence: confidence,
+			SearchBack: searchBack,
+		})
+		last = pk.posF
+		lastSlope = pk.slope
+		noise = noise[:0]
+	}
+
+	for _, pk := range peaks {
+		// 超过RR平均值的1.66倍未检出心搏时，在噪声峰中以第二阈值回溯
+		if avg := state.rrAverage(); last >= 0 && avg > 0 && float64(pk.posF-last) > qrsMissedRRFactor*avg {
+			best := state.searchBack(noise, last, refractory)
+			if best < 0 {
+				// 没有峰超过第二阈值时信号幅度可能已经下降（如增益改变），
+				// 将信号峰估计向噪声峰估计收缩一半后再回溯一次
+				state.spki = state.npki + 0.5*(state.spki-state.npki)
+				state.spkf = state.npkf + 0.5*(state.spkf-state.npkf)
+				best = state.searchBack(noise, last, refractory)
+			}
+			if best >= 0 {
+				n := noise[best]
+				confidence := state.confidence(n)
+				state.spki = 0.25*n.peakI + 0.75*state.spki
+				state.spkf = 0.25*n.peakF + 0.75*state.spkf
+				accept(n, true, confidence)
+			}
+		}
+
+		if last >= 0 && pk.posF-last <= refractory {
+			continue
+		}
+
+		thi, thf := state.thresholds()
+		isQRS := pk.peakI > thi && pk.peakF > thf
+
+		// 上一心搏后360ms内斜率不到其一半的峰视为T波
+		if isQRS && last >= 0 && pk.posF-last < tWave && pk.slope < lastSlope/2 {
+			isQRS = false
+		}
+
+		if isQRS {
+			confidence := state.confidence(pk)
+			state.spki = 0.125*pk.peakI + 0.875*state.spki
+			state.spkf = 0.125*pk.peakF + 0.875*state.spkf
+			accept(pk, false, confidence)
+		} else {
+			state.npki = 0.125*pk.peakI + 0.875*state.npki
+			state.npkf = 0.125*pk.peakF + 0.875*state.npkf
+			noise = append(noise, pk)
+		}
+	}
+
+	for i := range beats {
+		beats[i].Time = float64(beats[i].Index) / fs
+	}
+	return beats, nil
+}
+
+// 在上一心搏last之后的噪声峰中寻找积分信号最大且超过第二阈值的峰，没有时返回-1
+func (s *qrsState) searchBack(noise []qrsPeak, last, refractory int) int {
+	thi, thf := s.thresholds()
+	best := -1
+	for i, n := range noise {
+		if n.posF-last <= refractory || n.peakI <= thi/2 || n.peakF <= thf/2 {
+			continue
+		}
+		if best < 0 || n.peakI > noise[best].peakI {
+			best = i
+		}
+	}
+	return best
+}
+
+// 根据峰值在噪声估计和信号估计之间的位置计算置信度，取积分信号和带通信号的平均
+func (s *qrsState) confidence(pk qrsPeak) float64 {
+	ratio := func(v, noise, signal float64) float64 {
+		if signal <= noise {
+			return 1
+		}
+		return math.Max(0, math.Min(1, (v-noise)/(signal-noise)))
+	}
+	return (ratio(pk.peakI, s.npki, s.spki) + ratio(pk.peakF, s.npkf, s.spkf)) / 2
+}
+
+// 在积分信号上寻找间隔不小于不应期的局部极大值，并找到对应的带通信号峰和斜率
+func (d *QRSDetector) findPeaks(integrated, filtered, derivative []float64, window, refractory int) []qrsPeak {
+	var peaks []qrsPeak
+	for i := 1; i < len(integrated)-1; i++ {
+		v := integrated[i]
+		if v <= integrated[i-1] || v < integrated[i+1] {
+			continue
+		}
+		if n := len(peaks); n > 0 && i-peaks[n-1].index < refractory {
+			if v > peaks[n-1].peakI {
+				peaks[n-1] = qrsPeak{index: i, peakI: v}
+			}
+			continue
+		}
+		peaks = append(peaks, qrsPeak{index: i, peakI: v})
+	}
+
+	half := window / 2
+	for k := range peaks {
+		lo, hi := clampRange(peaks[k].index-half, peaks[k].index+half, len(filtered))
+		peaks[k].posF = lo
+		for i := lo; i < hi; i++ {
+			if a := math.Abs(filtered[i]); a > peaks[k].peakF {
+				peaks[k].peakF, peaks[k].posF = a, i
+			}
+			if a := math.Abs(derivative[i]); a > peaks[k].slope {
+				peaks[k].slope = a
+			}
+		}
+	}
+	return peaks
+}
+
+// 在带通信号峰附近的原始信号上定位R波峰，取偏离局部均值最大的点
+func refinePeak(x []float64, pos, window int) int {
+	lo, hi := clampRange(pos-window, pos+window, len(x))
+	mean := 0.0
+	for i := lo; i < hi; i++ {
+		mean += x[i]
+	}
+	mean /= float64(hi - lo)
+
+	lo, hi = clampRange(pos-window/3, pos+window/3+1, len(x))
+	best := pos
+	for i := lo; i < hi; i++ {
+		if math.Abs(x[i]-mean) > math.Abs(x[best]-mean) {
+			best = i
+		}
+	}
+	return best
+}
+
+// 中心五点微分：y[n] = (-x[n-2] - 2x[n-1] + 2x[n+1] + x[n+2]) * fs / 8
+func fivePointDerivative(x []float64, sampleRate float64) []float64 {
+	at := func(i int) float64 {
+		if i < 0 {
+			i = 0
+		} else if i >= len(x) {
+			i = len(x) - 1
+		}
+		return x[i]
+	}
+
+	y := make([]float64, len(x))
+	for n := range x {
+		y[n] = (-at(n-2) - 2*at(n-1) + 2*at(n+1) + at(n+2)) * sampleRate / 8
+	}
+	return y
+}
+
+// 宽度为window的中心滑动平均，两端按实际覆盖的点数平均
+func centeredMovingAverage(x []float64, window int) []float64 {
+	prefix := make([]float64, len(x)+1)
+	for i, v := range x {
+		prefix[i+1] = prefix[i] + v
+	}
+
+	y := make([]float64, len(x))
+	for i := range x {
+		lo, hi := clampRange(i-window/2, i-window/2+window, len(x))
+		y[i] = (prefix[hi] - prefix[lo]) / float64(hi-lo)
+	}
+	return y
+}
+
+// 将[lo, hi)限制在[0, n)内
+func clampRange(lo, hi, n int) (int, int) {
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > n {
+		hi = n
+	}
+	return lo, hi
+}
+
+// 返回最大值和平均值
+func maxMean(x []float64) (float64, float64) {
+	if len(x) == 0 {
+		return 0, 0
+	}
+	maxV, sum := x[0], 0.0
+	for _, v := range x {
+		maxV = math.Max(maxV, v)
+		sum += v
+	}
+	return maxV, sum / float64(len(x))
+}
+
+// 返回各元素的绝对值
+func absValues(x []float64) []float64 {
+	y := make([]float64, len(x))
+	for i, v := range x {
+		y[i] = math.Abs(v)
+	}
+	return y
+}
+
+// 返回整数的平均值，切片为空时返回0
+func meanInt(x []int) float64 {
+	if len(x) == 0 {
+		return 0
+	}
+	sum := 0
+	for _, v := range x {
+		sum += v
+	}
+	return float64(sum) / float64(len(x))
+}
+
+// 追加元素并只保留最后limit个
+func appendLimited(x []int, v, limit int) []int {
+	x = append(x, v)
+	if len(x) > limit {
+		x = x[len(x)-limit:]
+	}
+	return x
+}
+
+// DetectQRS 检测通道中的QRS波群，心搏时间取自通道数据的X值
+func (p *Processor) DetectQRS(channel *data.Channel) ([]Beat, error) {
+	values := make([]float64, len(channel.Data))
+	for i, pt := range channel.Data {
+		values[i] = pt.Y
+	}
+
+	beats, err := NewQRSDetector(p.SampleRate).Detect(values)
+	if err != nil {
+		return nil, err
+	}
+	for i := range beats {
+		beats[i].Time = channel.Data[beats[i].Index].X
+	}
+	return beats, nil
+}
+
+// DetectionStats 表示心搏检测结果与参考标注的比对统计
+type DetectionStats struct {
+	TruePositives  int
+	FalsePositives int
+	FalseNegatives int
+	Sensitivity    float64 // TP/(TP+FN)
+	PPV            float64 // 阳性预测值，TP/(TP+FP)
+}
+
+// EvaluateDetections 将检测到的心搏时间与参考标注时间（秒）逐一匹配
+// 与参考心搏相差不超过tolerance秒的检测记为正确检出，每个参考心搏最多匹配一次；
+// 常用的容差为0.15秒（ANSI/AAMI EC57）。
+func EvaluateDetections(reference, detected []float64, tolerance float64) DetectionStats {
+	ref := append([]float64(nil), reference...)
+	det := append([]float64(nil), detected...)
+	sort.Float64s(ref)
+	sort.Float64s(det)
+
+	var stats DetectionStats
+	i, j := 0, 0
+	for i < len(ref) && j < len(det) {
+		switch {
+		case math.Abs(ref[i]-det[j]) <= tolerance:
+			// 下一个检测更接近当前参考心搏时，当前检测记为误检
+			if j+1 < len(det) && math.Abs(ref[i]-det[j+1]) < math.Abs(ref[i]-det[j]) {
+				stats.FalsePositives++
+				j++
+				continue
+			}
+			stats.TruePositives++
+			i++
+			j++
+		case det[j] < ref[i]:
+			stats.FalsePositives++
+			j++
+		default:
+			stats.FalseNegatives++
+			i++
+		}
+	}
+	stats.FalseNegatives += len(ref) - i
+	stats.FalsePositives += len(det) - j
+
+	if n := stats.TruePositives + stats.FalseNegatives; n > 0 {
+		stats.Sensitivity = float64(stats.TruePositives) / float64(n)
+	}
+	if n := stats.TruePositives + stats.FalsePositives; n > 0 {
+		stats.PPV = float64(stats.TruePositives) / float64(n)
+	}
+	return stats
+}
+
+// IsBeatAnnotation 判断WFDB标注符号是否表示一次心搏，用于从标注文件提取参考心搏
+func IsBeatAnnotation(symbol string) bool {
+	switch symbol {
+	case "N", "L", "R", "B", "A", "a", "J", "S", "V", "r", "F", "e", "j", "n", "E", "/", "f", "Q", "?":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,241 @@
+package signal
+
+import (
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+	"github.com/ljx520ljx/chartSystem/pkg/fileio"
+)
+
+// 合成心电信号，返回信号和R波峰时间（秒）
+// 心搏由P、Q、R、S、T五个高斯波组成，RR间期随机变化并夹杂早搏，叠加基线漂移、白噪声和50Hz工频干扰；
+// gainChange为true时后半段的心搏幅度降为0.3倍。
+func synthECG(fs, seconds float64, seed int64, gainChange bool) ([]float64, []float64) {
+	rng := rand.New(rand.NewSource(seed))
+	var beats []float64
+	for t := 0.5; t < seconds-0.5; {
+		beats = append(beats, t)
+		rr := 0.8 + 0.15*rng.NormFloat64()
+		if rng.Float64() < 0.05 {
+			rr *= 0.6
+		}
+		t += rr
+	}
+
+	wave := func(t, mu, sigma, amp float64) float64 {
+		d := (t - mu) / sigma
+		return amp * math.Exp(-d*d/2)
+	}
+	x := make([]float64, int(seconds*fs))
+	for i := range x {
+		t := float64(i) / fs
+		v := 0.0
+		for _, b := range beats {
+			if math.Abs(t-b) > 0.6 {
+				continue
+			}
+			v += wave(t, b-0.18, 0.025, 0.15) + wave(t, b-0.03, 0.01, -0.12) + wave(t, b, 0.012, 1.2) +
+				wave(t, b+0.03, 0.01, -0.25) + wave(t, b+0.28, 0.05, 0.35)
+		}
+		if gainChange && t > seconds/2 {
+			v *= 0.3
+		}
+		x[i] = v + 0.4*math.Sin(2*math.Pi*0.25*t) + 0.03*rng.NormFloat64() + 0.05*math.Sin(2*math.Pi*50*t)
+	}
+	return x, beats
+}
+
+func TestQRSDetector(t *testing.T) {
+	tests := []struct {
+		name       string
+		fs         float64
+		seed       int64
+		gainChange bool
+		invert     bool
+	}{
+		{"250Hz", 250, 1, false, false},
+		{"360Hz", 360, 2, false, false},
+		{"500Hz", 500, 3, false, false},
+		{"增益下降", 360, 4, true, false},
+		{"倒置", 250, 5, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, ref := synthECG(tt.fs, 120, tt.seed, tt.gainChange)
+			if tt.invert {
+				for i := range x {
+					x[i] = -x[i]
+				}
+			}
+			beats, err := NewQRSDetector(tt.fs).Detect(x)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			detected := make([]float64, len(beats))
+			for i, b := range beats {
+				detected[i] = b.Time
+				if b.Index < 0 || b.Index >= len(x) || b.Time != float64(b.Index)/tt.fs {
+					t.Fatalf("beats[%d] = %+v", i, b)
+				}
+				if b.Confidence < 0 || b.Confidence > 1 {
+					t.Errorf("beats[%d]的置信度 = %g", i, b.Confidence)
+				}
+			}
+			stats := EvaluateDetections(ref, detected, 0.15)
+			if stats.Sensitivity < 0.99 || stats.PPV < 0.99 {
+				t.Errorf("%d个参考心搏，检出%d个: %+v", len(ref), len(beats), stats)
+			}
+
+			// R波峰定位误差不超过20ms
+			for _, d := range detected {
+				best := math.Inf(1)
+				for _, r := range ref {
+					best = math.Min(best, math.Abs(d-r))
+				}
+				if best < 0.15 && best > 0.02 {
+					t.Errorf("%gs处的心搏定位误差为%gs", d, best)
+				}
+			}
+		})
+	}
+}
+
+func TestQRSDetectorEdgeCases(t *testing.T) {
+	if _, err := NewQRSDetector(0).Detect(make([]float64, 1000)); err == nil {
+		t.Error("采样率为0时应返回错误")
+	}
+	detector := NewQRSDetector(250)
+	detector.HighCutoff = 200
+	if _, err := detector.Detect(make([]float64, 1000)); err == nil {
+		t.Error("带通截止频率无效时应返回错误")
+	}
+
+	// 信号过短或没有心搏时不返回心搏
+	for _, n := range []int{0, 10, 1000} {
+		beats, err := NewQRSDetector(250).Detect(make([]float64, n))
+		if err != nil || len(beats) != 0 {
+			t.Errorf("长度为%d的零信号: %v, %v", n, beats, err)
+		}
+	}
+}
+
+func TestDetectQRS(t *testing.T) {
+	// 心搏时间取自通道数据的X值
+	x, ref := synthECG(250, 30, 6, false)
+	channel := data.NewChannel("0", "ECG")
+	for i, v := range x {
+		channel.AddDataPoint(10+float64(i)/250, v)
+	}
+	beats, err := NewProcessor(250).DetectQRS(channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	detected := make([]float64, len(beats))
+	for i, b := range beats {
+		detected[i] = b.Time - 10
+		if b.Time != channel.Data[b.Index].X {
+			t.Errorf("beats[%d].Time = %g, want %g", i, b.Time, channel.Data[b.Index].X)
+		}
+	}
+	if stats := EvaluateDetections(ref, detected, 0.15); stats.Sensitivity < 0.99 || stats.PPV < 0.99 {
+		t.Errorf("DetectQRS: %+v", stats)
+	}
+}
+
+// testdata中的WFDB记录（MIT-BIH格式，format 212，.atr参考标注）
+// 以参考标注中的心搏为基准评估检测的敏感度和阳性预测值。
+func TestQRSDetectorWFDBRecords(t *testing.T) {
+	headers, err := filepath.Glob(filepath.Join("testdata", "*.hea"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(headers) == 0 {
+		t.Fatal("testdata中没有WFDB记录")
+	}
+
+	for _, header := range headers {
+		t.Run(filepath.Base(header), func(t *testing.T) {
+			reader, err := fileio.OpenWFDB(header)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer reader.Close()
+
+			annotations, err := reader.ReadAnnotations("atr")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ref []float64
+			nonBeat := 0
+			for _, a := range annotations {
+				if IsBeatAnnotation(a.Symbol) {
+					ref = append(ref, a.Time)
+				} else {
+					nonBeat++
+				}
+			}
+			if len(ref) == 0 || nonBeat == 0 {
+				t.Fatalf("%d条标注中有%d个心搏，应同时包含心搏和非心搏标注", len(annotations), len(ref))
+			}
+
+			channel := data.NewChannel("0", "ECG")
+			if err := reader.LoadSignalToChannel(0, channel); err != nil {
+				t.Fatal(err)
+			}
+			beats, err := NewProcessor(channel.SampleRate).DetectQRS(channel)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			detected := make([]float64, len(beats))
+			for i, b := range beats {
+				detected[i] = b.Time
+			}
+			stats := EvaluateDetections(ref, detected, 0.15)
+			if stats.Sensitivity < 0.99 || stats.PPV < 0.99 {
+				t.Errorf("%d个参考心搏，检出%d个: %+v", len(ref), len(beats), stats)
+			}
+		})
+	}
+}
+
+func TestEvaluateDetections(t *testing.T) {
+	tests := []struct {
+		name                string
+		reference, detected []float64
+		tp, fp, fn          int
+	}{
+		{"全部检出", []float64{1, 2, 3}, []float64{3.05, 1.1, 1.9}, 3, 0, 0},
+		{"多检和漏检", []float64{1, 2, 3}, []float64{1.01, 1.05, 2.2, 3.1}, 2, 2, 1},
+		{"较近的检测优先", []float64{1}, []float64{0.9, 0.98}, 1, 1, 0},
+		{"没有检测", []float64{1, 2}, nil, 0, 0, 2},
+		{"没有参考心搏", nil, []float64{1}, 0, 1, 0},
+	}
+	for _, tt := range tests {
+		stats := EvaluateDetections(tt.reference, tt.detected, 0.15)
+		if stats.TruePositives != tt.tp || stats.FalsePositives != tt.fp || stats.FalseNegatives != tt.fn {
+			t.Errorf("%s: %+v, want TP=%d FP=%d FN=%d", tt.name, stats, tt.tp, tt.fp, tt.fn)
+		}
+	}
+
+	stats := EvaluateDetections([]float64{1, 2, 3, 4}, []float64{1, 2, 3, 5}, 0.15)
+	if stats.Sensitivity != 0.75 || stats.PPV != 0.75 {
+		t.Errorf("Sensitivity = %g, PPV = %g", stats.Sensitivity, stats.PPV)
+	}
+	if stats := EvaluateDetections(nil, nil, 0.15); stats.Sensitivity != 0 || stats.PPV != 0 {
+		t.Errorf("空输入: %+v", stats)
+	}
+}
+
+func TestIsBeatAnnotation(t *testing.T) {
+	for symbol, want := range map[string]bool{"N": true, "V": true, "/": true, "+": false, "~": false, "": false} {
+		if got := IsBeatAnnotation(symbol); got != want {
+			t.Errorf("IsBeatAnnotation(%q) = %v, want %v", symbol, got, want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package signal
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+	"gonum.org/v1/gonum/dsp/fourier"
+)
+
+// 时频图的幅值标度
+const (
+	ScaleLinear = "linear" // 功率谱密度，单位为物理单位²/Hz
+	ScaleLog    = "log"    // 功率谱密度的分贝值，10·log10(P)
+)
+
+// 对数标度下功率的下限，避免对零取对数
+const minLogPower = 1e-30
+
+// SpectrogramParams 表示短时傅里叶变换时频图的参数
+type SpectrogramParams struct {
+	WindowSize int     `json:"window_size"` // 每帧的样本数
+	Hop        int     `json:"hop"`         // 相邻帧起点的间隔（样本数），为0时取窗长的一半
+	Window     string  `json:"window"`      // 窗函数名称，见Window
+	MinFreq    float64 `json:"min_freq"`    // 输出的最低频率（Hz）
+	MaxFreq    float64 `json:"max_freq"`    // 输出的最高频率（Hz），为0时到奈奎斯特频率
+	Scale      string  `json:"scale"`       // linear或log
+}
+
+// DefaultSpectrogramParams 返回默认的时频图参数：256点汉宁窗、50%重叠、全频率范围、对数标度
+func DefaultSpectrogramParams() SpectrogramParams {
+	return SpectrogramParams{
+		WindowSize: 256,
+		Window:     WindowHann,
+		Scale:      ScaleLog,
+	}
+}
+
+// Spectrogram 表示时频图，Values[i][j]为第i帧在第j个频率处的值
+type Spectrogram struct {
+	Times       []float64   `json:"times"`       // 各帧中心的时间（秒）
+	Frequencies []float64   `json:"frequencies"` // 各频率（Hz），按升序排列
+	Values      [][]float64 `json:"values"`
+	Scale       string      `json:"scale"`
+	Resolution  float64     `json:"resolution"` // 频率间隔（Hz）
+	FrameStep   float64     `json:"frame_step"` // 帧间隔（秒）
+}
+
+// ComputeSpectrogram 计算x的短时傅里叶变换时频图
+// 每帧减去均值后加窗做FFT，功率按窗函数能量归一化为单边功率谱密度，与Welch的density标定一致。
+// startTime为x[0]的时间（秒），用于计算各帧的时间。
+func ComputeSpectrogram(x []float64, sampleRate, startTime float64, params SpectrogramParams) (*Spectrogram, error) {
+	if !(sampleRate > 0) {
+		return nil, fmt.Errorf("无效的采样率: %g", sampleRate)
+	}
+	size := params.WindowSize
+	if size < 2 {
+		return nil, fmt.Errorf("窗长必须不小于2: %d", size)
+	}
+	if len(x) < size {
+		return nil, fmt.Errorf("数据点数%d少于窗长%d", len(x), size)
+	}
+	hop := params.Hop
+	if hop == 0 {
+		hop = size / 2
+	}
+	if hop < 1 {
+		return nil, fmt.Errorf("帧间隔必须大于0: %d", hop)
+	}
+	if params.Scale != ScaleLinear && params.Scale != ScaleLog {
+		return nil, fmt.Errorf("不支持的标度: %s", params.Scale)
+	}
+
+	nyq := sampleRate / 2
+	minFreq, maxFreq := params.MinFreq, params.MaxFreq
+	if maxFreq == 0 || maxFreq > nyq {
+		maxFreq = nyq
+	}
+	if minFreq < 0 || minFreq >= maxFreq {
+		return nil, fmt.Errorf("无效的频率范围: %g~%gHz", minFreq, maxFreq)
+	}
+
+	w, err := PeriodicWindow(params.Window, size, 0)
+	if err != nil {
+		return nil, err
+	}
+	sumW2 := 0.0
+	for _, v := range w {
+		sumW2 += v * v
+	}
+	scale := 1 / (sampleRate * sumW2)
+
+	// 输出频率范围对应的FFT系数下标[lo, hi]
+	df := sampleRate / float64(size)
+	lo := int(math.Ceil(minFreq/df - 1e-9))
+	hi := int(math.Floor(maxFreq/df + 1e-9))
+	if hi > size/2 {
+		hi = size / 2
+	}
+	if lo > hi {
+		return nil, fmt.Errorf("频率范围%g~%gHz内没有频点，频率间隔为%gHz", minFreq, maxFreq, df)
+	}
+
+	spec := &Spectrogram{
+		Frequencies: make([]float64, hi-lo+1),
+		Scale:       params.Scale,
+		Resolution:  df,
+		FrameStep:   float64(hop) / sampleRate,
+	}
+	for k := range spec.Frequencies {
+		spec.Frequencies[k] = float64(lo+k) * df
+	}
+
+	fft := fourier.NewFFT(size)
+	buf := make([]float64, size)
+	var coeffs []complex128
+	for start := 0; start+size <= len(x); start += hop {
+		copy(buf, x[start:start+size])
+		detrend(buf, DetrendConstant)
+		for i, v := range w {
+			buf[i] *= v
+		}
+		coeffs = fft.Coefficients(coeffs, buf)
+
+		row := make([]float64, hi-lo+1)
+		for k := range row {
+			c := coeffs[lo+k]
+			p := (real(c)*real(c) + imag(c)*imag(c)) * scale
+			if lo+k > 0 && !(size%2 == 0 && lo+k == size/2) {
+				p *= 2
+			}
+			if params.Scale == ScaleLog {
+				p = 10 * math.Log10(math.Max(p, minLogPower))
+			}
+			row[k] = p
+		}
+		spec.Values = append(spec.Values, row)
+		spec.Times = append(spec.Times, startTime+(float64(start)+float64(size)/2)/sampleRate)
+	}
+	return spec, nil
+}
+
+// Spectrogram 计算通道数据的时频图，帧时间以通道第一个数据点的X值为起点
+func (p *Processor) Spectrogram(channel *data.Channel, params SpectrogramParams) (*Spectrogram, error) {
+	values := make([]float64, len(channel.Data))
+	for i, pt := range channel.Data {
+		values[i] = pt.Y
+	}
+
+	startTime := 0.0
+	if len(channel.Data) > 0 {
+		startTime = channel.Data[0].X
+	}
+	return ComputeSpectrogram(values, p.SampleRate, startTime, params)
+}
@@ -0,0 +1,195 @@
+package signal
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+// 参考值为scipy.signal.spectrogram(x, fs=10, window='hann', nperseg=16, noverlap=8)
+func TestSpectrogramSciPy(t *testing.T) {
+	x := make([]float64, 32)
+	for i := range x {
+		x[i] = math.Sin(0.7*float64(i)) + 0.1*float64(i)
+	}
+	want := [][]float64{
+		{0.007082847596, 0.5488810516, 0.5724525839, 0.05684792949, 0.000161901105, 9.440364957e-06, 1.539850022e-06, 5.221991768e-07, 1.791349635e-07},
+		{0.01398423307, 0.4063440902, 0.5269613346, 0.06053767953, 0.0002579676157, 2.291358767e-05, 4.626205648e-06, 1.449431274e-06, 4.310004648e-07},
+		{0.005789672228, 0.1987881739, 0.4676077305, 0.06588818654, 0.0004053233316, 4.320119429e-05, 8.523303366e-06, 1.95189003e-06, 3.541253448e-07},
+	}
+
+	params := SpectrogramParams{WindowSize: 16, Window: WindowHann, Scale: ScaleLinear}
+	spec, err := ComputeSpectrogram(x, 10, 0, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spec.Values) != len(want) || !coefficientsNear(spec.Times, []float64{0.8, 1.6, 2.4}, 1e-12) {
+		t.Fatalf("%d帧, Times = %v", len(spec.Values), spec.Times)
+	}
+	for i := range want {
+		if !coefficientsNear(spec.Values[i], want[i], 1e-8) {
+			t.Errorf("Values[%d] = %v, want %v", i, spec.Values[i], want[i])
+		}
+	}
+	if spec.Resolution != 0.625 || spec.FrameStep != 0.8 || len(spec.Frequencies) != 9 || spec.Frequencies[8] != 5 {
+		t.Errorf("Resolution = %g, FrameStep = %g, Frequencies = %v", spec.Resolution, spec.FrameStep, spec.Frequencies)
+	}
+
+	// 对数标度为分贝值；频率范围只保留1~3Hz内的频点
+	params.Scale = ScaleLog
+	params.MinFreq, params.MaxFreq = 1, 3
+	spec, err = ComputeSpectrogram(x, 10, 100, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !coefficientsNear(spec.Frequencies, []float64{1.25, 1.875, 2.5}, 1e-12) || spec.Times[0] != 100.8 {
+		t.Fatalf("Frequencies = %v, Times = %v", spec.Frequencies, spec.Times)
+	}
+	for k, v := range spec.Values[1] {
+		if db := 10 * math.Log10(want[1][k+2]); math.Abs(v-db) > 1e-6 {
+			t.Errorf("Values[1][%d] = %gdB, want %gdB", k, v, db)
+		}
+	}
+}
+
+func TestSpectrogramTracksFrequency(t *testing.T) {
+	// 前5秒为10Hz正弦，之后为40Hz
+	fs := 256.0
+	x := make([]float64, 2560)
+	for i := range x {
+		tt := float64(i) / fs
+		f := 10.0
+		if tt > 5 {
+			f = 40
+		}
+		x[i] = math.Sin(2 * math.Pi * f * tt)
+	}
+
+	spec, err := ComputeSpectrogram(x, fs, 0, DefaultSpectrogramParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	peak := func(row []float64) float64 {
+		best := 0
+		for j := range row {
+			if row[j] > row[best] {
+				best = j
+			}
+		}
+		return spec.Frequencies[best]
+	}
+	if f := peak(spec.Values[2]); f != 10 {
+		t.Errorf("开始时的谱峰频率 = %g, want 10", f)
+	}
+	if f := peak(spec.Values[len(spec.Values)-2]); f != 40 {
+		t.Errorf("结束时的谱峰频率 = %g, want 40", f)
+	}
+
+	// 线性标度的功率谱密度积分等于正弦的均方值
+	params := DefaultSpectrogramParams()
+	params.Scale = ScaleLinear
+	spec, err = ComputeSpectrogram(x, fs, 0, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := 0.0
+	for _, v := range spec.Values[1] {
+		total += v * spec.Resolution
+	}
+	if math.Abs(total-0.5) > 0.01 {
+		t.Errorf("功率谱密度的积分 = %g, want 0.5", total)
+	}
+}
+
+func TestSpectrogramErrors(t *testing.T) {
+	x := make([]float64, 1000)
+	valid := DefaultSpectrogramParams()
+	tests := map[string]func(*SpectrogramParams){
+		"窗长小于2":       func(p *SpectrogramParams) { p.WindowSize = 1 },
+		"窗长超过数据点数":    func(p *SpectrogramParams) { p.WindowSize = 2000 },
+		"帧间隔为负数":      func(p *SpectrogramParams) { p.Hop = -1 },
+		"标度":          func(p *SpectrogramParams) { p.Scale = "sqrt" },
+		"窗函数":         func(p *SpectrogramParams) { p.Window = "triangle" },
+		"最低频率不小于最高频率": func(p *SpectrogramParams) { p.MinFreq, p.MaxFreq = 50, 40 },
+		"频率范围内没有频点":   func(p *SpectrogramParams) { p.MinFreq, p.MaxFreq = 10.1, 10.2 },
+	}
+	for name, modify := range tests {
+		params := valid
+		modify(&params)
+		if _, err := ComputeSpectrogram(x, 500, 0, params); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+	if _, err := ComputeSpectrogram(x, 0, 0, valid); err == nil {
+		t.Error("采样率为0时应返回错误")
+	}
+}
+
+func TestProcessorSpectrogram(t *testing.T) {
+	// 帧时间以通道第一个数据点的X值为起点，不修改ProcessedData
+	channel := data.NewChannel("0", "EEG")
+	for i := 0; i < 1024; i++ {
+		channel.AddDataPoint(30+float64(i)/256, math.Sin(2*math.Pi*10*float64(i)/256))
+	}
+	params := DefaultSpectrogramParams()
+	params.Hop = 128
+	spec, err := NewProcessor(256).Spectrogram(channel, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spec.Times) != 7 || spec.Times[0] != 30.5 || spec.FrameStep != 0.5 || len(channel.ProcessedData) != 0 {
+		t.Errorf("Times = %v, FrameStep = %g", spec.Times, spec.FrameStep)
+	}
+}
+
+func TestProcessSpectrogram(t *testing.T) {
+	channel := data.NewChannel("0", "EEG")
+	for i := 0; i < 1024; i++ {
+		channel.AddDataPoint(float64(i)/256, math.Sin(2*math.Pi*10*float64(i)/256))
+	}
+	p := NewProcessor(256)
+
+	result, err := p.ProcessResult(channel, TaskSpectrogram, []byte(`{"window_size": 128, "hop": 64, "scale": "linear"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec, ok := result.(*Spectrogram)
+	if !ok {
+		t.Fatalf("ProcessResult返回%T，应为*Spectrogram", result)
+	}
+	if len(spec.Times) != 15 || spec.Resolution != 2 || spec.Scale != ScaleLinear || len(channel.ProcessedData) != 0 {
+		t.Errorf("%d帧，分辨率%g，标度%s", len(spec.Times), spec.Resolution, spec.Scale)
+	}
+
+	// 结果按Spectrogram的JSON字段序列化
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"times", "frequencies", "values", "scale", "resolution", "frame_step"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("JSON中缺少%s", key)
+		}
+	}
+
+	// 参数无效时返回错误且结果为nil
+	result, err = p.ProcessResult(channel, TaskSpectrogram, []byte(`{"window_size": 4096}`))
+	if err == nil || result != nil {
+		t.Errorf("ProcessResult = %v, %v", result, err)
+	}
+	if err := p.Process(channel, TaskSpectrogram, nil); err != nil {
+		t.Errorf("Process使用默认参数失败: %v", err)
+	}
+
+	// 其他任务的结果写入ProcessedData，不返回结果
+	result, err = p.ProcessResult(channel, TaskDifferential, nil)
+	if err != nil || result != nil || len(channel.ProcessedData) != 1024 {
+		t.Errorf("ProcessResult(differential) = %v, %v", result, err)
+	}
+}
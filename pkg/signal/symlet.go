@@ -0,0 +1,53 @@
+package signal
+
+// symletDecLo 是sym2~sym10的分解低通滤波器系数，按阶数索引
+// 与PyWavelets的pywt.Wavelet("symN").dec_lo相同。
+var symletDecLo = map[int][]float64{
+	2: {
+		-0.12940952255092145, 0.22414386804185735, 0.836516303737469, 0.48296291314469025,
+	},
+	3: {
+		0.035226291882100656, -0.08544127388224149, -0.13501102001039084, 0.4598775021193313,
+		0.8068915093133388, 0.3326705529509569,
+	},
+	4: {
+		-0.07576571478927333, -0.02963552764599851, 0.49761866763201545, 0.8037387518059161,
+		0.29785779560527736, -0.09921954357684722, -0.012603967262037833, 0.0322231006040427,
+	},
+	5: {
+		0.027333068345077982, 0.029519490925774643, -0.039134249302383094, 0.1993975339773936,
+		0.7234076904024206, 0.6339789634582119, 0.01660210576452232, -0.17532808990845047,
+		-0.021101834024758855, 0.019538882735286728,
+	},
+	6: {
+		0.015404109327027373, 0.0034907120842174702, -0.11799011114819057, -0.048311742585633,
+		0.4910559419267466, 0.787641141030194, 0.3379294217276218, -0.07263752278646252,
+		-0.021060292512300564, 0.04472490177066578, 0.0017677118642428036, -0.007800708325034148,
+	},
+	7: {
+		0.002681814568257878, -0.0010473848886829163, -0.01263630340325193, 0.03051551316596357,
+		0.0678926935013727, -0.049552834937127255, 0.017441255086855827, 0.5361019170917628,
+		0.767764317003164, 0.2886296317515146, -0.14004724044296152, -0.10780823770381774,
+		0.004010244871533663, 0.010268176708511255,
+	},
+	8: {
+		-0.0033824159510061256, -0.0005421323317911481, 0.03169508781149298, 0.007607487324917605,
+		-0.1432942383508097, -0.061273359067658524, 0.4813596512583722, 0.7771857517005235,
+		0.3644418948353314, -0.05194583810770904, -0.027219029917056003, 0.049137179673607506,
+		0.003808752013890615, -0.01495225833704823, -0.0003029205147213668, 0.0018899503327594609,
+	},
+	9: {
+		0.0014009155259146807, 0.0006197808889855868, -0.013271967781817119, -0.01152821020767923,
+		0.03022487885827568, 0.0005834627461258068, -0.05456895843083407, 0.238760914607303,
+		0.717897082764412, 0.6173384491409358, 0.035272488035271894, -0.19155083129728512,
+		-0.018233770779395985, 0.06207778930288603, 0.008859267493400484, -0.010264064027633142,
+		-0.0004731544986800831, 0.0010694900329086053,
+	},
+	10: {
+		0.0007701598091144901, 9.563267072289475e-05, -0.008641299277022422, -0.0014653825813050513,
+		0.0459272392310922, 0.011609893903711381, -0.15949427888491757, -0.07088053578324385,
+		0.47169066693843925, 0.7695100370211071, 0.38382676106708546, -0.03553674047381755,
+		-0.0319900568824278, 0.04999497207737669, 0.005764912033581909, -0.02035493981231129,
+		-0.0008043589320165449, 0.004593173585311828, 5.7036083618494284e-05, -0.0004593294210046588,
+	},
+}
@@ -0,0 +1,101 @@
+package signal
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+// 处理任务类型，对应API中的process_type
+const (
+	TaskFilter        = "filter"
+	TaskFFT           = "fft"
+	TaskDifferential  = "differential"
+	TaskMovingAverage = "moving_average"
+	TaskPSD           = "psd"
+	TaskWavelet       = "wavelet"
+	TaskSpectrogram   = "spectrogram"
+)
+
+// MovingAverageParams 表示移动平均处理的参数
+type MovingAverageParams struct {
+	WindowSize int `json:"window_size"`
+}
+
+// Process 按处理任务类型和JSON参数处理通道数据，结果写入ProcessedData
+func (p *Processor) Process(channel *data.Channel, processType string, parameters []byte) error {
+	_, err := p.ProcessResult(channel, processType, parameters)
+	return err
+}
+
+// ProcessResult 与Process相同，同时返回不能写入ProcessedData的结果，可直接序列化为JSON
+// spectrogram返回*Spectrogram且不修改ProcessedData，其他任务返回nil。
+func (p *Processor) ProcessResult(channel *data.Channel, processType string, parameters []byte) (interface{}, error) {
+	switch processType {
+	case TaskFilter:
+		var params FilterParams
+		if err := decodeParams(parameters, &params); err != nil {
+			return nil, err
+		}
+		return nil, p.ApplyFilter(channel, params)
+
+	case TaskFFT:
+		p.ApplyFFT(channel)
+		return nil, nil
+
+	case TaskPSD:
+		params := DefaultWelchParams()
+		if err := decodeParams(parameters, &params); err != nil {
+			return nil, err
+		}
+		_, err := p.ApplyWelch(channel, params)
+		return nil, err
+
+	case TaskWavelet:
+		params := DefaultWaveletParams()
+		if err := decodeParams(parameters, &params); err != nil {
+			return nil, err
+		}
+		return nil, p.ApplyWavelet(channel, params)
+
+	case TaskSpectrogram:
+		params := DefaultSpectrogramParams()
+		if err := decodeParams(parameters, &params); err != nil {
+			return nil, err
+		}
+		spectrogram, err := p.Spectrogram(channel, params)
+		if err != nil {
+			return nil, err
+		}
+		return spectrogram, nil
+
+	case TaskDifferential:
+		p.ApplyDifferential(channel)
+		return nil, nil
+
+	case TaskMovingAverage:
+		params := MovingAverageParams{WindowSize: 5}
+		if err := decodeParams(parameters, &params); err != nil {
+			return nil, err
+		}
+		if params.WindowSize < 1 {
+			return nil, fmt.Errorf("窗口大小必须大于0: %d", params.WindowSize)
+		}
+		p.ApplyMovingAverage(channel, params.WindowSize)
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("不支持的处理类型: %s", processType)
+}
+
+// 解析处理参数，参数为空时保留默认值
+func decodeParams(parameters []byte, v interface{}) error {
+	if len(parameters) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(parameters, v); err != nil {
+		return fmt.Errorf("解析处理参数失败: %w", err)
+	}
+	return nil
+}
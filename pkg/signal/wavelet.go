@@ -0,0 +1,416 @@
+package signal
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 小波族名称前缀，与PyWavelets的命名相同，如db4、sym8
+const (
+	WaveletDaubechies = "db"
+	WaveletSymlet     = "sym"
+)
+
+// 支持的消失矩阶数范围
+const (
+	maxDaubechiesOrder = 10
+	maxSymletOrder     = 10
+)
+
+// Wavelet 表示一个正交小波的分解和重构滤波器
+// 滤波器系数的顺序与PyWavelets相同：DecLo为RecLo的逆序，高通滤波器由低通滤波器正交镜像得到。
+type Wavelet struct {
+	Name  string
+	DecLo []float64
+	DecHi []float64
+	RecLo []float64
+	RecHi []float64
+}
+
+// 已构造的小波，按名称缓存
+var waveletCache sync.Map
+
+// NewWavelet 按名称创建小波，支持haar（即db1）、db1~db10和sym2~sym10
+// Daubechies滤波器由Daubechies多项式取最小相位零点的谱分解得到；Symlet的零点组合不唯一，
+// 直接使用与PyWavelets相同的系数表。
+func NewWavelet(name string) (*Wavelet, error) {
+	if v, ok := waveletCache.Load(name); ok {
+		return v.(*Wavelet), nil
+	}
+
+	family, order := name, 0
+	if name == "haar" {
+		family, order = WaveletDaubechies, 1
+	} else {
+		for _, prefix := range []string{WaveletDaubechies, WaveletSymlet} {
+			if strings.HasPrefix(name, prefix) {
+				n, err := strconv.Atoi(name[len(prefix):])
+				if err == nil {
+					family, order = prefix, n
+				}
+				break
+			}
+		}
+	}
+
+	var recLo []float64
+	switch {
+	case family == WaveletDaubechies && order >= 1 && order <= maxDaubechiesOrder:
+		recLo = spectralFactor(order, 0)
+	case family == WaveletSymlet && order >= 2 && order <= maxSymletOrder:
+		decLo := symletDecLo[order]
+		recLo = make([]float64, len(decLo))
+		for i, v := range decLo {
+			recLo[len(decLo)-1-i] = v
+		}
+	default:
+		return nil, fmt.Errorf("不支持的小波: %s", name)
+	}
+
+	n := len(recLo)
+	w := &Wavelet{
+		Name:  name,
+		DecLo: make([]float64, n),
+		DecHi: make([]float64, n),
+		RecLo: recLo,
+		RecHi: make([]float64, n),
+	}
+	for i := range recLo {
+		w.DecLo[i] = recLo[n-1-i]
+		w.RecHi[i] = recLo[n-1-i]
+		if i%2 == 1 {
+			w.RecHi[i] = -w.RecHi[i]
+		}
+	}
+	for i := range w.RecHi {
+		w.DecHi[i] = w.RecHi[n-1-i]
+	}
+
+	v, _ := waveletCache.LoadOrStore(name, w)
+	return v.(*Wavelet), nil
+}
+
+// 由N阶消失矩的Daubechies多项式构造重构低通滤波器，系数和为√2
+// H(z) ∝ (1+z⁻¹)^N·Q(z)，|Q|²由P(y) = Σ C(N-1+k, k)·y^k（y = sin²(ω/2)）给出，
+// P(y)的每个根对应z平面上互为倒数的一对零点，从每对中选一个。
+// mask的第g位为1时第g组根取单位圆外的零点，mask为0时得到最小相位的Daubechies滤波器；
+// mask超出根的组合数时返回nil。
+func spectralFactor(order, mask int) []float64 {
+	coeffs := make([]float64, order)
+	for k := range coeffs {
+		coeffs[k] = binomial(order-1+k, k)
+	}
+	yRoots := polyRoots(coeffs)
+
+	// 把根分组：实根单独一组，共轭复根为一组，同一组必须选同侧的零点以保证系数为实数
+	var groups [][]complex128
+	used := make([]bool, len(yRoots))
+	for i, y := range yRoots {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		if math.Abs(imag(y)) < 1e-10 {
+			groups = append(groups, []complex128{complex(real(y), 0)})
+			continue
+		}
+		for j := i + 1; j < len(yRoots); j++ {
+			if !used[j] && cmplx.Abs(yRoots[j]-cmplx.Conj(y)) < 1e-8 {
+				used[j] = true
+				break
+			}
+		}
+		groups = append(groups, []complex128{y, cmplx.Conj(y)})
+	}
+
+	// z + 1/z = 2 - 4y，inside为单位圆内的零点
+	inside := func(y complex128) complex128 {
+		b := 2 - 4*y
+		z := (b + cmplx.Sqrt(b*b-4)) / 2
+		if cmplx.Abs(z) > 1 {
+			z = 1 / z
+		}
+		return z
+	}
+
+	if mask >= 1<<len(groups) {
+		return nil
+	}
+
+	var zeros []complex128
+	for g, roots := range groups {
+		for _, y := range roots {
+			z := inside(y)
+			if mask&(1<<g) != 0 {
+				z = 1 / z
+			}
+			zeros = append(zeros, z)
+		}
+	}
+	for i := 0; i < order; i++ {
+		zeros = append(zeros, -1)
+	}
+	return normalizeFilter(polyFromRoots(zeros))
+}
+
+// 组合数C(n, k)
+func binomial(n, k int) float64 {
+	r := 1.0
+	for i := 1; i <= k; i++ {
+		r = r * float64(n-k+i) / float64(i)
+	}
+	return r
+}
+
+// 用Durand-Kerner迭代求实系数多项式Σ c[k]·x^k的全部复根
+func polyRoots(c []float64) []complex128 {
+	deg := len(c) - 1
+	if deg < 1 {
+		return nil
+	}
+
+	// 化为首一多项式
+	a := make([]complex128, deg+1)
+	for k := range c {
+		a[k] = complex(c[k]/c[deg], 0)
+	}
+	eval := func(x complex128) complex128 {
+		v := complex(0, 0)
+		for k := deg; k >= 0; k-- {
+			v = v*x + a[k]
+		}
+		return v
+	}
+
+	roots := make([]complex128, deg)
+	for i := range roots {
+		roots[i] = cmplx.Pow(complex(0.4, 0.9), complex(float64(i), 0))
+	}
+	for iter := 0; iter < 1000; iter++ {
+		maxStep := 0.0
+		for i := range roots {
+			den := complex(1, 0)
+			for j := range roots {
+				if j != i {
+					den *= roots[i] - roots[j]
+				}
+			}
+			step := eval(roots[i]) / den
+			roots[i] -= step
+			maxStep = math.Max(maxStep, cmplx.Abs(step))
+		}
+		if maxStep < 1e-15 {
+			break
+		}
+	}
+	return roots
+}
+
+// 由零点展开多项式Π(z - r)，返回按z的降幂排列的实系数
+func polyFromRoots(roots []complex128) []float64 {
+	p := []complex128{1}
+	for _, r := range roots {
+		next := make([]complex128, len(p)+1)
+		for i, v := range p {
+			next[i] += v
+			next[i+1] -= v * r
+		}
+		p = next
+	}
+
+	out := make([]float64, len(p))
+	for i, v := range p {
+		out[i] = real(v)
+	}
+	return out
+}
+
+// 将滤波器系数缩放为和为√2
+func normalizeFilter(h []float64) []float64 {
+	sum := 0.0
+	for _, v := range h {
+		sum += v
+	}
+	scale := math.Sqrt2 / sum
+	for i := range h {
+		h[i] *= scale
+	}
+	return h
+}
+
+// Len 返回滤波器长度
+func (w *Wavelet) Len() int {
+	return len(w.DecLo)
+}
+
+// DWTMaxLevel 返回长度为n的信号可分解的最大层数，与PyWavelets的dwt_max_level相同
+func DWTMaxLevel(n int, w *Wavelet) int {
+	if w.Len() < 2 || n < w.Len()-1 {
+		return 0
+	}
+	return int(math.Floor(math.Log2(float64(n) / float64(w.Len()-1))))
+}
+
+// DWT 对x做单层离散小波变换，边界采用对称延拓，返回近似系数和细节系数
+// 系数长度为⌊(len(x)+滤波器长度-1)/2⌋，与PyWavelets的symmetric模式相同。
+func DWT(x []float64, w *Wavelet) (approx, detail []float64) {
+	n, f := len(x), w.Len()
+	out := (n + f - 1) / 2
+	approx = make([]float64, out)
+	detail = make([]float64, out)
+	for i := 0; i < out; i++ {
+		for j := 0; j < f; j++ {
+			v := x[symmetricIndex(2*i+1-j, n)]
+			approx[i] += w.DecLo[j] * v
+			detail[i] += w.DecHi[j] * v
+		}
+	}
+	return approx, detail
+}
+
+// IDWT 由单层的近似系数和细节系数重构信号，结果长度为2·len(approx)-滤波器长度+2
+// detail为nil时按全零处理。
+func IDWT(approx, detail []float64, w *Wavelet) []float64 {
+	f := w.Len()
+	n := 2*len(approx) - f + 2
+	if n < 0 {
+		n = 0
+	}
+	x := make([]float64, n)
+	for k := range x {
+		// x[k] = Σ approx[i]·RecLo[k+f-2-2i] + detail[i]·RecHi[k+f-2-2i]
+		m := k + f - 2
+		iMin := (m - f + 2) / 2
+		if iMin < 0 {
+			iMin = 0
+		}
+		for i := iMin; i < len(approx) && 2*i <= m; i++ {
+			j := m - 2*i
+			if j >= f {
+				continue
+			}
+			x[k] += approx[i] * w.RecLo[j]
+			if detail != nil {
+				x[k] += detail[i] * w.RecHi[j]
+			}
+		}
+	}
+	return x
+}
+
+// 半样本对称延拓的下标：x[-1]=x[0]，x[n]=x[n-1]
+func symmetricIndex(i, n int) int {
+	period := 2 * n
+	i %= period
+	if i < 0 {
+		i += period
+	}
+	if i >= n {
+		i = period - 1 - i
+	}
+	return i
+}
+
+// WaveletCoeffs 表示多层小波分解的系数
+// Details[0]为第1层（最高频）的细节系数，Approx为最后一层的近似系数。
+type WaveletCoeffs struct {
+	Wavelet    *Wavelet
+	Approx     []float64
+	Details    [][]float64
+	Stationary bool  // 是否为平稳小波变换（各层系数长度都等于信号长度）
+	lengths    []int // 离散小波变换各层输入的长度，用于重构时截取
+}
+
+// Level 返回分解层数
+func (c *WaveletCoeffs) Level() int {
+	return len(c.Details)
+}
+
+// WaveDec 对x做level层离散小波分解，level为0时分解到DWTMaxLevel
+func WaveDec(x []float64, w *Wavelet, level int) (*WaveletCoeffs, error) {
+	level, err := checkWaveletLevel(len(x), w, level)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &WaveletCoeffs{Wavelet: w, Approx: x}
+	for i := 0; i < level; i++ {
+		c.lengths = append(c.lengths, len(c.Approx))
+		approx, detail := DWT(c.Approx, w)
+		c.Approx = approx
+		c.Details = append(c.Details, detail)
+	}
+	return c, nil
+}
+
+// SWT 对x做level层平稳小波变换（不抽取的à trous算法），边界采用周期延拓
+// 平稳小波变换具有平移不变性，去噪时不易产生伪吉布斯振荡，但计算量和存储量为DWT的level倍。
+func SWT(x []float64, w *Wavelet, level int) (*WaveletCoeffs, error) {
+	level, err := checkWaveletLevel(len(x), w, level)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(x)
+	c := &WaveletCoeffs{Wavelet: w, Approx: x, Stationary: true}
+	for j := 0; j < level; j++ {
+		step := 1 << j
+		approx := make([]float64, n)
+		detail := make([]float64, n)
+		for i := 0; i < n; i++ {
+			for k := 0; k < w.Len(); k++ {
+				v := c.Approx[(i+k*step)%n]
+				approx[i] += w.RecLo[k] * v
+				detail[i] += w.RecHi[k] * v
+			}
+		}
+		c.Approx = approx
+		c.Details = append(c.Details, detail)
+	}
+	return c, nil
+}
+
+// 检查分解层数，level为0时使用最大层数
+func checkWaveletLevel(n int, w *Wavelet, level int) (int, error) {
+	maxLevel := DWTMaxLevel(n, w)
+	if level == 0 {
+		level = maxLevel
+	}
+	if level < 1 || level > maxLevel {
+		return 0, fmt.Errorf("长度为%d的信号用%s最多可分解%d层，无法分解%d层", n, w.Name, maxLevel, level)
+	}
+	return level, nil
+}
+
+// Reconstruct 由小波系数重构信号
+func (c *WaveletCoeffs) Reconstruct() []float64 {
+	w := c.Wavelet
+	approx := c.Approx
+
+	if c.Stationary {
+		n := len(approx)
+		for j := len(c.Details) - 1; j >= 0; j-- {
+			step := 1 << j
+			detail := c.Details[j]
+			prev := make([]float64, n)
+			for i := 0; i < n; i++ {
+				for k := 0; k < w.Len(); k++ {
+					idx := ((i-k*step)%n + n) % n
+					prev[i] += w.RecLo[k]*approx[idx] + w.RecHi[k]*detail[idx]
+				}
+				prev[i] /= 2
+			}
+			approx = prev
+		}
+		return approx
+	}
+
+	for j := len(c.Details) - 1; j >= 0; j-- {
+		approx = IDWT(approx, c.Details[j], w)[:c.lengths[j]]
+	}
+	return approx
+}
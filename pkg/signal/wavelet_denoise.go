@@ -0,0 +1,221 @@
+package signal
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+// 小波处理的操作类型
+const (
+	WaveletDenoise  = "denoise"  // 细节系数阈值去噪
+	WaveletBaseline = "baseline" // 将近似系数置零以去除基线漂移
+)
+
+// 阈值选择规则
+const (
+	ThresholdUniversal = "universal" // VisuShrink：σ·√(2·ln n)
+	ThresholdSURE      = "sure"      // SureShrink：逐层最小化Stein无偏风险估计
+)
+
+// 阈值处理方式
+const (
+	ThresholdSoft = "soft"
+	ThresholdHard = "hard"
+)
+
+// 未指定时的小波参数
+const (
+	defaultWavelet        = "sym4"
+	defaultBaselineCutoff = 0.5 // Hz
+)
+
+// WaveletParams 表示小波处理的参数，对应处理任务中process_type为"wavelet"时的parameters
+type WaveletParams struct {
+	Operation      string  `json:"operation"`       // denoise或baseline
+	Wavelet        string  `json:"wavelet"`         // 小波名称，见NewWavelet
+	Level          int     `json:"level"`           // 分解层数，0表示自动：去噪时取最大层数，去基线时由baseline_cutoff确定
+	Stationary     bool    `json:"stationary"`      // 使用平稳小波变换
+	Threshold      string  `json:"threshold"`       // 去噪的阈值规则，universal或sure
+	Mode           string  `json:"mode"`            // 去噪的阈值处理方式，soft或hard
+	BaselineCutoff float64 `json:"baseline_cutoff"` // 去基线时近似系数频带的上限（Hz）
+}
+
+// DefaultWaveletParams 返回默认的小波参数：sym4小波、通用阈值软阈值去噪
+func DefaultWaveletParams() WaveletParams {
+	return WaveletParams{
+		Operation:      WaveletDenoise,
+		Wavelet:        defaultWavelet,
+		Threshold:      ThresholdUniversal,
+		Mode:           ThresholdSoft,
+		BaselineCutoff: defaultBaselineCutoff,
+	}
+}
+
+// 按参数分解信号
+func (wp WaveletParams) decompose(x []float64, w *Wavelet, level int) (*WaveletCoeffs, error) {
+	if wp.Stationary {
+		return SWT(x, w, level)
+	}
+	return WaveDec(x, w, level)
+}
+
+// WaveletDenoiseSignal 用小波阈值法对x去噪
+// 噪声标准差由第1层细节系数的中位数绝对偏差估计（σ = MAD/0.6745），各层细节系数按阈值收缩后重构，
+// 近似系数保持不变。
+func WaveletDenoiseSignal(x []float64, params WaveletParams) ([]float64, error) {
+	w, err := NewWavelet(params.Wavelet)
+	if err != nil {
+		return nil, err
+	}
+	shrink, err := thresholdFunc(params.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := params.decompose(x, w, params.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	sigma := medianAbs(c.Details[0]) / 0.6745
+	if sigma == 0 {
+		return c.Reconstruct(), nil
+	}
+	for _, detail := range c.Details {
+		var thr float64
+		switch params.Threshold {
+		case ThresholdUniversal:
+			thr = sigma * math.Sqrt(2*math.Log(float64(len(x))))
+		case ThresholdSURE:
+			thr = sigma * sureThreshold(detail, sigma)
+		default:
+			return nil, fmt.Errorf("不支持的阈值规则: %s", params.Threshold)
+		}
+		for i, v := range detail {
+			detail[i] = shrink(v, thr)
+		}
+	}
+	return c.Reconstruct(), nil
+}
+
+// WaveletRemoveBaseline 将小波分解的近似系数置零以去除基线漂移
+// 第L层近似系数覆盖0到fs/2^(L+1)的频带；Level为0时取使该频带上限不超过BaselineCutoff的最小层数，
+// 超过最大分解层数时使用最大层数。
+func WaveletRemoveBaseline(x []float64, sampleRate float64, params WaveletParams) ([]float64, error) {
+	w, err := NewWavelet(params.Wavelet)
+	if err != nil {
+		return nil, err
+	}
+
+	level := params.Level
+	if level == 0 {
+		if !(sampleRate > 0) || !(params.BaselineCutoff > 0) {
+			return nil, fmt.Errorf("无效的采样率%g或基线截止频率%g", sampleRate, params.BaselineCutoff)
+		}
+		level = int(math.Ceil(math.Log2(sampleRate/params.BaselineCutoff) - 1))
+		if maxLevel := DWTMaxLevel(len(x), w); level > maxLevel {
+			level = maxLevel
+		}
+		if level < 1 {
+			level = 1
+		}
+	}
+
+	c, err := params.decompose(x, w, level)
+	if err != nil {
+		return nil, err
+	}
+	for i := range c.Approx {
+		c.Approx[i] = 0
+	}
+	return c.Reconstruct(), nil
+}
+
+// 返回阈值处理函数
+func thresholdFunc(mode string) (func(v, thr float64) float64, error) {
+	switch mode {
+	case ThresholdSoft:
+		return func(v, thr float64) float64 {
+			if math.Abs(v) <= thr {
+				return 0
+			}
+			return math.Copysign(math.Abs(v)-thr, v)
+		}, nil
+	case ThresholdHard:
+		return func(v, thr float64) float64 {
+			if math.Abs(v) <= thr {
+				return 0
+			}
+			return v
+		}, nil
+	}
+	return nil, fmt.Errorf("不支持的阈值处理方式: %s", mode)
+}
+
+// 对按σ归一化的系数计算SURE阈值，上限为通用阈值√(2·ln n)
+// SURE(t) = n - 2·#{|x| ≤ t} + Σ min(|x|, t)²，在各|x|处取最小值。
+func sureThreshold(detail []float64, sigma float64) float64 {
+	n := len(detail)
+	sq := make([]float64, n)
+	for i, v := range detail {
+		a := v / sigma
+		sq[i] = a * a
+	}
+	sort.Float64s(sq)
+
+	universal := math.Sqrt(2 * math.Log(float64(n)))
+	best, bestRisk := universal, math.Inf(1)
+	cum := 0.0
+	for k, s := range sq {
+		// 阈值t = √s时，前k+1个系数被置零，其余系数的贡献为t²
+		cum += s
+		risk := float64(n) - 2*float64(k+1) + cum + float64(n-k-1)*s
+		if risk < bestRisk {
+			best, bestRisk = math.Sqrt(s), risk
+		}
+	}
+	return math.Min(best, universal)
+}
+
+// 返回绝对值的中位数
+func medianAbs(x []float64) float64 {
+	if len(x) == 0 {
+		return 0
+	}
+	a := make([]float64, len(x))
+	for i, v := range x {
+		a[i] = math.Abs(v)
+	}
+	sort.Float64s(a)
+	if n := len(a); n%2 == 0 {
+		return (a[n/2-1] + a[n/2]) / 2
+	}
+	return a[len(a)/2]
+}
+
+// ApplyWavelet 按参数对通道数据做小波去噪或去基线，结果写入ProcessedData
+func (p *Processor) ApplyWavelet(channel *data.Channel, params WaveletParams) error {
+	values := make([]float64, len(channel.Data))
+	for i, pt := range channel.Data {
+		values[i] = pt.Y
+	}
+
+	var out []float64
+	var err error
+	switch params.Operation {
+	case WaveletDenoise:
+		out, err = WaveletDenoiseSignal(values, params)
+	case WaveletBaseline:
+		out, err = WaveletRemoveBaseline(values, p.SampleRate, params)
+	default:
+		return fmt.Errorf("不支持的小波操作: %s", params.Operation)
+	}
+	if err != nil {
+		return err
+	}
+
+	return applyToChannel(channel, func([]float64) ([]float64, error) { return out, nil })
+}
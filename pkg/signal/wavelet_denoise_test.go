@@ -0,0 +1,207 @@
+package signal
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/ljx520ljx/chartSystem/internal/data"
+)
+
+// 两个序列差的均方根
+func rmsError(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum / float64(len(a)))
+}
+
+// 360Hz采样20秒的干净信号：1.3Hz正弦加每秒一个窄脉冲
+func cleanWaveletSignal() []float64 {
+	x := make([]float64, 20*360)
+	for i := range x {
+		t := float64(i) / 360
+		d := math.Mod(t, 1) - 0.5
+		x[i] = math.Sin(2*math.Pi*1.3*t) + 0.5*math.Exp(-d*d/0.0004)
+	}
+	return x
+}
+
+func TestWaveletDenoiseSignal(t *testing.T) {
+	clean := cleanWaveletSignal()
+	rng := rand.New(rand.NewSource(9))
+	noisy := make([]float64, len(clean))
+	for i := range noisy {
+		noisy[i] = clean[i] + 0.2*rng.NormFloat64()
+	}
+
+	// 各种组合都应把噪声的均方根0.2至少降低40%
+	for _, stationary := range []bool{false, true} {
+		for _, threshold := range []string{ThresholdUniversal, ThresholdSURE} {
+			for _, mode := range []string{ThresholdSoft, ThresholdHard} {
+				params := DefaultWaveletParams()
+				params.Wavelet = "sym8"
+				params.Stationary = stationary
+				params.Threshold = threshold
+				params.Mode = mode
+				if stationary {
+					params.Level = 6
+				}
+				y, err := WaveletDenoiseSignal(noisy, params)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(y) != len(noisy) {
+					t.Fatalf("len = %d, want %d", len(y), len(noisy))
+				}
+				if e := rmsError(y, clean); e > 0.12 {
+					t.Errorf("stationary=%v, %s, %s: 均方根误差 = %g", stationary, threshold, mode, e)
+				}
+			}
+		}
+	}
+
+	// 没有噪声的分段常数信号估计的σ为0，原样返回
+	step := make([]float64, 64)
+	for i := 32; i < 64; i++ {
+		step[i] = 1
+	}
+	params := DefaultWaveletParams()
+	params.Wavelet = "haar"
+	if y, err := WaveletDenoiseSignal(step, params); err != nil || !coefficientsNear(y, step, 1e-12) {
+		t.Errorf("WaveletDenoiseSignal = %v, %v", y, err)
+	}
+}
+
+func TestWaveletRemoveBaseline(t *testing.T) {
+	// 脉冲序列加0.15Hz漂移和直流偏置
+	clean := cleanWaveletSignal()
+	pulses := make([]float64, len(clean))
+	wander := make([]float64, len(clean))
+	for i := range clean {
+		t := float64(i) / 360
+		pulses[i] = clean[i] - math.Sin(2*math.Pi*1.3*t)
+		wander[i] = pulses[i] + 0.8*math.Sin(2*math.Pi*0.15*t) + 0.3
+	}
+	m := mean(pulses)
+	for i := range pulses {
+		pulses[i] -= m
+	}
+
+	for _, stationary := range []bool{false, true} {
+		params := DefaultWaveletParams()
+		params.Operation = WaveletBaseline
+		params.Stationary = stationary
+		y, err := WaveletRemoveBaseline(wander, 360, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := len(y)
+		if e := rmsError(y[500:n-500], pulses[500:n-500]); e > 0.05 {
+			t.Errorf("stationary=%v: 去基线后的均方根误差 = %g", stationary, e)
+		}
+	}
+
+	params := DefaultWaveletParams()
+	params.BaselineCutoff = 0
+	if _, err := WaveletRemoveBaseline(wander, 360, params); err == nil {
+		t.Error("基线截止频率为0时应返回错误")
+	}
+}
+
+func TestThresholdFunc(t *testing.T) {
+	soft, err := thresholdFunc(ThresholdSoft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hard, err := thresholdFunc(ThresholdHard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		v, soft, hard float64
+	}{
+		{3, 2, 3},
+		{-3, -2, -3},
+		{1, 0, 0},
+		{-0.5, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := soft(tt.v, 1); got != tt.soft {
+			t.Errorf("软阈值(%g) = %g, want %g", tt.v, got, tt.soft)
+		}
+		if got := hard(tt.v, 1); got != tt.hard {
+			t.Errorf("硬阈值(%g) = %g, want %g", tt.v, got, tt.hard)
+		}
+	}
+	if _, err := thresholdFunc("garrote"); err == nil {
+		t.Error("不支持的阈值处理方式应返回错误")
+	}
+}
+
+func TestSureThreshold(t *testing.T) {
+	// 纯噪声时SURE阈值接近通用阈值
+	rng := rand.New(rand.NewSource(5))
+	noise := make([]float64, 1024)
+	for i := range noise {
+		noise[i] = rng.NormFloat64()
+	}
+	universal := math.Sqrt(2 * math.Log(1024))
+	if thr := sureThreshold(noise, 1); thr > universal || thr < 0.5*universal {
+		t.Errorf("纯噪声的SURE阈值 = %g，通用阈值为%g", thr, universal)
+	}
+
+	// 系数为[0.25, 0.5, 1.5]σ时，阈值取0.5σ的风险最小
+	if thr := sureThreshold([]float64{1, -0.5, 3}, 2); math.Abs(thr-0.5) > 1e-12 {
+		t.Errorf("SURE阈值 = %g, want 0.5", thr)
+	}
+
+	if m := medianAbs([]float64{-3, 1, -2, 4}); m != 2.5 {
+		t.Errorf("medianAbs = %g, want 2.5", m)
+	}
+	if m := medianAbs([]float64{-3, 1, 2}); m != 2 {
+		t.Errorf("medianAbs = %g, want 2", m)
+	}
+}
+
+func TestProcessWavelet(t *testing.T) {
+	clean := cleanWaveletSignal()
+	channel := data.NewChannel("0", "ECG")
+	for i, v := range clean {
+		channel.AddDataPoint(float64(i)/360, v)
+	}
+	p := NewProcessor(360)
+
+	for _, params := range []string{
+		`{"wavelet": "db4", "threshold": "sure", "stationary": true, "level": 4}`,
+		`{"operation": "baseline"}`,
+		``,
+	} {
+		channel.ProcessedData = nil
+		if err := p.Process(channel, TaskWavelet, []byte(params)); err != nil {
+			t.Fatalf("%s: %v", params, err)
+		}
+		if len(channel.ProcessedData) != len(clean) || channel.ProcessedData[100].X != channel.Data[100].X {
+			t.Errorf("%s: len(ProcessedData) = %d", params, len(channel.ProcessedData))
+		}
+	}
+
+	// 参数无效时返回错误且不修改ProcessedData
+	channel.ProcessedData = []data.DataPoint{{X: 0, Y: 42}}
+	for _, params := range []string{
+		`{"wavelet": "coif3"}`,
+		`{"operation": "compress"}`,
+		`{"threshold": "minimax"}`,
+		`{"mode": "garrote"}`,
+		`{"level": 20}`,
+	} {
+		if err := p.Process(channel, TaskWavelet, []byte(params)); err == nil {
+			t.Errorf("%s: 应返回错误", params)
+		}
+	}
+	if len(channel.ProcessedData) != 1 || channel.ProcessedData[0].Y != 42 {
+		t.Errorf("ProcessedData = %v", channel.ProcessedData)
+	}
+}
@@ -0,0 +1,223 @@
+package signal
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestNewWaveletFilters(t *testing.T) {
+	// 参考值为PyWavelets的pywt.Wavelet(name).dec_lo
+	tests := map[string][]float64{
+		"haar": {0.7071067811865476, 0.7071067811865476},
+		"db2":  {-0.12940952255126037, 0.2241438680420134, 0.8365163037378079, 0.48296291314453416},
+		"db4": {-0.010597401785069032, 0.0328830116668852, 0.030841381835560764, -0.18703481171909309,
+			-0.027983769416859854, 0.6308807679298589, 0.7148465705529157, 0.2303778133088965},
+		"sym4": {-0.07576571478927333, -0.02963552764599851, 0.49761866763201545, 0.8037387518059161,
+			0.29785779560527736, -0.09921954357684722, -0.012603967262037833, 0.0322231006040427},
+		"sym8": {-0.0033824159510061256, -0.0005421323317911481, 0.03169508781149298, 0.007607487324917605,
+			-0.1432942383508097, -0.061273359067658524, 0.4813596512583722, 0.7771857517005235,
+			0.3644418948353314, -0.05194583810770904, -0.027219029917056003, 0.049137179673607506,
+			0.003808752013890615, -0.01495225833704823, -0.0003029205147213668, 0.0018899503327594609},
+		"sym7": {0.002681814568257878, -0.0010473848886829163, -0.01263630340325193, 0.03051551316596357,
+			0.0678926935013727, -0.049552834937127255, 0.017441255086855827, 0.5361019170917628,
+			0.767764317003164, 0.2886296317515146, -0.14004724044296152, -0.10780823770381774,
+			0.004010244871533663, 0.010268176708511255},
+	}
+	for name, want := range tests {
+		w, err := NewWavelet(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !coefficientsNear(w.DecLo, want, 1e-10) {
+			t.Errorf("%s: DecLo = %v, want %v", name, w.DecLo, want)
+		}
+	}
+}
+
+func TestSymletSpectralFactor(t *testing.T) {
+	// 每个Symlet系数表都是同阶Daubechies多项式的一种谱分解，可能相差时间反转
+	for order := 2; order <= maxSymletOrder; order++ {
+		w, err := NewWavelet(fmt.Sprintf("sym%d", order))
+		if err != nil {
+			t.Fatal(err)
+		}
+		reversed := make([]float64, w.Len())
+		for i, v := range w.RecLo {
+			reversed[w.Len()-1-i] = v
+		}
+
+		found := false
+		for mask := 0; !found; mask++ {
+			h := spectralFactor(order, mask)
+			if h == nil {
+				break
+			}
+			found = coefficientsNear(h, w.RecLo, 1e-10) || coefficientsNear(h, reversed, 1e-10)
+		}
+		if !found {
+			t.Errorf("sym%d的系数不是%d阶Daubechies多项式的谱分解", order, order)
+		}
+	}
+
+	// mask为0时为最小相位的Daubechies滤波器
+	if h := spectralFactor(2, 0); !coefficientsNear(h, []float64{0.48296291314453416, 0.8365163037378079, 0.2241438680420134, -0.12940952255126037}, 1e-12) {
+		t.Errorf("spectralFactor(2, 0) = %v", h)
+	}
+	if spectralFactor(2, 2) != nil {
+		t.Error("mask超出根的组合数时应返回nil")
+	}
+}
+
+func TestNewWaveletOrthogonal(t *testing.T) {
+	// 低通滤波器的偶数位移自相关为δ，系数和为√2，高通滤波器系数和为0
+	names := []string{"db1", "db3", "db6", "db10"}
+	for order := 2; order <= maxSymletOrder; order++ {
+		names = append(names, fmt.Sprintf("sym%d", order))
+	}
+	for _, name := range names {
+		w, err := NewWavelet(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := w.DecLo
+		for shift := 0; shift < len(h); shift += 2 {
+			sum := 0.0
+			for i := 0; i+shift < len(h); i++ {
+				sum += h[i] * h[i+shift]
+			}
+			want := 0.0
+			if shift == 0 {
+				want = 1
+			}
+			if math.Abs(sum-want) > 1e-10 {
+				t.Errorf("%s: 位移%d的自相关 = %g", name, shift, sum)
+			}
+		}
+		if lo, hi := mean(w.DecLo)*float64(w.Len()), mean(w.DecHi)*float64(w.Len()); math.Abs(lo-math.Sqrt2) > 1e-10 || math.Abs(hi) > 1e-10 {
+			t.Errorf("%s: 低通系数和 = %g, 高通系数和 = %g", name, lo, hi)
+		}
+	}
+
+	// 同名的小波只构造一次
+	a, _ := NewWavelet("db6")
+	b, _ := NewWavelet("db6")
+	if a != b {
+		t.Error("NewWavelet未缓存")
+	}
+
+	for _, name := range []string{"sym1", "db0", "db11", "sym11", "coif3", "db", ""} {
+		if _, err := NewWavelet(name); err == nil {
+			t.Errorf("NewWavelet(%q)应返回错误", name)
+		}
+	}
+}
+
+func TestDWT(t *testing.T) {
+	// PyWavelets文档中的示例：pywt.dwt([1, 2, 3, 4, 5, 6], 'db1')
+	haar, err := NewWavelet("haar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	approx, detail := DWT([]float64{1, 2, 3, 4, 5, 6}, haar)
+	s := math.Sqrt2 / 2
+	if !coefficientsNear(approx, []float64{3 * s, 7 * s, 11 * s}, 1e-12) || !coefficientsNear(detail, []float64{-s, -s, -s}, 1e-12) {
+		t.Errorf("DWT = %v, %v", approx, detail)
+	}
+
+	// pywt.wavedec([1, 2, 3, 4, 5, 6, 7, 8], 'db1', level=2)
+	c, err := WaveDec([]float64{1, 2, 3, 4, 5, 6, 7, 8}, haar, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Level() != 2 || !coefficientsNear(c.Approx, []float64{5, 13}, 1e-12) || !coefficientsNear(c.Details[1], []float64{-2, -2}, 1e-12) {
+		t.Errorf("WaveDec = %v, %v", c.Approx, c.Details)
+	}
+
+	// 系数长度与PyWavelets的symmetric模式相同
+	db4, err := NewWavelet("db4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	approx, detail = DWT(make([]float64, 100), db4)
+	if len(approx) != 53 || len(detail) != 53 {
+		t.Errorf("系数长度 = %d, want 53", len(approx))
+	}
+	if n := DWTMaxLevel(1000, db4); n != 7 {
+		t.Errorf("DWTMaxLevel(1000, db4) = %d, want 7", n)
+	}
+	if _, err := WaveDec(make([]float64, 100), db4, 5); err == nil {
+		t.Error("超过最大分解层数时应返回错误")
+	}
+	if _, err := SWT(make([]float64, 5), db4, 0); err == nil {
+		t.Error("信号短于滤波器时应返回错误")
+	}
+}
+
+func TestWaveletReconstruct(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for _, name := range []string{"haar", "db2", "db4", "sym4", "sym8", "db10"} {
+		w, err := NewWavelet(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, n := range []int{64, 100, 257, 1001} {
+			x := make([]float64, n)
+			for i := range x {
+				x[i] = rng.NormFloat64()
+			}
+
+			c, err := WaveDec(x, w, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, err := SWT(x, w, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, d := range s.Details {
+				if len(d) != n {
+					t.Fatalf("%s: 平稳小波变换的系数长度 = %d, want %d", name, len(d), n)
+				}
+			}
+
+			if y := c.Reconstruct(); !coefficientsNear(y, x, 1e-9) {
+				t.Errorf("%s, n=%d: 离散小波变换重构误差过大", name, n)
+			}
+			if y := s.Reconstruct(); !coefficientsNear(y, x, 1e-9) {
+				t.Errorf("%s, n=%d: 平稳小波变换重构误差过大", name, n)
+			}
+		}
+	}
+}
+
+func TestSWTShiftInvariant(t *testing.T) {
+	// 信号循环平移后，平稳小波变换的系数同样循环平移
+	w, err := NewWavelet("db2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(4))
+	x := make([]float64, 64)
+	for i := range x {
+		x[i] = rng.NormFloat64()
+	}
+	shifted := append(append([]float64(nil), x[5:]...), x[:5]...)
+
+	a, err := SWT(x, w, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := SWT(shifted, w, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for j := range a.Details {
+		for i := range x {
+			if math.Abs(b.Details[j][i]-a.Details[j][(i+5)%64]) > 1e-12 {
+				t.Fatalf("第%d层第%d个系数不满足平移不变性", j+1, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package signal
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/dsp/window"
+)
+
+// 窗函数名称
+const (
+	WindowRectangular    = "rectangular"
+	WindowHann           = "hann"
+	WindowHamming        = "hamming"
+	WindowBlackman       = "blackman"
+	WindowBlackmanHarris = "blackman_harris"
+	WindowFlatTop        = "flat_top"
+	WindowKaiser         = "kaiser"
+)
+
+// 未指定β时凯泽窗使用的参数，旁瓣衰减与布莱克曼窗相近
+const defaultKaiserBeta = 8.6
+
+// 由gonum实现的窗函数，原地乘以窗系数
+var windowFuncs = map[string]func([]float64) []float64{
+	WindowRectangular:    window.Rectangular,
+	WindowHann:           window.Hann,
+	WindowHamming:        window.Hamming,
+	WindowBlackman:       window.Blackman,
+	WindowBlackmanHarris: window.BlackmanHarris,
+	WindowFlatTop:        window.FlatTop,
+}
+
+// Window 返回长度为n的对称窗函数系数，用于FIR滤波器设计
+// beta只用于凯泽窗，为0时使用8.6。
+func Window(name string, n int, beta float64) ([]float64, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("窗长度必须大于0: %d", n)
+	}
+	if n == 1 {
+		return []float64{1}, nil
+	}
+
+	if name == WindowKaiser {
+		if beta == 0 {
+			beta = defaultKaiserBeta
+		}
+		return KaiserWindow(n, beta), nil
+	}
+
+	fn, ok := windowFuncs[name]
+	if !ok {
+		return nil, fmt.Errorf("不支持的窗函数: %s", name)
+	}
+	w := make([]float64, n)
+	for i := range w {
+		w[i] = 1
+	}
+	return fn(w), nil
+}
+
+// PeriodicWindow 返回长度为n的周期窗函数系数（长度为n+1的对称窗去掉最后一点），用于频谱分析
+func PeriodicWindow(name string, n int, beta float64) ([]float64, error) {
+	w, err := Window(name, n+1, beta)
+	if err != nil {
+		return nil, err
+	}
+	return w[:n], nil
+}
+
+// KaiserWindow 返回长度为n、参数为beta的凯泽窗系数
+func KaiserWindow(n int, beta float64) []float64 {
+	w := make([]float64, n)
+	if n == 1 {
+		w[0] = 1
+		return w
+	}
+
+	denom := besselI0(beta)
+	for i := range w {
+		r := 2*float64(i)/float64(n-1) - 1
+		w[i] = besselI0(beta*math.Sqrt(math.Max(0, 1-r*r))) / denom
+	}
+	return w
+}
+
+// KaiserBeta 根据阻带衰减（dB）计算凯泽窗的β
+func KaiserBeta(attenuation float64) float64 {
+	switch {
+	case attenuation > 50:
+		return 0.1102 * (attenuation - 8.7)
+	case attenuation > 21:
+		return 0.5842*math.Pow(attenuation-21, 0.4) + 0.07886*(attenuation-21)
+	}
+	return 0
+}
+
+// 第一类零阶修正贝塞尔函数，按级数求和
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	half := x / 2
+	for k := 1; k < 500; k++ {
+		term *= half / float64(k)
+		t := term * term
+		sum += t
+		if t < sum*1e-17 {
+			break
+		}
+	}
+	return sum
+}